@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"marketflow/internal/config"
+)
+
+func runCheckConfig(args []string) error {
+	fs, src := newFlagSet("check-config", "check-config [flags]")
+	quiet := fs.Bool("q", false, "only report problems, do not print the effective config")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+
+	cfg, err := config.Load(*src)
+	if err != nil {
+		return err
+	}
+
+	if !*quiet {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, kv := range cfg.Values() {
+			fmt.Fprintf(tw, "%s\t%s\n", kv[0], kv[1])
+		}
+		tw.Flush()
+	}
+	fmt.Fprintln(os.Stderr, "config OK")
+	return nil
+}
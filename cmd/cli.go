@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"marketflow/internal/adapters/redis"
+	"marketflow/internal/adapters/storage/postgres"
+	"marketflow/internal/config"
+	"marketflow/internal/logger"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"serve", "run the ingestion pipeline and HTTP API (default)", runServe},
+		{"migrate", "apply, revert or list schema migrations", runMigrate},
+		{"export", "export stored price stats as CSV or JSON", runExport},
+		{"replay", "aggregate recorded ticks and store them as price stats", runReplay},
+		{"query", "print latest, average, min and max prices for a pair", runQuery},
+		{"keys", "list, create or revoke API keys", runKeys},
+		{"check-config", "validate the configuration and print the effective values", runCheckConfig},
+		{"version", "print version information", runVersion},
+		{"help", "show help for a command", runHelp},
+	}
+}
+
+// errUsage is returned after a command has already printed its own usage.
+var errUsage = errors.New("usage")
+
+// Execute dispatches os.Args to a subcommand and exits with its status.
+func Execute() {
+	os.Exit(execute(os.Args[1:]))
+}
+
+func execute(args []string) int {
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	for _, c := range commands {
+		if c.name == name {
+			if err := c.run(args); err != nil {
+				if errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp) {
+					return 2
+				}
+				fmt.Fprintf(os.Stderr, "marketflow %s: %v\n", name, err)
+				return 1
+			}
+			return 0
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "marketflow: unknown command %q\n\n", name)
+	printUsage(os.Stderr)
+	return 2
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: marketflow <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", c.name, c.summary)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'marketflow help <command>' or 'marketflow <command> -h' for command flags.")
+}
+
+func runHelp(args []string) error {
+	if len(args) == 0 {
+		printUsage(os.Stdout)
+		return nil
+	}
+	for _, c := range commands {
+		if c.name == args[0] && c.name != "help" {
+			if err := c.run([]string{"-h"}); !errors.Is(err, errUsage) {
+				return err
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+// newFlagSet creates a command flag set with the shared config flags bound.
+func newFlagSet(name, usage string) (*flag.FlagSet, *config.Source) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	src := config.BindFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: marketflow %s\n\nFlags:\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs, src
+}
+
+// loadConfig loads the configuration and sets up logging on stderr, so
+// command output on stdout is not mixed with log lines.
+func loadConfig(src *config.Source, logLevel string) (*config.Config, error) {
+	cfg, err := config.Load(*src)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := src.Overrides["log.level"]; ok || os.Getenv("LOG_LEVEL") != "" {
+		logLevel = cfg.Log.Level
+	}
+	logger.InitWithWriter(os.Stderr, cfg.Env, logLevel)
+	return cfg, nil
+}
+
+func openRepo(cfg *config.Config) (*postgres.PostgresRepository, error) {
+	return postgres.NewPostgresRepository(cfg.Postgres.DSN(), repoOptions(cfg))
+}
+
+func repoOptions(cfg *config.Config) postgres.Options {
+	return postgres.Options{
+		MaxOpenConns:    cfg.Postgres.MaxOpenConns,
+		MaxIdleConns:    cfg.Postgres.MaxIdleConns,
+		ConnMaxLifetime: cfg.Postgres.ConnMaxLifetime,
+		CopyThreshold:   cfg.Postgres.CopyThreshold,
+		Timeouts: postgres.Timeouts{
+			Read:  cfg.Postgres.ReadTimeout,
+			Write: cfg.Postgres.WriteTimeout,
+			Batch: cfg.Postgres.BatchTimeout,
+		},
+	}
+}
+
+func openCache(cfg *config.Config) *redis.RedisCache {
+	return redis.NewRedisCache(cfg.Redis.Addr(), redis.Options{
+		Password:    cfg.Redis.Password,
+		DB:          cfg.Redis.DB,
+		PoolSize:    cfg.Redis.PoolSize,
+		TTL:         cfg.RedisTTL,
+		TickHorizon: cfg.Redis.TickHorizon,
+		Publish:     cfg.Redis.PubSub,
+	})
+}
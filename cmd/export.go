@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+type exportRow struct {
+	Exchange  string    `json:"exchange"`
+	Pair      string    `json:"pair"`
+	Timestamp time.Time `json:"timestamp"`
+	Average   float64   `json:"average"`
+	Min       float64   `json:"min"`
+	Max       float64   `json:"max"`
+}
+
+func runExport(args []string) error {
+	fs, src := newFlagSet("export", "export -exchange NAME -pair PAIR [flags]")
+	exchange := fs.String("exchange", "", "exchange name (required)")
+	pair := fs.String("pair", "", "trading pair, e.g. BTCUSDT (required)")
+	since := fs.Duration("since", 24*time.Hour, "export rows newer than this")
+	format := fs.String("format", "csv", "output format: csv or json")
+	out := fs.String("o", "-", "output file, - for stdout")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if *exchange == "" || *pair == "" {
+		fs.Usage()
+		return errUsage
+	}
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	cfg, err := loadConfig(src, "warn")
+	if err != nil {
+		return err
+	}
+	repo, err := openRepo(cfg)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	stats, err := repo.GetStats(context.Background(), *pair, *exchange, time.Now().Add(-*since))
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", *out, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if *format == "json" {
+		err = writeJSON(w, stats)
+	} else {
+		err = writeCSV(w, stats)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "exported %d rows\n", len(stats))
+	return nil
+}
+
+func writeCSV(w io.Writer, stats []domain.PriceStats) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"exchange", "pair", "timestamp", "average", "min", "max"}); err != nil {
+		return err
+	}
+	for _, s := range stats {
+		if err := cw.Write([]string{
+			s.Exchange,
+			s.Pair,
+			s.Timestamp.UTC().Format(time.RFC3339Nano),
+			strconv.FormatFloat(s.Average, 'f', -1, 64),
+			strconv.FormatFloat(s.Min, 'f', -1, 64),
+			strconv.FormatFloat(s.Max, 'f', -1, 64),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeJSON(w io.Writer, stats []domain.PriceStats) error {
+	rows := make([]exportRow, len(stats))
+	for i, s := range stats {
+		rows[i] = exportRow(s)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rows)
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"marketflow/internal/adapters/keyfile"
+	"marketflow/internal/app/auth"
+	"marketflow/internal/config"
+	"marketflow/internal/domain"
+)
+
+func runKeys(args []string) error {
+	fs, src := newFlagSet("keys", "keys [flags] list | create -name NAME -scopes SCOPE[,SCOPE] | revoke ID")
+	name := fs.String("name", "", "name of the key to create")
+	scopes := fs.String("scopes", "", "comma-separated scopes of the key to create: "+strings.Join(auth.Scopes, ", "))
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+
+	// Flags may follow the action: "keys create -name ci -scopes read:prices".
+	action := fs.Arg(0)
+	if err := fs.Parse(fs.Args()[min(1, fs.NArg()):]); err != nil {
+		return errUsage
+	}
+	valid := (action == "list" && fs.NArg() == 0) ||
+		(action == "create" && fs.NArg() == 0) ||
+		(action == "revoke" && fs.NArg() == 1)
+	if !valid {
+		fs.Usage()
+		return errUsage
+	}
+
+	cfg, err := loadConfig(src, "warn")
+	if err != nil {
+		return err
+	}
+	var repo domain.PriceRepository
+	if cfg.Auth.Store == "postgres" {
+		pg, err := openRepo(cfg)
+		if err != nil {
+			return err
+		}
+		defer pg.Close()
+		repo = pg
+	}
+	svc, err := openAuth(cfg, repo)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	who := domain.AuditEntry{KeyName: "cli"}
+	if user := os.Getenv("USER"); user != "" {
+		who.KeyName += ":" + user
+	}
+	switch action {
+	case "list":
+		keys, err := svc.Keys.ListKeys(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME\tSCOPES\tCREATED")
+		for _, k := range keys {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", k.ID, k.Name, strings.Join(k.Scopes, ","), k.CreatedAt.Format(time.RFC3339))
+		}
+		tw.Flush()
+	case "create":
+		var list []string
+		for _, s := range strings.Split(*scopes, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				list = append(list, s)
+			}
+		}
+		key, secret, err := svc.Create(ctx, *name, list)
+		who.Action, who.Target = "keys.create", key.ID
+		if err != nil {
+			who.Target, who.Error = *name, err.Error()
+		}
+		svc.Record(ctx, who)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created key %s (%s)\n%s\n", key.ID, key.Name, secret)
+		fmt.Fprintln(os.Stderr, "The key is shown only once; store it now.")
+	case "revoke":
+		err := svc.Keys.DeleteKey(ctx, fs.Arg(0))
+		who.Action, who.Target = "keys.delete", fs.Arg(0)
+		if err != nil {
+			who.Error = err.Error()
+		}
+		svc.Record(ctx, who)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("revoked key %s\n", fs.Arg(0))
+	}
+	return nil
+}
+
+// openAuth opens the key store selected by cfg.Auth.Store. repo must be the
+// Postgres repository when that is the store.
+func openAuth(cfg *config.Config, repo domain.PriceRepository) (*auth.Service, error) {
+	if cfg.Auth.Store == "file" {
+		store, err := keyfile.Open(cfg.Auth.KeysFile, cfg.Auth.AuditFile)
+		if err != nil {
+			return nil, err
+		}
+		return auth.NewService(store, store), nil
+	}
+
+	store, ok := repo.(interface {
+		domain.KeyStore
+		domain.AuditLog
+	})
+	if !ok {
+		return nil, errors.New("auth.store postgres needs storage postgres")
+	}
+	return auth.NewService(store, store), nil
+}
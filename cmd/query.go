@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+func runQuery(args []string) error {
+	fs, src := newFlagSet("query", "query [flags] PAIR")
+	exchange := fs.String("exchange", "ex1", "exchange name")
+	period := fs.Duration("period", time.Minute, "period for average, min and max")
+	timeout := fs.Duration("timeout", 10*time.Second, "query timeout")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	pair := fs.Arg(0)
+
+	cfg, err := loadConfig(src, "error")
+	if err != nil {
+		return err
+	}
+	repo, err := openRepo(cfg)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+	cache := openCache(cfg)
+	defer cache.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer tw.Flush()
+	fmt.Fprintf(tw, "exchange\t%s\n", *exchange)
+	fmt.Fprintf(tw, "pair\t%s\n", pair)
+
+	if update, err := cache.GetLatest(ctx, *exchange, pair); err == nil {
+		fmt.Fprintf(tw, "latest\t%g\t%s\t(redis)\n", update.Price, update.Time.Format(time.RFC3339))
+	} else if stat, err := repo.GetLatest(ctx, *exchange, pair); err == nil {
+		fmt.Fprintf(tw, "latest\t%g\t%s\t(postgres average)\n", stat.Average, stat.Timestamp.Format(time.RFC3339))
+	} else {
+		fmt.Fprintf(tw, "latest\t-\t\t(no data)\n")
+	}
+
+	stats, err := repo.GetByPeriod(ctx, *exchange, pair, *period)
+	if err != nil {
+		return err
+	}
+	if len(stats) == 0 {
+		fmt.Fprintf(tw, "period\t%s\t\t(no data)\n", *period)
+		return nil
+	}
+
+	var sum float64
+	minPrice, maxPrice := stats[0].Min, stats[0].Max
+	for _, s := range stats {
+		sum += s.Average
+		minPrice = min(minPrice, s.Min)
+		maxPrice = max(maxPrice, s.Max)
+	}
+	fmt.Fprintf(tw, "period\t%s\t%d rows\n", *period, len(stats))
+	fmt.Fprintf(tw, "average\t%g\n", sum/float64(len(stats)))
+	fmt.Fprintf(tw, "min\t%g\n", minPrice)
+	fmt.Fprintf(tw, "max\t%g\n", maxPrice)
+	return nil
+}
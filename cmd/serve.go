@@ -0,0 +1,439 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"marketflow/internal/adapters/grpcapi"
+	"marketflow/internal/adapters/memory"
+	"marketflow/internal/adapters/redis"
+	"marketflow/internal/adapters/storage/filestore"
+	"marketflow/internal/adapters/tiered"
+	"marketflow/internal/adapters/web"
+	"marketflow/internal/adapters/webhook"
+	"marketflow/internal/app/aggregator"
+	"marketflow/internal/app/alerts"
+	"marketflow/internal/app/analytics"
+	"marketflow/internal/app/auth"
+	"marketflow/internal/app/hub"
+	"marketflow/internal/app/indicators"
+	"marketflow/internal/app/leader"
+	"marketflow/internal/app/maintenance"
+	"marketflow/internal/app/mode"
+	"marketflow/internal/app/pipeline"
+	"marketflow/internal/app/query"
+	"marketflow/internal/app/reload"
+	"marketflow/internal/app/statscache"
+	"marketflow/internal/config"
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+func runServe(args []string) error {
+	fs, src := newFlagSet("serve", "serve [flags]")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+
+	cfg, err := config.Load(*src)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	logger.Init(cfg.Env, cfg.Log.Level)
+
+	logger.Info("starting application", "env", cfg.Env, "role", cfg.Role, "version", Version)
+
+	repo, cache, shared, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+	defer cache.Close()
+	if tc, ok := cache.(*tiered.Cache); ok {
+		go tc.Start(context.Background())
+	}
+
+	ticks := hub.New()
+	inputChan := make(chan domain.PriceUpdate, cfg.Pipeline.InputBuffer)
+
+	// Period query results are dropped when this instance stores a new row
+	// for them; elsewhere they expire after cache.query_ttl.
+	var results *statscache.Cache
+	var onFlush func([]domain.PriceStats)
+	if cfg.QueryCacheTTL > 0 {
+		results = statscache.New(cfg.QueryCacheTTL)
+		onFlush = results.InvalidateStats
+	}
+
+	// Every instance watches spreads on the ticks its hub sees; only the
+	// ingesting one records them.
+	var spreads *analytics.SpreadMonitor
+	var spreadHistory domain.SpreadStore
+	var recorder *analytics.SpreadRecorder
+	if cfg.Spread.Enabled {
+		spreads = analytics.NewSpreadMonitor(float64(cfg.Spread.ThresholdBps), cfg.Spread.MinDuration, cfg.Spread.MaxAge)
+		go spreads.Run(context.Background(), ticks.SubscribeAll("spreads").Updates)
+
+		var ok bool
+		if spreadHistory, ok = repo.(domain.SpreadStore); !ok {
+			spreadHistory = memory.NewSpreadStore()
+		}
+		recorder = &analytics.SpreadRecorder{
+			Monitor:   spreads,
+			Store:     spreadHistory,
+			Interval:  cfg.Spread.RecordInterval,
+			Retention: cfg.Spread.Retention,
+		}
+	}
+
+	// Indicators are tracked on every instance from the ticks its hub sees.
+	var indicatorService *indicators.Service
+	if cfg.Indicators.Enabled {
+		hourly, _ := repo.(domain.RollupRepository)
+		indicatorService = indicators.NewService(query.New(repo, cache, results), hourly,
+			cfg.Indicators.TrackAfter, cfg.Indicators.TrackTTL, cfg.Indicators.MaxTracked)
+		go indicatorService.Run(context.Background(), ticks.SubscribeAll("indicators").Updates)
+	}
+
+	// Any instance manages alerts; only the ingesting one evaluates them, so
+	// each event is delivered once.
+	var alertService *alerts.Service
+	var engine *alerts.Engine
+	if cfg.Alerts.Enabled {
+		store, ok := repo.(domain.AlertStore)
+		if !ok {
+			store = memory.NewAlertStore()
+		}
+		sender := webhook.NewSender(cfg.Alerts.WebhookTimeout, cfg.Alerts.AllowPrivateWebhooks)
+		alertService = alerts.NewService(store, cfg.Alerts.Cooldown)
+		alertService.CheckWebhook = sender.CheckURL
+		engine = &alerts.Engine{
+			Store:           store,
+			Notifier:        sender,
+			Query:           query.New(repo, cache, results),
+			Ticks:           ticks,
+			EvalInterval:    cfg.Alerts.EvalInterval,
+			RefreshInterval: cfg.Alerts.RefreshInterval,
+			Retries:         cfg.Alerts.Retries,
+			RetryBackoff:    cfg.Alerts.RetryBackoff,
+		}
+	}
+
+	var manager *mode.Manager
+	var agg *aggregator.Aggregator
+	switch {
+	case cfg.Role == "api":
+		if err := followTicks(context.Background(), shared, cache, ticks, nil); err != nil {
+			return err
+		}
+	case cfg.Leader.Enabled:
+		// Every tick reaches the hub through pub/sub, so followers stream the
+		// leader's ticks and a new leader needs no rewiring.
+		f := newFeed(cfg, repo, cache, nil, onFlush, inputChan)
+		f.spreads, f.alerts = recorder, engine
+		manager, agg = f.manager, f.agg
+		manager.Standby()
+
+		if shared == nil {
+			return errors.New("leader election needs a redis cache")
+		}
+		elector := leader.NewElector(shared.Lease(cfg.Leader.Key, instanceID()), cfg.Leader.Lease)
+		if err := followTicks(context.Background(), shared, cache, ticks, elector.IsLeader); err != nil {
+			return err
+		}
+		go elector.Run(context.Background(), f.run)
+	default:
+		f := newFeed(cfg, repo, cache, ticks.Publish, onFlush, inputChan)
+		f.spreads, f.alerts = recorder, engine
+		manager, agg = f.manager, f.agg
+		go f.run(context.Background())
+	}
+
+	if cleaner, ok := cache.(domain.CacheCleaner); ok {
+		go maintenance.NewCacheJob(cleaner, cfg.StaleAfter, cfg.CleanupInterval).Start(context.Background())
+	}
+
+	var authService *auth.Service
+	if cfg.Auth.Enabled {
+		if authService, err = openAuth(cfg, repo); err != nil {
+			return fmt.Errorf("failed to open api keys: %w", err)
+		}
+		logger.Info("api key authentication enabled", "store", cfg.Auth.Store)
+	} else {
+		logger.Warn("api key authentication is disabled; every endpoint is open")
+	}
+
+	var rateLimit *web.RateLimit
+	var grpcRateLimit *grpcapi.RateLimit
+	if cfg.RateLimit.Enabled {
+		limiter, err := openRateLimiter(cfg, shared)
+		if err != nil {
+			return err
+		}
+		rateLimit = &web.RateLimit{Limiter: limiter, HistoryCost: cfg.RateLimit.HistoryCost, Burst: cfg.RateLimit.Burst}
+		grpcRateLimit = &grpcapi.RateLimit{Limiter: limiter, HistoryCost: cfg.RateLimit.HistoryCost}
+	}
+
+	apiServer := web.NewServer(repo, cache, manager, ticks, web.Options{
+		Auth:          authService,
+		RateLimit:     rateLimit,
+		Results:       results,
+		BatchLimit:    cfg.BatchLimit,
+		WSOrigins:     cfg.WSOrigins,
+		Spreads:       spreads,
+		SpreadHistory: spreadHistory,
+		Alerts:        alertService,
+		Indicators:    indicatorService,
+	})
+
+	srv := &http.Server{
+		Addr:    cfg.APIAddr,
+		Handler: apiServer.Router(inputChan), // создадим метод Router() чуть ниже
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("API server failed", "error", err)
+			log.Fatalf("API server failed: %v", err)
+		}
+	}()
+
+	var grpcServer *grpc.Server
+	if cfg.GRPCAddr != "" {
+		lis, err := net.Listen("tcp", cfg.GRPCAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen for grpc: %w", err)
+		}
+		grpcServer = grpcapi.NewServer(repo, cache, manager, inputChan, ticks, grpcapi.Options{
+			Auth:      authService,
+			RateLimit: grpcRateLimit,
+			Results:   results,
+		}).GRPCServer()
+		go func() {
+			if err := grpcServer.Serve(lis); err != nil {
+				logger.Error("gRPC server failed", "error", err)
+				log.Fatalf("gRPC server failed: %v", err)
+			}
+		}()
+		logger.Info("gRPC API listening", "addr", cfg.GRPCAddr)
+	}
+
+	reloadCtx, reloadCancel := context.WithCancel(context.Background())
+	defer reloadCancel()
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	reloader := reload.NewReloader(*src, cfg, manager, agg, cache)
+	go reloader.Run(reloadCtx, hup)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+	<-stop
+	logger.Info("shutting down...")
+	reloadCancel()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Error("API shutdown error", "error", err)
+	}
+	if grpcServer != nil {
+		stopGRPC(shutdownCtx, grpcServer)
+	}
+	logger.Info("shutdown complete")
+	return nil
+}
+
+// stopGRPC waits for calls to finish until ctx is done, then cuts off the
+// rest; price streams never finish on their own.
+func stopGRPC(ctx context.Context, srv *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		srv.Stop()
+	}
+}
+
+// serveRepo and serveCache are what serve needs from either storage backend.
+type serveRepo interface {
+	domain.PriceRepository
+	Close() error
+}
+
+type serveCache interface {
+	domain.Cache
+	SetTTL(ttl time.Duration)
+	Close() error
+}
+
+// openStorage opens the backend selected by cfg.Storage: Postgres and Redis,
+// migrated if configured and with Redis behind an in-process tier unless
+// cache.flush_interval is 0; the file store with an in-process cache; or
+// process-local memory for both. shared is the Redis adapter itself, for
+// leases, rate limits and subscriptions, and nil without Redis.
+func openStorage(cfg *config.Config) (serveRepo, serveCache, *redis.RedisCache, error) {
+	switch cfg.Storage {
+	case "memory":
+		logger.Warn("using in-memory storage; data is lost on exit")
+		return memory.NewRepository(), memory.NewCache(cfg.RedisTTL, cfg.Redis.TickHorizon), nil, nil
+	case "file":
+		repo, err := filestore.Open(cfg.FileStore.Dir, filestore.Options{SegmentSize: int64(cfg.FileStore.SegmentSize)})
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to open file store: %w", err)
+		}
+		return repo, memory.NewCache(cfg.RedisTTL, cfg.Redis.TickHorizon), nil, nil
+	}
+
+	repo, err := openRepo(cfg)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to init postgres: %w", err)
+	}
+	if cfg.Postgres.AutoMigrate {
+		migrator, err := repo.Migrator()
+		if err == nil {
+			_, err = migrator.Up(context.Background())
+		}
+		if err != nil {
+			repo.Close()
+			return nil, nil, nil, fmt.Errorf("failed to migrate database: %w", err)
+		}
+	}
+	shared := openCache(cfg)
+	if cfg.FlushInterval > 0 {
+		return repo, tiered.New(shared, cfg.RedisTTL, cfg.FlushInterval), shared, nil
+	}
+	return repo, shared, shared, nil
+}
+
+// openRateLimiter keeps token buckets in process memory, or in Redis when
+// instances should share them.
+func openRateLimiter(cfg *config.Config, shared *redis.RedisCache) (domain.RateLimiter, error) {
+	if cfg.RateLimit.Store == "memory" {
+		return memory.NewRateLimiter(cfg.RateLimit.Rate, cfg.RateLimit.Burst), nil
+	}
+	if shared == nil {
+		return nil, errors.New("ratelimit.store redis needs a redis cache")
+	}
+	return shared.RateLimiter(cfg.RateLimit.Rate, cfg.RateLimit.Burst), nil
+}
+
+// feed is the ingesting side of an instance: exchange clients, workers,
+// aggregator, storage maintenance, spread recording and alerts.
+type feed struct {
+	manager *mode.Manager
+	agg     *aggregator.Aggregator
+	job     *maintenance.Job          // nil unless storage maintenance is enabled
+	spreads *analytics.SpreadRecorder // nil unless the spread monitor is enabled
+	alerts  *alerts.Engine            // nil unless alerts are enabled
+	input   chan domain.PriceUpdate
+}
+
+// newFeed starts the workers of an instance that ingests prices; run starts
+// the rest. observe, if not nil, sees every tick, and onFlush every batch the
+// aggregator stores.
+func newFeed(cfg *config.Config, repo serveRepo, cache serveCache, observe func(domain.PriceUpdate), onFlush func([]domain.PriceStats), inputChan chan domain.PriceUpdate) *feed {
+	outputChan := make(chan domain.PriceUpdate, cfg.Pipeline.OutputBuffer)
+
+	f := &feed{
+		manager: mode.NewManager(cfg),
+		agg:     aggregator.NewAggregator(outputChan, repo, cfg.AggregatorWindow),
+		input:   inputChan,
+	}
+	f.agg.OnFlush = onFlush
+	if store, ok := repo.(domain.StorageMaintainer); ok && cfg.Maintenance.Enabled {
+		f.job = maintenance.NewJob(store, domain.RetentionPolicy{
+			Raw:             cfg.Maintenance.RawRetention,
+			Hourly:          cfg.Maintenance.HourlyRetention,
+			PartitionsAhead: cfg.Maintenance.PartitionsAhead,
+		}, cfg.Maintenance.Interval)
+	}
+
+	for i := 0; i < cfg.Pipeline.Workers; i++ {
+		worker := &pipeline.Worker{
+			ID:      i,
+			Input:   inputChan,
+			Cache:   cache,
+			Output:  outputChan,
+			Observe: observe,
+		}
+		worker.Start(context.Background())
+	}
+	return f
+}
+
+// run ingests and aggregates until ctx is cancelled, then stands the exchange
+// clients by and waits for the aggregator to flush.
+func (f *feed) run(ctx context.Context) {
+	if err := f.manager.Resume(f.input); err != nil {
+		logger.Error("failed to start exchange clients", "error", err)
+	}
+	aggDone := make(chan struct{})
+	go func() {
+		defer close(aggDone)
+		f.agg.Start(ctx)
+	}()
+	if f.job != nil {
+		go f.job.Start(ctx)
+	}
+	if f.spreads != nil {
+		go f.spreads.Start(ctx)
+	}
+	if f.alerts != nil {
+		go f.alerts.Start(ctx)
+	}
+
+	<-ctx.Done()
+	f.manager.Standby()
+	<-aggDone
+}
+
+// followTicks feeds the ticks that feed instances publish on shared into the
+// local cache tier and the stream hub of an instance that does not ingest
+// them itself. The local tier is skipped while isLeader, if not nil, reports
+// true, since the leader's own workers already wrote those ticks.
+func followTicks(ctx context.Context, shared *redis.RedisCache, cache serveCache, ticks *hub.Hub, isLeader func() bool) error {
+	if shared == nil {
+		return errors.New("following published ticks needs a redis cache to subscribe to")
+	}
+	updates, err := shared.Subscribe(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to follow live prices: %w", err)
+	}
+
+	local, _ := cache.(*tiered.Cache)
+	go func() {
+		for update := range updates {
+			if local != nil && (isLeader == nil || !isLeader()) {
+				local.Observe(update)
+			}
+			ticks.Publish(update)
+		}
+		logger.Warn("live price subscription ended")
+	}()
+	return nil
+}
+
+// instanceID names this process in the leader lease.
+func instanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%d", host, os.Getpid(), time.Now().UnixNano())
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Set at build time with
+// -ldflags "-X marketflow/cmd.Version=... -X marketflow/cmd.Commit=... -X marketflow/cmd.BuildDate=...".
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildDate = "unknown"
+)
+
+func runVersion(args []string) error {
+	fs, _ := newFlagSet("version", "version")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	fmt.Printf("marketflow %s (commit %s, built %s, %s)\n", Version, Commit, BuildDate, runtime.Version())
+	return nil
+}
@@ -13,19 +13,23 @@ import (
 )
 
 type LiveClient struct {
-	ctx      context.Context
-	exchange string
-	addr     string
-	conn     net.Conn
-	stopCh   chan struct{}
+	ctx            context.Context
+	exchange       string
+	addr           string
+	dialTimeout    time.Duration
+	reconnectDelay time.Duration
+	conn           net.Conn
+	stopCh         chan struct{}
 }
 
-func NewTCPClient(ctx context.Context, exchange, addr string) *LiveClient {
+func NewTCPClient(ctx context.Context, exchange, addr string, dialTimeout, reconnectDelay time.Duration) *LiveClient {
 	return &LiveClient{
-		ctx:      ctx,
-		exchange: exchange,
-		addr:     addr,
-		stopCh:   make(chan struct{}),
+		ctx:            ctx,
+		exchange:       exchange,
+		addr:           addr,
+		dialTimeout:    dialTimeout,
+		reconnectDelay: reconnectDelay,
+		stopCh:         make(chan struct{}),
 	}
 }
 
@@ -48,7 +52,7 @@ func (c *LiveClient) Start(ctx context.Context, out chan<- domain.PriceUpdate) e
 					return ctx.Err()
 				case <-c.stopCh:
 					return nil
-				case <-time.After(5 * time.Second):
+				case <-time.After(c.reconnectDelay):
 					logger.Info("reconnecting", "exchange", c.exchange, "addr", c.addr)
 					continue
 				}
@@ -58,7 +62,7 @@ func (c *LiveClient) Start(ctx context.Context, out chan<- domain.PriceUpdate) e
 }
 
 func (c *LiveClient) connectAndRead(ctx context.Context, out chan<- domain.PriceUpdate) error {
-	conn, err := net.DialTimeout("tcp", c.addr, 5*time.Second)
+	conn, err := net.DialTimeout("tcp", c.addr, c.dialTimeout)
 	if err != nil {
 		return fmt.Errorf("failed to dial %s: %w", c.addr, err)
 	}
@@ -10,26 +10,28 @@ import (
 
 type TestGenerator struct {
 	exchange string
+	pairs    []string
+	interval time.Duration
 	stopCh   chan struct{}
 }
 
-func NewTestGenerator(exchange string) *TestGenerator {
+func NewTestGenerator(exchange string, pairs []string, interval time.Duration) *TestGenerator {
 	return &TestGenerator{
 		exchange: exchange,
+		pairs:    pairs,
+		interval: interval,
 		stopCh:   make(chan struct{}),
 	}
 }
 
 func (g *TestGenerator) Start(ctx context.Context, out chan<- domain.PriceUpdate) error {
-	pairs := []string{"BTCUSDT", "ETHUSDT", "DOGEUSDT", "TONUSDT", "SOLUSDT"}
-
-	ticker := time.NewTicker(1 * time.Second)
+	ticker := time.NewTicker(g.interval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
-			for _, pair := range pairs {
+			for _, pair := range g.pairs {
 				update := domain.PriceUpdate{
 					Exchange: g.exchange,
 					Pair:     pair,
@@ -50,12 +52,15 @@ func (g *TestGenerator) Stop() error {
 }
 
 func randomPrice(pair string) float64 {
-	base := map[string]float64{
+	base, ok := map[string]float64{
 		"BTCUSDT":  60000,
 		"ETHUSDT":  3000,
 		"DOGEUSDT": 0.12,
 		"TONUSDT":  5.5,
 		"SOLUSDT":  160,
 	}[pair]
+	if !ok {
+		base = 100
+	}
 	return base + rand.Float64()*base*0.02 // ±2%
 }
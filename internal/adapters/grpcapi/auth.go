@@ -0,0 +1,116 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"marketflow/internal/app/auth"
+	"marketflow/internal/domain"
+	"marketflow/pkg/pb"
+)
+
+// methodScopes is the scope each RPC needs, as on the matching HTTP routes.
+var methodScopes = map[string]string{
+	pb.MarketFlow_Latest_FullMethodName:          auth.ReadPrices,
+	pb.MarketFlow_Highest_FullMethodName:         auth.ReadPrices,
+	pb.MarketFlow_Lowest_FullMethodName:          auth.ReadPrices,
+	pb.MarketFlow_Average_FullMethodName:         auth.ReadPrices,
+	pb.MarketFlow_History_FullMethodName:         auth.ReadPrices,
+	pb.MarketFlow_SubscribePrices_FullMethodName: auth.ReadPrices,
+	pb.MarketFlow_SetMode_FullMethodName:         auth.AdminMode,
+}
+
+type apiKeyKey struct{}
+
+// authorize checks the call's API key, sent as "authorization: Bearer <key>"
+// or "x-api-key" metadata, against the method's scope. Calls to methods
+// without a scope are refused. With rate limiting on, calls whose key is
+// missing or unknown are charged to the peer's IP address, and once it runs
+// dry they are refused before the key is looked up.
+func (s *Server) authorize(ctx context.Context, method string) (context.Context, error) {
+	scope, ok := methodScopes[method]
+	if !ok {
+		return nil, toStatus(fmt.Errorf("method %s has no scope: %w", method, domain.ErrForbidden))
+	}
+	if err := s.admitUnauthenticated(ctx); err != nil {
+		return nil, err
+	}
+	key, err := s.auth.Authenticate(ctx, apiKeySecret(ctx))
+	if err != nil {
+		if errors.Is(err, domain.ErrUnauthenticated) {
+			s.chargeAuthFailure(ctx)
+		}
+		return nil, toStatus(err)
+	}
+	if !key.HasScope(scope) {
+		return nil, toStatus(fmt.Errorf("api key %s lacks scope %s: %w", key.ID, scope, domain.ErrForbidden))
+	}
+	return context.WithValue(ctx, apiKeyKey{}, key), nil
+}
+
+func (s *Server) authUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if s.auth == nil {
+		return handler(ctx, req)
+	}
+	ctx, err := s.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) authStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if s.auth == nil {
+		return handler(srv, ss)
+	}
+	ctx, err := s.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &keyedStream{ServerStream: ss, ctx: ctx})
+}
+
+// keyedStream carries the authorized key to the interceptors and handler
+// after authStream.
+type keyedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (k *keyedStream) Context() context.Context { return k.ctx }
+
+func apiKeySecret(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, h := range md.Get("authorization") {
+		if token, ok := strings.CutPrefix(h, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	if keys := md.Get("x-api-key"); len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
+
+// audit records an admin action taken by the call's key.
+func (s *Server) audit(ctx context.Context, action, target string, err error) {
+	if s.auth == nil {
+		return
+	}
+	key, _ := ctx.Value(apiKeyKey{}).(domain.APIKey)
+	entry := domain.AuditEntry{
+		KeyID:   key.ID,
+		KeyName: key.Name,
+		Action:  action,
+		Target:  target,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	s.auth.Record(context.WithoutCancel(ctx), entry)
+}
@@ -0,0 +1,59 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+// errorCode maps domain errors to a gRPC code, as web.errorStatus does to
+// HTTP statuses.
+func errorCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, errRateLimited):
+		return codes.ResourceExhausted
+	case errors.Is(err, domain.ErrInvalidArgument):
+		return codes.InvalidArgument
+	case errors.Is(err, domain.ErrUnauthenticated):
+		return codes.Unauthenticated
+	case errors.Is(err, domain.ErrForbidden):
+		return codes.PermissionDenied
+	case errors.Is(err, domain.ErrNotFound):
+		return codes.NotFound
+	case errors.Is(err, domain.ErrConflict):
+		return codes.FailedPrecondition
+	case domain.IsTimeout(err), errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, domain.ErrUnavailable):
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+// toStatus converts err to a gRPC status. Server-side errors are logged
+// with their details and reported to the client generically, as
+// web.clientMessage does.
+func toStatus(err error) error {
+	code := errorCode(err)
+	message := err.Error()
+	switch code {
+	case codes.Internal:
+		logger.Error("grpc call failed", "error", err)
+		message = "internal error"
+	case codes.Unavailable:
+		logger.Warn("grpc call failed", "error", err)
+		message = "service unavailable"
+	case codes.DeadlineExceeded:
+		logger.Warn("grpc call failed", "error", err)
+		message = "timed out"
+	}
+	return status.Error(code, message)
+}
@@ -0,0 +1,160 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+	"marketflow/pkg/pb"
+)
+
+var errRateLimited = errors.New("rate limit exceeded")
+
+// RateLimit meters calls with the same buckets and costs as the HTTP API:
+// pass the limiter web.RateLimit uses, so a client spends one bucket on
+// both. A latest price costs one token; calls that read stored history cost
+// HistoryCost, as does opening a stream.
+type RateLimit struct {
+	Limiter     domain.RateLimiter
+	HistoryCost int
+}
+
+const (
+	costLatest = 1
+
+	// costAuthFailure is charged to the peer's IP address for a call with a
+	// missing or unknown API key.
+	costAuthFailure = 1
+)
+
+// methodCost is the cost of each metered RPC; SetMode is not metered, like
+// the /mode routes.
+func (s *Server) methodCost(method string) int {
+	switch method {
+	case pb.MarketFlow_Latest_FullMethodName:
+		return costLatest
+	case pb.MarketFlow_Highest_FullMethodName, pb.MarketFlow_Lowest_FullMethodName,
+		pb.MarketFlow_Average_FullMethodName, pb.MarketFlow_History_FullMethodName,
+		pb.MarketFlow_SubscribePrices_FullMethodName:
+		return s.rateLimit.HistoryCost
+	}
+	return 0
+}
+
+// take charges the call's cost to the client's bucket. It answers
+// ResourceExhausted when the bucket is dry, and InvalidArgument when the
+// cost is more than the bucket could ever hold. The bucket's state goes
+// back in x-ratelimit-* headers. A limiter that fails lets the call
+// through, as on the HTTP API.
+func (s *Server) take(ctx context.Context, method string, setHeader func(metadata.MD) error) error {
+	cost := s.methodCost(method)
+	if cost == 0 {
+		return nil
+	}
+	res, err := s.rateLimit.Limiter.Take(ctx, clientKey(ctx), cost)
+	if err != nil {
+		logger.Warn("rate limiter failed, allowing call", "method", method, "error", err)
+		return nil
+	}
+
+	md := metadata.Pairs(
+		"x-ratelimit-limit", strconv.Itoa(res.Limit),
+		"x-ratelimit-remaining", strconv.Itoa(res.Remaining),
+		"x-ratelimit-reset", strconv.Itoa(ceilSeconds(res.Reset)),
+	)
+	switch {
+	case res.Allowed:
+		setHeader(md)
+		return nil
+	case cost > res.Limit:
+		setHeader(md)
+		return toStatus(fmt.Errorf("call costs %d tokens, more than the rate limit allows at once (%d): %w", cost, res.Limit, domain.ErrInvalidArgument))
+	default:
+		md.Set("retry-after", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+		setHeader(md)
+		return toStatus(fmt.Errorf("%w: call costs %d, %d left", errRateLimited, cost, res.Remaining))
+	}
+}
+
+func (s *Server) limitUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if s.rateLimit == nil {
+		return handler(ctx, req)
+	}
+	setHeader := func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }
+	if err := s.take(ctx, info.FullMethod, setHeader); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) limitStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if s.rateLimit == nil {
+		return handler(srv, ss)
+	}
+	if err := s.take(ss.Context(), info.FullMethod, ss.SetHeader); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// admitUnauthenticated is asked before an API key is looked up. It refuses
+// the call when the peer's IP address has spent its bucket on failed
+// attempts. It only looks at the bucket; chargeAuthFailure spends it.
+func (s *Server) admitUnauthenticated(ctx context.Context) error {
+	if s.rateLimit == nil {
+		return nil
+	}
+	res, err := s.rateLimit.Limiter.Take(ctx, peerKey(ctx), 0)
+	if err != nil {
+		logger.Warn("rate limiter failed, allowing call", "error", err)
+		return nil
+	}
+	if res.Remaining >= costAuthFailure {
+		return nil
+	}
+	return toStatus(fmt.Errorf("%w: too many calls without a valid api key", errRateLimited))
+}
+
+func (s *Server) chargeAuthFailure(ctx context.Context) {
+	if s.rateLimit == nil {
+		return
+	}
+	if _, err := s.rateLimit.Limiter.Take(ctx, peerKey(ctx), costAuthFailure); err != nil {
+		logger.Warn("rate limiter failed", "error", err)
+	}
+}
+
+// clientKey identifies who is metered, with the same keys as the HTTP API:
+// the API key when authentication is on, otherwise the peer address.
+func clientKey(ctx context.Context) string {
+	if key, ok := ctx.Value(apiKeyKey{}).(domain.APIKey); ok && key.ID != "" {
+		return "key:" + key.ID
+	}
+	return peerKey(ctx)
+}
+
+func peerKey(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "ip:"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+	return "ip:" + host
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
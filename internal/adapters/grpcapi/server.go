@@ -0,0 +1,223 @@
+// Package grpcapi serves the price API over gRPC for internal services. It
+// answers from the same repository, caches and tick hub as the HTTP API.
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"marketflow/internal/app/auth"
+	"marketflow/internal/app/hub"
+	"marketflow/internal/app/mode"
+	"marketflow/internal/app/query"
+	"marketflow/internal/app/statscache"
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+	"marketflow/pkg/pb"
+)
+
+type Server struct {
+	pb.UnimplementedMarketFlowServer
+
+	query   *query.Service
+	manager *mode.Manager // nil on api-only instances
+	input   chan<- domain.PriceUpdate
+	hub     *hub.Hub
+	auth    *auth.Service
+
+	rateLimit *RateLimit
+}
+
+// Options are the optional parts of a Server; each nil field is disabled.
+type Options struct {
+	Auth      *auth.Service
+	RateLimit *RateLimit
+	// Results caches and coalesces period queries; share the HTTP API's.
+	Results *statscache.Cache
+}
+
+func NewServer(repo domain.PriceRepository, cache domain.Cache, manager *mode.Manager, input chan<- domain.PriceUpdate, ticks *hub.Hub, opts Options) *Server {
+	return &Server{
+		query:   query.New(repo, cache, opts.Results),
+		manager: manager,
+		input:   input,
+		hub:     ticks,
+		auth:    opts.Auth,
+
+		rateLimit: opts.RateLimit,
+	}
+}
+
+// GRPCServer returns a gRPC server with the MarketFlow service registered
+// and, when enabled, API keys required on every call and calls rate limited.
+func (s *Server) GRPCServer() *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(s.authUnary, s.limitUnary),
+		grpc.ChainStreamInterceptor(s.authStream, s.limitStream),
+	)
+	pb.RegisterMarketFlowServer(srv, s)
+	return srv
+}
+
+func (s *Server) Latest(ctx context.Context, req *pb.PriceRequest) (*pb.LatestPrice, error) {
+	exchange, pair, err := pairParams(req.GetExchange(), req.GetPair())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	update, source, err := s.query.Latest(ctx, exchange, pair)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.LatestPrice{
+		Exchange: update.Exchange,
+		Pair:     update.Pair,
+		Price:    update.Price,
+		Time:     timestamppb.New(update.Time),
+		Source:   source,
+		Degraded: s.query.CacheHealth() != nil,
+	}, nil
+}
+
+func (s *Server) Highest(ctx context.Context, req *pb.PeriodRequest) (*pb.Price, error) {
+	exchange, pair, stats, err := s.periodStats(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	price, at := query.Highest(stats)
+	return &pb.Price{Exchange: exchange, Pair: pair, Price: price, Time: timestamppb.New(at)}, nil
+}
+
+func (s *Server) Lowest(ctx context.Context, req *pb.PeriodRequest) (*pb.Price, error) {
+	exchange, pair, stats, err := s.periodStats(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	price, at := query.Lowest(stats)
+	return &pb.Price{Exchange: exchange, Pair: pair, Price: price, Time: timestamppb.New(at)}, nil
+}
+
+func (s *Server) Average(ctx context.Context, req *pb.PeriodRequest) (*pb.Price, error) {
+	exchange, pair, stats, err := s.periodStats(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Price{Exchange: exchange, Pair: pair, Price: query.Average(stats)}, nil
+}
+
+func (s *Server) History(ctx context.Context, req *pb.PeriodRequest) (*pb.PriceHistory, error) {
+	exchange, pair, stats, err := s.periodStats(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	period, _ := periodParam(req.GetPeriod())
+
+	windows := make([]*pb.Window, len(stats))
+	for i, stat := range stats {
+		windows[i] = &pb.Window{Time: timestamppb.New(stat.Timestamp), Average: stat.Average, Min: stat.Min, Max: stat.Max}
+	}
+	return &pb.PriceHistory{
+		Exchange: exchange,
+		Pair:     pair,
+		Period:   durationpb.New(period),
+		Windows:  windows,
+	}, nil
+}
+
+func (s *Server) SetMode(ctx context.Context, req *pb.SetModeRequest) (*pb.SetModeResponse, error) {
+	if s.manager == nil {
+		return nil, toStatus(fmt.Errorf("api instances have no exchange clients to switch: %w", domain.ErrConflict))
+	}
+	var m mode.Mode
+	switch req.GetMode() {
+	case pb.Mode_MODE_TEST:
+		m = mode.Test
+	case pb.Mode_MODE_LIVE:
+		m = mode.Live
+	default:
+		return nil, toStatus(fmt.Errorf("invalid mode %s: %w", req.GetMode(), domain.ErrInvalidArgument))
+	}
+
+	err := s.manager.Start(s.input, m)
+	s.audit(ctx, "mode.set", string(m), err)
+	if err != nil {
+		logger.Error("failed to set mode", "mode", m, "error", err)
+		return nil, toStatus(err)
+	}
+	return &pb.SetModeResponse{Mode: req.GetMode()}, nil
+}
+
+// SubscribePrices sends live ticks until the client goes away or the
+// server stops.
+func (s *Server) SubscribePrices(req *pb.SubscribeRequest, stream pb.MarketFlow_SubscribePricesServer) error {
+	name := "grpc"
+	if p, ok := peer.FromContext(stream.Context()); ok {
+		name += " " + p.Addr.String()
+	}
+	sub := s.hub.Subscribe(name, req.GetExchange(), req.GetPair())
+	defer s.hub.Unsubscribe(sub)
+	logger.Info("grpc stream client connected", "exchange", sub.Exchange, "pair", sub.Pair)
+
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("grpc stream client disconnected", "exchange", sub.Exchange, "pair", sub.Pair)
+			return nil
+		case update := <-sub.Updates:
+			err := stream.Send(&pb.Tick{
+				Exchange: update.Exchange,
+				Pair:     update.Pair,
+				Price:    update.Price,
+				Time:     timestamppb.New(update.Time),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// periodStats resolves a period request and loads the matching stats,
+// returning errors as gRPC statuses.
+func (s *Server) periodStats(ctx context.Context, req *pb.PeriodRequest) (string, string, []domain.PriceStats, error) {
+	exchange, pair, err := pairParams(req.GetExchange(), req.GetPair())
+	if err != nil {
+		return "", "", nil, toStatus(err)
+	}
+	period, err := periodParam(req.GetPeriod())
+	if err != nil {
+		return "", "", nil, toStatus(err)
+	}
+	stats, err := s.query.Period(ctx, exchange, pair, period)
+	if err != nil {
+		return "", "", nil, toStatus(err)
+	}
+	return exchange, pair, stats, nil
+}
+
+// pairParams applies the HTTP API's default exchange.
+func pairParams(exchange, pair string) (string, string, error) {
+	if pair == "" {
+		return "", "", fmt.Errorf("pair must not be empty: %w", domain.ErrInvalidArgument)
+	}
+	if exchange == "" {
+		exchange = "ex1"
+	}
+	return exchange, pair, nil
+}
+
+func periodParam(d *durationpb.Duration) (time.Duration, error) {
+	if d == nil {
+		return time.Minute, nil
+	}
+	if err := d.CheckValid(); err != nil || d.AsDuration() <= 0 {
+		return 0, fmt.Errorf("invalid period %s: %w", d.AsDuration(), domain.ErrInvalidArgument)
+	}
+	return d.AsDuration(), nil
+}
@@ -0,0 +1,332 @@
+package grpcapi_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"marketflow/internal/adapters/grpcapi"
+	"marketflow/internal/adapters/memory"
+	"marketflow/internal/app/auth"
+	"marketflow/internal/app/hub"
+	"marketflow/internal/app/mode"
+	"marketflow/internal/config"
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+	"marketflow/pkg/pb"
+)
+
+func TestMain(m *testing.M) {
+	logger.InitWithWriter(io.Discard, "test", "error")
+	os.Exit(m.Run())
+}
+
+type fixture struct {
+	client  pb.MarketFlowClient
+	hub     *hub.Hub
+	manager *mode.Manager
+	now     time.Time
+}
+
+// newFixture serves a Server over an in-memory connection. The repository
+// holds one-minute windows of BTCUSDT on ex1 at now-3m, now-2m and now-1m,
+// and the cache its latest price.
+func newFixture(t *testing.T, opts grpcapi.Options) *fixture {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now()
+	repo := memory.NewRepository()
+	repo.StoreStatsBatch(ctx, []domain.PriceStats{
+		{Exchange: "ex1", Pair: "BTCUSDT", Timestamp: now.Add(-3 * time.Minute), Average: 100, Min: 95, Max: 105},
+		{Exchange: "ex1", Pair: "BTCUSDT", Timestamp: now.Add(-2 * time.Minute), Average: 110, Min: 108, Max: 120},
+		{Exchange: "ex1", Pair: "BTCUSDT", Timestamp: now.Add(-time.Minute), Average: 90, Min: 80, Max: 92},
+	})
+	cache := memory.NewCache(time.Minute, 0)
+	cache.SetLatest(ctx, domain.PriceUpdate{Exchange: "ex1", Pair: "BTCUSDT", Price: 91, Time: now})
+
+	// Test mode generates nothing within the test at this interval.
+	manager := mode.NewManager(&config.Config{Ingest: config.IngestConfig{TestInterval: time.Hour}})
+	t.Cleanup(manager.Stop)
+	ticks := hub.New()
+	input := make(chan domain.PriceUpdate, 16)
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpcapi.NewServer(repo, cache, manager, input, ticks, opts).GRPCServer()
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &fixture{client: pb.NewMarketFlowClient(conn), hub: ticks, manager: manager, now: now}
+}
+
+func wantCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != code {
+		t.Errorf("got %v (%v), want %v", got, err, code)
+	}
+}
+
+func TestPriceQueries(t *testing.T) {
+	f := newFixture(t, grpcapi.Options{})
+	ctx := context.Background()
+	period := durationpb.New(10 * time.Minute)
+	req := &pb.PeriodRequest{Pair: "BTCUSDT", Period: period}
+
+	latest, err := f.client.Latest(ctx, &pb.PriceRequest{Pair: "BTCUSDT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.GetExchange() != "ex1" || latest.GetPrice() != 91 || latest.GetSource() != "cache" {
+		t.Errorf("Latest = %v, want 91 on ex1 from cache", latest)
+	}
+
+	highest, err := f.client.Highest(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if highest.GetPrice() != 120 || !highest.GetTime().AsTime().Equal(f.now.Add(-2*time.Minute)) {
+		t.Errorf("Highest = %v at %v, want 120 at now-2m", highest.GetPrice(), highest.GetTime().AsTime())
+	}
+	lowest, err := f.client.Lowest(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lowest.GetPrice() != 80 {
+		t.Errorf("Lowest = %v, want 80", lowest.GetPrice())
+	}
+	average, err := f.client.Average(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if average.GetPrice() != 100 {
+		t.Errorf("Average = %v, want 100", average.GetPrice())
+	}
+
+	history, err := f.client.History(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history.GetWindows()) != 3 || history.GetPeriod().AsDuration() != 10*time.Minute {
+		t.Fatalf("History = %d windows over %v, want 3 over 10m", len(history.GetWindows()), history.GetPeriod().AsDuration())
+	}
+	if w := history.GetWindows()[1]; w.GetAverage() != 110 || w.GetMin() != 108 || w.GetMax() != 120 {
+		t.Errorf("second window = %v, want 110 within 108..120", w)
+	}
+
+	_, err = f.client.Latest(ctx, &pb.PriceRequest{Pair: "ETHUSDT"})
+	wantCode(t, err, codes.NotFound)
+	_, err = f.client.Average(ctx, &pb.PeriodRequest{Pair: "ETHUSDT", Period: period})
+	wantCode(t, err, codes.NotFound)
+	_, err = f.client.Highest(ctx, &pb.PeriodRequest{})
+	wantCode(t, err, codes.InvalidArgument)
+	_, err = f.client.History(ctx, &pb.PeriodRequest{Pair: "BTCUSDT", Period: durationpb.New(-time.Minute)})
+	wantCode(t, err, codes.InvalidArgument)
+}
+
+func TestSetMode(t *testing.T) {
+	f := newFixture(t, grpcapi.Options{})
+	ctx := context.Background()
+
+	resp, err := f.client.SetMode(ctx, &pb.SetModeRequest{Mode: pb.Mode_MODE_TEST})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.GetMode() != pb.Mode_MODE_TEST || f.manager.Mode() != mode.Test {
+		t.Errorf("SetMode answered %v with the manager in %q, want test", resp.GetMode(), f.manager.Mode())
+	}
+
+	_, err = f.client.SetMode(ctx, &pb.SetModeRequest{})
+	wantCode(t, err, codes.InvalidArgument)
+
+	// A follower refuses with ErrConflict.
+	f.manager.Standby()
+	_, err = f.client.SetMode(ctx, &pb.SetModeRequest{Mode: pb.Mode_MODE_LIVE})
+	wantCode(t, err, codes.FailedPrecondition)
+}
+
+func TestSubscribePrices(t *testing.T) {
+	f := newFixture(t, grpcapi.Options{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := f.client.SubscribePrices(ctx, &pb.SubscribeRequest{Exchange: "ex1", Pair: "BTCUSDT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The subscription starts on the server some time after the call
+	// returns, so publish until ticks come through.
+	go func() {
+		for ctx.Err() == nil {
+			f.hub.Publish(domain.PriceUpdate{Exchange: "ex2", Pair: "BTCUSDT", Price: 1, Time: time.Now()})
+			f.hub.Publish(domain.PriceUpdate{Exchange: "ex1", Pair: "ETHUSDT", Price: 2, Time: time.Now()})
+			f.hub.Publish(domain.PriceUpdate{Exchange: "ex1", Pair: "BTCUSDT", Price: 3, Time: time.Now()})
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+	for i := 0; i < 3; i++ {
+		tick, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tick.GetExchange() != "ex1" || tick.GetPair() != "BTCUSDT" || tick.GetPrice() != 3 {
+			t.Errorf("tick %d = %v, want only BTCUSDT on ex1", i, tick)
+		}
+	}
+}
+
+// keyStore holds keys by the hash of their secret.
+type keyStore struct {
+	domain.KeyStore
+	keys map[string]domain.APIKey
+}
+
+func (k keyStore) FindKey(ctx context.Context, hash string) (domain.APIKey, error) {
+	if key, ok := k.keys[hash]; ok {
+		return key, nil
+	}
+	return domain.APIKey{}, domain.ErrNotFound
+}
+
+type auditLog struct{ entries []domain.AuditEntry }
+
+func (a *auditLog) RecordAudit(ctx context.Context, entry domain.AuditEntry) error {
+	a.entries = append(a.entries, entry)
+	return nil
+}
+
+func TestAuthentication(t *testing.T) {
+	keys := keyStore{keys: map[string]domain.APIKey{
+		auth.Hash("reader"): {ID: "r", Scopes: []string{auth.ReadPrices}},
+		auth.Hash("admin"):  {ID: "a", Scopes: []string{auth.AdminMode}},
+	}}
+	audit := &auditLog{}
+	f := newFixture(t, grpcapi.Options{Auth: auth.NewService(keys, audit)})
+	withKey := func(secret string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+secret)
+	}
+	latest := &pb.PriceRequest{Pair: "BTCUSDT"}
+	setMode := &pb.SetModeRequest{Mode: pb.Mode_MODE_TEST}
+
+	_, err := f.client.Latest(context.Background(), latest)
+	wantCode(t, err, codes.Unauthenticated)
+	_, err = f.client.Latest(withKey("unknown"), latest)
+	wantCode(t, err, codes.Unauthenticated)
+	if _, err := f.client.Latest(withKey("reader"), latest); err != nil {
+		t.Errorf("Latest with a read:prices key: %v", err)
+	}
+	_, err = f.client.Latest(withKey("admin"), latest)
+	wantCode(t, err, codes.PermissionDenied)
+	_, err = f.client.SetMode(withKey("reader"), setMode)
+	wantCode(t, err, codes.PermissionDenied)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "admin")
+	if _, err := f.client.SetMode(ctx, setMode); err != nil {
+		t.Errorf("SetMode with an admin:mode key: %v", err)
+	}
+	if len(audit.entries) != 1 || audit.entries[0].KeyID != "a" || audit.entries[0].Action != "mode.set" {
+		t.Errorf("audit = %+v, want one mode.set by key a", audit.entries)
+	}
+
+	stream, err := f.client.SubscribePrices(context.Background(), &pb.SubscribeRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	wantCode(t, err, codes.Unauthenticated)
+}
+
+func TestRateLimit(t *testing.T) {
+	limiter := memory.NewRateLimiter(1, 10)
+	f := newFixture(t, grpcapi.Options{RateLimit: &grpcapi.RateLimit{Limiter: limiter, HistoryCost: 4}})
+	ctx := context.Background()
+	req := &pb.PeriodRequest{Pair: "BTCUSDT", Period: durationpb.New(10 * time.Minute)}
+
+	var header metadata.MD
+	if _, err := f.client.Highest(ctx, req, grpc.Header(&header)); err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Get("x-ratelimit-remaining"); len(got) != 1 || got[0] != "6" {
+		t.Errorf("x-ratelimit-remaining after a history call = %v, want 6", got)
+	}
+	if _, err := f.client.Average(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.client.Latest(ctx, &pb.PriceRequest{Pair: "BTCUSDT"}); err != nil {
+		t.Fatal(err)
+	}
+	// One token left: not enough for history, and mode changes are free.
+	_, err := f.client.History(ctx, req, grpc.Header(&header))
+	wantCode(t, err, codes.ResourceExhausted)
+	if got := header.Get("retry-after"); len(got) != 1 || got[0] == "0" {
+		t.Errorf("retry-after = %v, want a positive number of seconds", got)
+	}
+	if _, err := f.client.SetMode(ctx, &pb.SetModeRequest{Mode: pb.Mode_MODE_TEST}); err != nil {
+		t.Errorf("SetMode with a dry bucket: %v", err)
+	}
+	stream, err := f.client.SubscribePrices(ctx, &pb.SubscribeRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	wantCode(t, err, codes.ResourceExhausted)
+
+	// The bucket is the one the HTTP API charges for the same client.
+	if res, _ := limiter.Take(ctx, "ip:bufconn", 0); res.Remaining > 1 {
+		t.Errorf("shared bucket has %d tokens left, want at most 1", res.Remaining)
+	}
+}
+
+// countingKeys counts key lookups.
+type countingKeys struct {
+	keyStore
+	lookups int
+}
+
+func (k *countingKeys) FindKey(ctx context.Context, hash string) (domain.APIKey, error) {
+	k.lookups++
+	return k.keyStore.FindKey(ctx, hash)
+}
+
+func TestFailedAuthIsLimitedBeforeKeyLookup(t *testing.T) {
+	keys := &countingKeys{keyStore: keyStore{keys: map[string]domain.APIKey{
+		auth.Hash("reader"): {ID: "r", Scopes: []string{auth.ReadPrices}},
+	}}}
+	f := newFixture(t, grpcapi.Options{
+		Auth:      auth.NewService(keys, nil),
+		RateLimit: &grpcapi.RateLimit{Limiter: memory.NewRateLimiter(1, 3), HistoryCost: 1},
+	})
+	latest := func(secret string) error {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", secret)
+		_, err := f.client.Latest(ctx, &pb.PriceRequest{Pair: "BTCUSDT"})
+		return err
+	}
+
+	if err := latest("reader"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		wantCode(t, latest("bad"), codes.Unauthenticated)
+	}
+	before := keys.lookups
+	wantCode(t, latest("bad"), codes.ResourceExhausted)
+	wantCode(t, latest("reader"), codes.ResourceExhausted)
+	if keys.lookups != before {
+		t.Errorf("%d key lookups after the address ran dry, want none", keys.lookups-before)
+	}
+}
@@ -0,0 +1,164 @@
+package keyfile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+// Store keeps API keys in a JSON file and appends audit entries to a second
+// file as JSON lines, for deployments that keep their credentials next to
+// the config instead of in Postgres. The key file is rewritten in full on
+// every change, so it may also be edited by hand while serve is stopped.
+type Store struct {
+	path      string
+	auditPath string
+
+	mu   sync.Mutex
+	keys []domain.APIKey
+}
+
+type keyRecord struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Hash      string    `json:"sha256"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type auditRecord struct {
+	Time      time.Time `json:"time"`
+	KeyID     string    `json:"key_id"`
+	KeyName   string    `json:"key_name"`
+	Action    string    `json:"action"`
+	Target    string    `json:"target"`
+	RequestID string    `json:"request_id,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Open loads the keys in path, which need not exist yet.
+func Open(path, auditPath string) (*Store, error) {
+	s := &Store{path: path, auditPath: auditPath}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file %s: %w", path, err)
+	}
+	var records []keyRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("invalid key file %s: %w", path, err)
+	}
+	for _, rec := range records {
+		s.keys = append(s.keys, domain.APIKey(rec))
+	}
+	return s, nil
+}
+
+func (s *Store) CreateKey(ctx context.Context, key domain.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, k := range s.keys {
+		if k.ID == key.ID || k.Hash == key.Hash {
+			return fmt.Errorf("api key %s: %w", key.ID, domain.ErrConflict)
+		}
+	}
+	keys := append(s.keys[:len(s.keys):len(s.keys)], key)
+	if err := s.save(keys); err != nil {
+		return err
+	}
+	s.keys = keys
+	return nil
+}
+
+func (s *Store) FindKey(ctx context.Context, hash string) (domain.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, k := range s.keys {
+		if k.Hash == hash {
+			return k, nil
+		}
+	}
+	return domain.APIKey{}, fmt.Errorf("api key: %w", domain.ErrNotFound)
+}
+
+func (s *Store) ListKeys(ctx context.Context) ([]domain.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]domain.APIKey(nil), s.keys...), nil
+}
+
+func (s *Store) DeleteKey(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, k := range s.keys {
+		if k.ID != id {
+			continue
+		}
+		keys := append(append([]domain.APIKey(nil), s.keys[:i]...), s.keys[i+1:]...)
+		if err := s.save(keys); err != nil {
+			return err
+		}
+		s.keys = keys
+		return nil
+	}
+	return fmt.Errorf("api key %s: %w", id, domain.ErrNotFound)
+}
+
+// save replaces the key file through a temporary file, so a crash leaves
+// either the old or the new list.
+func (s *Store) save(keys []domain.APIKey) error {
+	records := make([]keyRecord, len(keys))
+	for i, k := range keys {
+		records[i] = keyRecord(k)
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) RecordAudit(ctx context.Context, entry domain.AuditEntry) error {
+	line, err := json.Marshal(auditRecord(entry))
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.auditPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"marketflow/internal/domain"
+)
+
+// maxDeliveries is how many deliveries AlertStore keeps per alert.
+const maxDeliveries = 100
+
+// AlertStore is a process-local domain.AlertStore, for storage backends
+// without alert tables.
+type AlertStore struct {
+	mu         sync.RWMutex
+	alerts     []domain.Alert
+	deliveries map[string][]domain.AlertDelivery // oldest first
+}
+
+func NewAlertStore() *AlertStore {
+	return &AlertStore{deliveries: make(map[string][]domain.AlertDelivery)}
+}
+
+func (s *AlertStore) CreateAlert(ctx context.Context, alert domain.Alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range s.alerts {
+		if a.ID == alert.ID {
+			return fmt.Errorf("alert %s: %w", alert.ID, domain.ErrConflict)
+		}
+	}
+	s.alerts = append(s.alerts, alert)
+	return nil
+}
+
+func (s *AlertStore) GetAlert(ctx context.Context, id string) (domain.Alert, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, a := range s.alerts {
+		if a.ID == id {
+			return a, nil
+		}
+	}
+	return domain.Alert{}, fmt.Errorf("alert %s: %w", id, domain.ErrNotFound)
+}
+
+func (s *AlertStore) ListAlerts(ctx context.Context) ([]domain.Alert, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]domain.Alert(nil), s.alerts...), nil
+}
+
+func (s *AlertStore) DeleteAlert(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, a := range s.alerts {
+		if a.ID == id {
+			s.alerts = append(s.alerts[:i:i], s.alerts[i+1:]...)
+			delete(s.deliveries, id)
+			return nil
+		}
+	}
+	return fmt.Errorf("alert %s: %w", id, domain.ErrNotFound)
+}
+
+func (s *AlertStore) RecordDelivery(ctx context.Context, d domain.AlertDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	found := false
+	for _, a := range s.alerts {
+		found = found || a.ID == d.AlertID
+	}
+	if !found {
+		return fmt.Errorf("alert %s: %w", d.AlertID, domain.ErrNotFound)
+	}
+	rows := append(s.deliveries[d.AlertID], d)
+	if len(rows) > maxDeliveries {
+		rows = append([]domain.AlertDelivery(nil), rows[len(rows)-maxDeliveries:]...)
+	}
+	s.deliveries[d.AlertID] = rows
+	return nil
+}
+
+func (s *AlertStore) ListDeliveries(ctx context.Context, alertID string, limit int) ([]domain.AlertDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rows := s.deliveries[alertID]
+	out := make([]domain.AlertDelivery, 0, min(limit, len(rows)))
+	for i := len(rows) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, rows[i])
+	}
+	return out, nil
+}
@@ -0,0 +1,144 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+type cacheEntry struct {
+	update    domain.PriceUpdate
+	expiresAt time.Time // zero means no expiry
+}
+
+// Cache is a process-local domain.Cache. Keys and TTL behave like the Redis
+// cache: latest:{exchange}:{pair}, expiring ttl after the last write. Like
+// the Redis cache it keeps the last tickHorizon of ticks per pair.
+type Cache struct {
+	mu      sync.RWMutex
+	entries map[string]cacheEntry
+	ttl     atomic.Int64
+
+	tickHorizon time.Duration
+	ticks       map[string][]domain.PriceUpdate
+}
+
+func NewCache(ttl, tickHorizon time.Duration) *Cache {
+	c := &Cache{
+		entries:     make(map[string]cacheEntry),
+		tickHorizon: tickHorizon,
+		ticks:       make(map[string][]domain.PriceUpdate),
+	}
+	c.SetTTL(ttl)
+	return c
+}
+
+func latestKey(exchange, pair string) string {
+	return fmt.Sprintf("latest:%s:%s", exchange, pair)
+}
+
+func (c *Cache) SetLatest(ctx context.Context, update domain.PriceUpdate) error {
+	entry := cacheEntry{update: update}
+	if ttl := time.Duration(c.ttl.Load()); ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	c.entries[latestKey(update.Exchange, update.Pair)] = entry
+	c.addTick(update)
+	c.mu.Unlock()
+	return nil
+}
+
+// addTick must be called with mu held. Ticks arrive roughly in time order,
+// so they are appended and the window is trimmed from the front.
+func (c *Cache) addTick(update domain.PriceUpdate) {
+	if c.tickHorizon <= 0 {
+		return
+	}
+	key := latestKey(update.Exchange, update.Pair)
+	ticks := append(c.ticks[key], update)
+	for i := len(ticks) - 1; i > 0 && ticks[i].Time.Before(ticks[i-1].Time); i-- {
+		ticks[i], ticks[i-1] = ticks[i-1], ticks[i]
+	}
+	cutoff := time.Now().Add(-c.tickHorizon)
+	i := sort.Search(len(ticks), func(i int) bool { return !ticks[i].Time.Before(cutoff) })
+	if i > len(ticks)/2 {
+		ticks = append([]domain.PriceUpdate(nil), ticks[i:]...)
+	} else {
+		ticks = ticks[i:]
+	}
+	c.ticks[key] = ticks
+}
+
+// GetTicks returns the ticks for exchange and pair at or after since, oldest
+// first. Only the last TickHorizon is kept.
+func (c *Cache) GetTicks(ctx context.Context, exchange, pair string, since time.Time) ([]domain.PriceUpdate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ticks := c.ticks[latestKey(exchange, pair)]
+	i := sort.Search(len(ticks), func(i int) bool { return !ticks[i].Time.Before(since) })
+	return append([]domain.PriceUpdate(nil), ticks[i:]...), nil
+}
+
+func (c *Cache) TickHorizon() time.Duration {
+	return c.tickHorizon
+}
+
+func (c *Cache) GetLatest(ctx context.Context, exchange, pair string) (domain.PriceUpdate, error) {
+	key := latestKey(exchange, pair)
+
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if !ok || entry.expired(time.Now()) {
+		return domain.PriceUpdate{}, fmt.Errorf("no data for %s: %w", key, domain.ErrNotFound)
+	}
+	return entry.update, nil
+}
+
+func (e cacheEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+func (c *Cache) SetTTL(ttl time.Duration) {
+	c.ttl.Store(int64(ttl))
+}
+
+// CleanStale drops prices whose tick time is older than staleAfter or whose
+// TTL has passed. Unlike Redis nothing expires on its own, so this is what
+// keeps the map bounded.
+func (c *Cache) CleanStale(ctx context.Context, staleAfter time.Duration) (domain.CacheCleanupReport, error) {
+	var report domain.CacheCleanupReport
+	now := time.Now()
+	cutoff := now.Add(-staleAfter)
+
+	c.mu.Lock()
+	for key, entry := range c.entries {
+		report.Checked++
+		if entry.expired(now) || entry.update.Time.Before(cutoff) {
+			delete(c.entries, key)
+			report.Removed++
+		}
+	}
+	// Tick windows of pairs that went quiet are only trimmed here.
+	for key, ticks := range c.ticks {
+		if len(ticks) == 0 || now.Sub(ticks[len(ticks)-1].Time) > c.tickHorizon {
+			delete(c.ticks, key)
+		}
+	}
+	c.mu.Unlock()
+
+	return report, nil
+}
+
+func (c *Cache) Close() error {
+	return nil
+}
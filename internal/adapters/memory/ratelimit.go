@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+// RateLimiter is a domain.RateLimiter keeping one token bucket per client
+// in process memory. Buckets that have refilled completely carry no state
+// and are dropped on the next sweep.
+type RateLimiter struct {
+	rate  float64
+	burst int
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	at     time.Time
+}
+
+// NewRateLimiter refills every bucket at rate tokens per second up to burst.
+func NewRateLimiter(rate, burst int) *RateLimiter {
+	return &RateLimiter{
+		rate:      float64(rate),
+		burst:     burst,
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (l *RateLimiter) Take(ctx context.Context, key string, cost int) (domain.RateLimitResult, error) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.burst), at: now}
+		l.buckets[key] = b
+	}
+	b.tokens = l.refill(b, now)
+	b.at = now
+
+	allowed := b.tokens >= float64(cost)
+	if allowed {
+		b.tokens -= float64(cost)
+	}
+	return domain.NewRateLimitResult(allowed, b.tokens, l.rate, l.burst, cost), nil
+}
+
+func (l *RateLimiter) refill(b *bucket, now time.Time) float64 {
+	return math.Min(float64(l.burst), b.tokens+now.Sub(b.at).Seconds()*l.rate)
+}
+
+// sweep drops full buckets, at most once per refill period.
+func (l *RateLimiter) sweep(now time.Time) {
+	period := time.Duration(float64(l.burst) / l.rate * float64(time.Second))
+	if now.Sub(l.lastSweep) < period {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if l.refill(b, now) >= float64(l.burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+// Repository is a process-local domain.PriceRepository. Stats are kept per
+// exchange and pair in slices sorted by timestamp; like the Postgres table,
+// a second row for the same exchange, pair and timestamp is ignored.
+type Repository struct {
+	mu    sync.RWMutex
+	stats map[string][]domain.PriceStats
+}
+
+func NewRepository() *Repository {
+	return &Repository{stats: make(map[string][]domain.PriceStats)}
+}
+
+func statsKey(exchange, pair string) string {
+	return exchange + ":" + pair
+}
+
+func (r *Repository) StoreStats(ctx context.Context, stat domain.PriceStats) error {
+	r.mu.Lock()
+	r.insert(stat)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Repository) StoreStatsBatch(ctx context.Context, stats []domain.PriceStats) error {
+	r.mu.Lock()
+	for _, stat := range stats {
+		r.insert(stat)
+	}
+	r.mu.Unlock()
+
+	logger.Debug("stored batch stats", "count", len(stats))
+	return nil
+}
+
+// insert must be called with mu held.
+func (r *Repository) insert(stat domain.PriceStats) {
+	key := statsKey(stat.Exchange, stat.Pair)
+	rows := r.stats[key]
+
+	i := sort.Search(len(rows), func(i int) bool { return !rows[i].Timestamp.Before(stat.Timestamp) })
+	if i < len(rows) && rows[i].Timestamp.Equal(stat.Timestamp) {
+		return
+	}
+	rows = append(rows, domain.PriceStats{})
+	copy(rows[i+1:], rows[i:])
+	rows[i] = stat
+	r.stats[key] = rows
+}
+
+// since returns a copy of the rows for exchange and pair at or after t.
+func (r *Repository) since(exchange, pair string, t time.Time) []domain.PriceStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rows := r.stats[statsKey(exchange, pair)]
+	i := sort.Search(len(rows), func(i int) bool { return !rows[i].Timestamp.Before(t) })
+	if i == len(rows) {
+		return nil
+	}
+	return append([]domain.PriceStats(nil), rows[i:]...)
+}
+
+func (r *Repository) GetStats(ctx context.Context, pair, exchange string, since time.Time) ([]domain.PriceStats, error) {
+	return r.since(exchange, pair, since), nil
+}
+
+func (r *Repository) GetLatest(ctx context.Context, exchange, pair string) (domain.PriceStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rows := r.stats[statsKey(exchange, pair)]
+	if len(rows) == 0 {
+		return domain.PriceStats{}, fmt.Errorf("no latest price for %s:%s: %w", exchange, pair, domain.ErrNotFound)
+	}
+	return rows[len(rows)-1], nil
+}
+
+func (r *Repository) GetByPeriod(ctx context.Context, exchange, pair string, period time.Duration) ([]domain.PriceStats, error) {
+	return r.since(exchange, pair, time.Now().Add(-period)), nil
+}
+
+// Maintain drops rows older than the raw retention. There are no partitions
+// or hourly rollups in memory, so only PurgedRows is reported.
+func (r *Repository) Maintain(ctx context.Context, policy domain.RetentionPolicy) (domain.MaintenanceReport, error) {
+	var report domain.MaintenanceReport
+	if policy.Raw <= 0 {
+		return report, nil
+	}
+	cutoff := time.Now().Add(-policy.Raw)
+
+	r.mu.Lock()
+	for key, rows := range r.stats {
+		i := sort.Search(len(rows), func(i int) bool { return !rows[i].Timestamp.Before(cutoff) })
+		if i == 0 {
+			continue
+		}
+		report.PurgedRows += int64(i)
+		if i == len(rows) {
+			delete(r.stats, key)
+			continue
+		}
+		r.stats[key] = append([]domain.PriceStats(nil), rows[i:]...)
+	}
+	r.mu.Unlock()
+
+	return report, nil
+}
+
+func (r *Repository) Close() error {
+	return nil
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+// SpreadStore is a process-local domain.SpreadStore, for storage backends
+// without a spread table. Samples are kept per pair in time order.
+type SpreadStore struct {
+	mu      sync.RWMutex
+	samples map[string][]domain.SpreadSample
+}
+
+func NewSpreadStore() *SpreadStore {
+	return &SpreadStore{samples: make(map[string][]domain.SpreadSample)}
+}
+
+func (s *SpreadStore) StoreSpreads(ctx context.Context, samples []domain.SpreadSample) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sample := range samples {
+		rows := s.samples[sample.Pair]
+		i := sort.Search(len(rows), func(i int) bool { return !rows[i].Time.Before(sample.Time) })
+		if i < len(rows) && rows[i].Time.Equal(sample.Time) {
+			continue
+		}
+		rows = append(rows, domain.SpreadSample{})
+		copy(rows[i+1:], rows[i:])
+		rows[i] = sample
+		s.samples[sample.Pair] = rows
+	}
+	return nil
+}
+
+func (s *SpreadStore) GetSpreads(ctx context.Context, pair string, since time.Time) ([]domain.SpreadSample, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rows := s.samples[pair]
+	i := sort.Search(len(rows), func(i int) bool { return !rows[i].Time.Before(since) })
+	return append([]domain.SpreadSample(nil), rows[i:]...), nil
+}
+
+func (s *SpreadStore) PruneSpreads(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var pruned int64
+	for pair, rows := range s.samples {
+		i := sort.Search(len(rows), func(i int) bool { return !rows[i].Time.Before(before) })
+		pruned += int64(i)
+		if i == len(rows) {
+			delete(s.samples, pair)
+		} else if i > 0 {
+			s.samples[pair] = append([]domain.SpreadSample(nil), rows[i:]...)
+		}
+	}
+	return pruned, nil
+}
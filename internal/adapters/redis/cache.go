@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -13,21 +16,38 @@ import (
 )
 
 type RedisCache struct {
-	client *redis.Client
-	ttl    time.Duration
+	client      *redis.Client
+	ttl         atomic.Int64
+	tickHorizon time.Duration
+	publish     bool
 }
 
-func NewRedisCache(addr, password string, db int, ttl time.Duration) *RedisCache {
+type Options struct {
+	Password string
+	DB       int
+	PoolSize int
+	TTL      time.Duration
+	// TickHorizon is how long every tick is kept in a per-pair sorted set,
+	// see GetTicks. Zero disables the tick window.
+	TickHorizon time.Duration
+	// Publish sends every tick to the pair's pub/sub channel, see Subscribe.
+	Publish bool
+}
+
+func NewRedisCache(addr string, opts Options) *RedisCache {
 	client := redis.NewClient(&redis.Options{
 		Addr:     addr,
-		Password: password,
-		DB:       db,
+		Password: opts.Password,
+		DB:       opts.DB,
+		PoolSize: opts.PoolSize,
 	})
 
 	cache := &RedisCache{
-		client: client,
-		ttl:    ttl,
+		client:      client,
+		tickHorizon: opts.TickHorizon,
+		publish:     opts.Publish,
 	}
+	cache.SetTTL(opts.TTL)
 
 	for i := 0; i < 3; i++ {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -54,7 +74,10 @@ func (r *RedisCache) SetLatest(ctx context.Context, update domain.PriceUpdate) e
 		logger.Error("marshal error", "key", key, "error", err)
 		return fmt.Errorf("marshal error: %w", err)
 	}
-	if err := r.client.Set(ctx, key, data, r.ttl).Err(); err != nil {
+	pipe := r.client.Pipeline()
+	pipe.Set(ctx, key, data, time.Duration(r.ttl.Load()))
+	r.addTicks(ctx, pipe, []domain.PriceUpdate{update})
+	if _, err := pipe.Exec(ctx); err != nil {
 		logger.Warn("redis set error, using fallback", "key", key, "error", err)
 		return nil
 	}
@@ -62,16 +85,130 @@ func (r *RedisCache) SetLatest(ctx context.Context, update domain.PriceUpdate) e
 	return nil
 }
 
+// SetLatestBatch writes updates in one pipeline. Unlike SetLatest it reports
+// Redis failures, so a write-behind caller can retry them.
+func (r *RedisCache) SetLatestBatch(ctx context.Context, updates []domain.PriceUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	ttl := time.Duration(r.ttl.Load())
+	pipe := r.client.Pipeline()
+	for _, update := range updates {
+		data, err := json.Marshal(update)
+		if err != nil {
+			return fmt.Errorf("marshal error: %w", err)
+		}
+		pipe.Set(ctx, fmt.Sprintf("latest:%s:%s", update.Exchange, update.Pair), data, ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("redis %w: %w", domain.ErrUnavailable, err)
+	}
+	logger.Debug("flushed latest prices", "count", len(updates))
+	return nil
+}
+
+func ticksKey(exchange, pair string) string {
+	return fmt.Sprintf("ticks:%s:%s", exchange, pair)
+}
+
+// AddTicks records ticks in the rolling window and publishes them. Unlike
+// SetLatest it reports Redis failures.
+func (r *RedisCache) AddTicks(ctx context.Context, ticks []domain.PriceUpdate) error {
+	if (r.tickHorizon <= 0 && !r.publish) || len(ticks) == 0 {
+		return nil
+	}
+	pipe := r.client.Pipeline()
+	r.addTicks(ctx, pipe, ticks)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("redis %w: %w", domain.ErrUnavailable, err)
+	}
+	return nil
+}
+
+// addTicks queues ZADDs scored by tick time in milliseconds, then trims each
+// touched set to the horizon and lets idle sets expire. Members carry the
+// nanosecond time and price, so equal prices at different times are kept.
+// With publishing on, each tick is also queued to its pair's channel.
+func (r *RedisCache) addTicks(ctx context.Context, pipe redis.Pipeliner, ticks []domain.PriceUpdate) {
+	if r.publish {
+		for _, t := range ticks {
+			if data, err := json.Marshal(t); err == nil {
+				pipe.Publish(ctx, priceChannel(t.Exchange, t.Pair), data)
+			}
+		}
+	}
+	if r.tickHorizon <= 0 {
+		return
+	}
+	touched := make(map[string]bool)
+	for _, t := range ticks {
+		key := ticksKey(t.Exchange, t.Pair)
+		pipe.ZAdd(ctx, key, &redis.Z{
+			Score:  float64(t.Time.UnixMilli()),
+			Member: strconv.FormatInt(t.Time.UnixNano(), 10) + ":" + strconv.FormatFloat(t.Price, 'g', -1, 64),
+		})
+		touched[key] = true
+	}
+	cutoff := strconv.FormatInt(time.Now().Add(-r.tickHorizon).UnixMilli(), 10)
+	for key := range touched {
+		pipe.ZRemRangeByScore(ctx, key, "-inf", "("+cutoff)
+		pipe.PExpire(ctx, key, r.tickHorizon)
+	}
+}
+
+// GetTicks returns the ticks for exchange and pair at or after since, oldest
+// first. Only the last TickHorizon is kept.
+func (r *RedisCache) GetTicks(ctx context.Context, exchange, pair string, since time.Time) ([]domain.PriceUpdate, error) {
+	if r.tickHorizon <= 0 {
+		return nil, nil
+	}
+	members, err := r.client.ZRangeByScore(ctx, ticksKey(exchange, pair), &redis.ZRangeBy{
+		Min: strconv.FormatInt(since.UnixMilli(), 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis %w: %w", domain.ErrUnavailable, err)
+	}
+
+	ticks := make([]domain.PriceUpdate, 0, len(members))
+	for _, m := range members {
+		ts, price, ok := strings.Cut(m, ":")
+		nanos, err1 := strconv.ParseInt(ts, 10, 64)
+		p, err2 := strconv.ParseFloat(price, 64)
+		if !ok || err1 != nil || err2 != nil {
+			logger.Warn("skipping malformed tick", "key", ticksKey(exchange, pair), "member", m)
+			continue
+		}
+		t := time.Unix(0, nanos).UTC()
+		if t.Before(since) {
+			continue
+		}
+		ticks = append(ticks, domain.PriceUpdate{Exchange: exchange, Pair: pair, Price: p, Time: t})
+	}
+	return ticks, nil
+}
+
+func (r *RedisCache) TickHorizon() time.Duration {
+	return r.tickHorizon
+}
+
+func (r *RedisCache) Ping(ctx context.Context) error {
+	if err := r.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("redis %w: %w", domain.ErrUnavailable, err)
+	}
+	return nil
+}
+
 func (r *RedisCache) GetLatest(ctx context.Context, exchange, pair string) (domain.PriceUpdate, error) {
 	key := fmt.Sprintf("latest:%s:%s", exchange, pair)
 	val, err := r.client.Get(ctx, key).Result()
 	if err == redis.Nil {
 		logger.Warn("no data in redis", "key", key)
-		return domain.PriceUpdate{}, fmt.Errorf("no data for %s", key)
+		return domain.PriceUpdate{}, fmt.Errorf("no data for %s: %w", key, domain.ErrNotFound)
 	}
 	if err != nil {
 		logger.Warn("redis get error, using fallback", "key", key, "error", err)
-		return domain.PriceUpdate{}, fmt.Errorf("redis unavailable: %w", err)
+		return domain.PriceUpdate{}, fmt.Errorf("redis %w: %w", domain.ErrUnavailable, err)
 	}
 	var update domain.PriceUpdate
 	if err := json.Unmarshal([]byte(val), &update); err != nil {
@@ -82,21 +219,110 @@ func (r *RedisCache) GetLatest(ctx context.Context, exchange, pair string) (doma
 	return update, nil
 }
 
-func (r *RedisCache) CleanOld(ctx context.Context, pattern string) error {
-	keys, err := r.client.Keys(ctx, pattern).Result()
+// GetLatestMany reads the latest prices of keys with one MGET.
+func (r *RedisCache) GetLatestMany(ctx context.Context, keys []domain.PairKey) (map[domain.PairKey]domain.PriceUpdate, error) {
+	out := make(map[domain.PairKey]domain.PriceUpdate, len(keys))
+	if len(keys) == 0 {
+		return out, nil
+	}
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = fmt.Sprintf("latest:%s:%s", k.Exchange, k.Pair)
+	}
+	vals, err := r.client.MGet(ctx, names...).Result()
 	if err != nil {
-		logger.Warn("failed to scan keys for cleanup", "pattern", pattern, "error", err)
-		return nil
+		return out, fmt.Errorf("redis %w: %w", domain.ErrUnavailable, err)
 	}
-	if len(keys) == 0 {
-		return nil
+	for i, val := range vals {
+		data, ok := val.(string)
+		if !ok {
+			continue // missing key
+		}
+		var update domain.PriceUpdate
+		if err := json.Unmarshal([]byte(data), &update); err != nil {
+			logger.Error("unmarshal error", "key", names[i], "error", err)
+			continue
+		}
+		out[keys[i]] = update
 	}
-	if err := r.client.Del(ctx, keys...).Err(); err != nil {
-		logger.Warn("failed to delete old keys", "pattern", pattern, "error", err)
-		return nil
+	logger.Debug("got latest prices", "requested", len(keys), "found", len(out))
+	return out, nil
+}
+
+func (r *RedisCache) SetTTL(ttl time.Duration) {
+	r.ttl.Store(int64(ttl))
+}
+
+// scanBatch is the SCAN COUNT hint and the number of keys read per MGET.
+const scanBatch = 500
+
+// deleteIfUnchanged removes a key only if it still holds the value that was
+// judged stale, so a tick written during the scan is not lost.
+var deleteIfUnchanged = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// CleanStale walks latest:* with SCAN and removes prices whose tick time is
+// older than staleAfter. Unreadable values are removed too.
+func (r *RedisCache) CleanStale(ctx context.Context, staleAfter time.Duration) (domain.CacheCleanupReport, error) {
+	var report domain.CacheCleanupReport
+	cutoff := time.Now().Add(-staleAfter)
+
+	var cursor uint64
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, "latest:*", scanBatch).Result()
+		if err != nil {
+			return report, fmt.Errorf("redis %w: %w", domain.ErrUnavailable, err)
+		}
+		if len(keys) > 0 {
+			removed, err := r.cleanBatch(ctx, keys, cutoff)
+			report.Checked += len(keys)
+			report.Removed += removed
+			if err != nil {
+				return report, err
+			}
+		}
+		if cursor = next; cursor == 0 {
+			return report, nil
+		}
 	}
-	logger.Info("cleaned old keys", "pattern", pattern, "count", len(keys))
-	return nil
+}
+
+func (r *RedisCache) cleanBatch(ctx context.Context, keys []string, cutoff time.Time) (int, error) {
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return 0, fmt.Errorf("redis %w: %w", domain.ErrUnavailable, err)
+	}
+
+	pipe := r.client.Pipeline()
+	var cmds []*redis.Cmd
+	for i, v := range values {
+		raw, ok := v.(string)
+		if !ok {
+			continue // expired since SCAN saw it
+		}
+		var update domain.PriceUpdate
+		if err := json.Unmarshal([]byte(raw), &update); err == nil && !update.Time.Before(cutoff) {
+			continue
+		}
+		cmds = append(cmds, deleteIfUnchanged.Eval(ctx, pipe, []string{keys[i]}, raw))
+	}
+	if len(cmds) == 0 {
+		return 0, nil
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("redis %w: %w", domain.ErrUnavailable, err)
+	}
+
+	removed := 0
+	for _, cmd := range cmds {
+		if n, _ := cmd.Int(); n > 0 {
+			removed++
+		}
+	}
+	return removed, nil
 }
 
 func (r *RedisCache) Close() error {
@@ -0,0 +1,61 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"marketflow/internal/domain"
+)
+
+// Lease is a lock held under key for a limited time by owner. It is only
+// extended or released by the owner that holds it.
+type Lease struct {
+	client *redis.Client
+	key    string
+	owner  string
+}
+
+var (
+	renewIfOwner = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+	releaseIfOwner = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+func (r *RedisCache) Lease(key, owner string) domain.LeaseLock {
+	return &Lease{client: r.client, key: key, owner: owner}
+}
+
+func (l *Lease) Acquire(ctx context.Context, ttl time.Duration) (bool, error) {
+	ok, err := l.client.SetNX(ctx, l.key, l.owner, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("redis %w: %w", domain.ErrUnavailable, err)
+	}
+	return ok, nil
+}
+
+// Renew extends the lease to ttl from now and reports false if owner no
+// longer holds it.
+func (l *Lease) Renew(ctx context.Context, ttl time.Duration) (bool, error) {
+	n, err := renewIfOwner.Run(ctx, l.client, []string{l.key}, l.owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("redis %w: %w", domain.ErrUnavailable, err)
+	}
+	return n == 1, nil
+}
+
+func (l *Lease) Release(ctx context.Context) error {
+	if err := releaseIfOwner.Run(ctx, l.client, []string{l.key}, l.owner).Err(); err != nil {
+		return fmt.Errorf("redis %w: %w", domain.ErrUnavailable, err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+// Ticks are published on one channel per pair, prices:{exchange}:{pair}.
+const priceChannelPrefix = "prices:"
+
+func priceChannel(exchange, pair string) string {
+	return fmt.Sprintf("%s%s:%s", priceChannelPrefix, exchange, pair)
+}
+
+// Subscribe returns the ticks published by every instance until ctx is
+// done. Pub/sub does not buffer for absent subscribers, so ticks sent while
+// the connection is down are missed; the latest price is still in Redis.
+func (r *RedisCache) Subscribe(ctx context.Context) (<-chan domain.PriceUpdate, error) {
+	sub := r.client.PSubscribe(ctx, priceChannelPrefix+"*")
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, fmt.Errorf("redis %w: failed to subscribe: %w", domain.ErrUnavailable, err)
+	}
+	logger.Info("subscribed to live prices", "pattern", priceChannelPrefix+"*")
+
+	out := make(chan domain.PriceUpdate, 256)
+	go func() {
+		defer close(out)
+		defer sub.Close()
+
+		msgs := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				var update domain.PriceUpdate
+				if err := json.Unmarshal([]byte(msg.Payload), &update); err != nil {
+					logger.Warn("skipping malformed price message", "channel", msg.Channel, "error", err)
+					continue
+				}
+				select {
+				case out <- update:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
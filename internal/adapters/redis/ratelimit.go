@@ -0,0 +1,61 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+
+	"marketflow/internal/domain"
+)
+
+// RateLimiter keeps token buckets in Redis hashes under ratelimit:{key}, so
+// every instance draws from the same bucket for a client. Time comes from
+// the Redis server, so instance clocks do not matter.
+type RateLimiter struct {
+	client *redis.Client
+	rate   int
+	burst  int
+}
+
+// takeTokens refills the bucket in KEYS[1] at ARGV[1] tokens per second up
+// to ARGV[2] and takes ARGV[3] tokens if it holds that many. It returns
+// whether it did and the tokens left, as a string to keep the fraction.
+var takeTokens = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local b = redis.call("HMGET", KEYS[1], "tokens", "at")
+local tokens = tonumber(b[1]) or burst
+local at = tonumber(b[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - at) * rate / 1000)
+local allowed = 0
+if tokens >= cost then
+	tokens = tokens - cost
+	allowed = 1
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "at", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {allowed, tostring(tokens)}`)
+
+// RateLimiter returns a limiter sharing this cache's connection pool.
+func (r *RedisCache) RateLimiter(rate, burst int) domain.RateLimiter {
+	return &RateLimiter{client: r.client, rate: rate, burst: burst}
+}
+
+func (l *RateLimiter) Take(ctx context.Context, key string, cost int) (domain.RateLimitResult, error) {
+	res, err := takeTokens.Run(ctx, l.client, []string{"ratelimit:" + key}, l.rate, l.burst, cost).Slice()
+	if err != nil {
+		return domain.RateLimitResult{}, fmt.Errorf("redis %w: %w", domain.ErrUnavailable, err)
+	}
+	allowed, _ := res[0].(int64)
+	str, _ := res[1].(string)
+	tokens, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return domain.RateLimitResult{}, fmt.Errorf("unexpected rate limit state %q: %w", str, err)
+	}
+	return domain.NewRateLimitResult(allowed == 1, tokens, float64(l.rate), l.burst, cost), nil
+}
@@ -0,0 +1,157 @@
+package filestore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+// Maintain drops stats older than the raw retention and compacts segments.
+// Records that left the index, because they expired or repeat a timestamp
+// already stored, are dead; segments with no live records are
+// removed and segments with some are rewritten with only the live ones.
+// There are no hourly rollups in the file store.
+func (r *Repository) Maintain(ctx context.Context, policy domain.RetentionPolicy) (domain.MaintenanceReport, error) {
+	var report domain.MaintenanceReport
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var cutoff int64
+	if policy.Raw > 0 {
+		cutoff = time.Now().Add(-policy.Raw).UnixNano()
+	}
+
+	for key, s := range r.series {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		purged, dropped, err := s.compact(cutoff)
+		report.PurgedRows += purged
+		report.DroppedPartitions = append(report.DroppedPartitions, dropped...)
+		if err != nil {
+			return report, fmt.Errorf("failed to compact %s: %w", key, err)
+		}
+		if len(s.index) == 0 && len(s.segments) == 0 {
+			delete(r.series, key)
+			os.Remove(s.dir)
+		}
+	}
+
+	logger.Debug("file store maintenance finished",
+		"dropped", len(report.DroppedPartitions), "purged", report.PurgedRows)
+	return report, nil
+}
+
+// compact expires index entries before cutoff, then removes or rewrites
+// every segment holding dead records. It returns the number of expired rows
+// and the removed or rewritten segment files.
+func (s *series) compact(cutoff int64) (int64, []string, error) {
+	var purged int64
+	for len(s.index) > 0 && s.index[0].ts < cutoff {
+		s.index = s.index[1:]
+		purged++
+	}
+
+	live := make(map[*segment][]int, len(s.segments))
+	for i, e := range s.index {
+		live[e.seg] = append(live[e.seg], i)
+	}
+
+	var dropped []string
+	for _, seg := range append([]*segment(nil), s.segments...) {
+		entries := live[seg]
+		if int64(len(entries))*recordSize == seg.size {
+			continue
+		}
+		if len(entries) == 0 {
+			if err := s.remove(seg); err != nil {
+				return purged, dropped, err
+			}
+			dropped = append(dropped, seg.path)
+			continue
+		}
+		if err := s.rewrite(seg, entries); err != nil {
+			return purged, dropped, err
+		}
+		dropped = append(dropped, seg.path)
+	}
+	return purged, dropped, nil
+}
+
+// rewrite copies the live records of seg, given as index positions, into a
+// new file and renames it over seg, so the segment keeps its sequence number
+// and its place in the series. The new file is complete and synced before
+// the rename; a crash before it leaves a temporary file that Open removes.
+func (s *series) rewrite(seg *segment, entries []int) error {
+	tmp := seg.path + ".tmp"
+
+	buf := make([]byte, len(entries)*recordSize)
+	for i, idx := range entries {
+		var stat domain.PriceStats
+		if err := seg.read(s.index[idx].off, &stat); err != nil {
+			return err
+		}
+		encodeRecord(buf[i*recordSize:], stat)
+	}
+
+	f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", tmp, err)
+	}
+	if _, err := f.Write(buf); err == nil {
+		err = f.Sync()
+	}
+	f.Close()
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, seg.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to rename %s: %w", tmp, err)
+	}
+	if err := syncDir(s.dir); err != nil {
+		return fmt.Errorf("failed to sync %s: %w", s.dir, err)
+	}
+
+	next, err := openSegment(s.dir, seg.seq)
+	if err != nil {
+		return err
+	}
+	seg.file.Close()
+	for i, idx := range entries {
+		s.index[idx].seg = next
+		s.index[idx].off = int64(i * recordSize)
+	}
+	for i, other := range s.segments {
+		if other == seg {
+			s.segments[i] = next
+			break
+		}
+	}
+	logger.Info("rewrote segment", "segment", seg.path, "records", len(entries))
+	return nil
+}
+
+func (s *series) remove(seg *segment) error {
+	for i, other := range s.segments {
+		if other == seg {
+			s.segments = append(s.segments[:i], s.segments[i+1:]...)
+			break
+		}
+	}
+	seg.file.Close()
+	if err := os.Remove(seg.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", seg.path, err)
+	}
+	if err := syncDir(s.dir); err != nil {
+		return fmt.Errorf("failed to sync %s: %w", s.dir, err)
+	}
+	logger.Info("removed segment", "segment", seg.path)
+	return nil
+}
@@ -0,0 +1,364 @@
+package filestore
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+// Repository is a domain.PriceRepository kept in plain files, for hosts
+// that cannot run Postgres. Each exchange and pair gets a directory of
+// append-only segments under dir/{exchange}/{pair}; an in-memory index of
+// timestamps to record offsets is rebuilt from the segments on Open.
+//
+// Writes are fsynced before they are acknowledged. Like the Postgres table,
+// a second row for the same exchange, pair and timestamp is ignored.
+type Repository struct {
+	dir         string
+	segmentSize int64
+
+	mu     sync.RWMutex
+	series map[string]*series
+}
+
+type Options struct {
+	// SegmentSize is the size in bytes at which a series starts a new
+	// segment file.
+	SegmentSize int64
+}
+
+type series struct {
+	exchange string
+	pair     string
+	dir      string
+	segments []*segment // by seq; the last one takes appends
+	index    []indexEntry
+	nextSeq  uint64
+}
+
+type indexEntry struct {
+	ts  int64
+	seg *segment
+	off int64
+}
+
+func Open(dir string, opts Options) (*Repository, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create file store %s: %w", dir, err)
+	}
+	r := &Repository{dir: dir, segmentSize: opts.SegmentSize, series: make(map[string]*series)}
+
+	exchanges, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file store %s: %w", dir, err)
+	}
+	for _, ex := range exchanges {
+		if !ex.IsDir() {
+			continue
+		}
+		pairs, err := os.ReadDir(filepath.Join(dir, ex.Name()))
+		if err != nil {
+			r.Close()
+			return nil, fmt.Errorf("failed to read file store %s: %w", dir, err)
+		}
+		for _, p := range pairs {
+			if !p.IsDir() {
+				continue
+			}
+			exchange, err1 := url.PathUnescape(ex.Name())
+			pair, err2 := url.PathUnescape(p.Name())
+			if err1 != nil || err2 != nil {
+				logger.Warn("skipping unknown directory in file store", "path", filepath.Join(ex.Name(), p.Name()))
+				continue
+			}
+			s, err := r.loadSeries(exchange, pair)
+			if err != nil {
+				r.Close()
+				return nil, err
+			}
+			r.series[seriesKey(exchange, pair)] = s
+		}
+	}
+
+	logger.Info("file store opened", "dir", dir, "series", len(r.series))
+	return r, nil
+}
+
+func seriesKey(exchange, pair string) string {
+	return exchange + ":" + pair
+}
+
+func (r *Repository) seriesDir(exchange, pair string) string {
+	return filepath.Join(r.dir, url.PathEscape(exchange), url.PathEscape(pair))
+}
+
+// loadSeries opens every segment of a series and indexes its records. A
+// torn record at the end of a segment, left by a crash mid-write, is
+// truncated away; corrupt records elsewhere are skipped.
+func (r *Repository) loadSeries(exchange, pair string) (*series, error) {
+	s := &series{exchange: exchange, pair: pair, dir: r.seriesDir(exchange, pair), nextSeq: 1}
+
+	seqs, err := listSegments(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list segments of %s:%s: %w", exchange, pair, err)
+	}
+	for _, seq := range seqs {
+		seg, err := openSegment(s.dir, seq)
+		if err != nil {
+			s.close()
+			return nil, err
+		}
+		s.segments = append(s.segments, seg)
+		s.nextSeq = seq + 1
+
+		records, skipped := 0, 0
+		end, err := seg.scan(func(off int64, stat domain.PriceStats) {
+			records++
+			s.insert(stat.Timestamp.UnixNano(), seg, off)
+		}, func() { skipped++ })
+		if err != nil {
+			s.close()
+			return nil, err
+		}
+		if skipped > 0 {
+			logger.Warn("skipped corrupt records", "segment", seg.path, "count", skipped)
+		}
+		if end < seg.size {
+			logger.Warn("truncating torn segment tail", "segment", seg.path, "bytes", seg.size-end)
+			if err := seg.truncate(end); err != nil {
+				s.close()
+				return nil, err
+			}
+		}
+	}
+	return s, nil
+}
+
+// insert adds an index entry unless the timestamp is already present, and
+// reports whether it did.
+func (s *series) insert(ts int64, seg *segment, off int64) bool {
+	i := sort.Search(len(s.index), func(i int) bool { return s.index[i].ts >= ts })
+	if i < len(s.index) && s.index[i].ts == ts {
+		return false
+	}
+	s.index = append(s.index, indexEntry{})
+	copy(s.index[i+1:], s.index[i:])
+	s.index[i] = indexEntry{ts: ts, seg: seg, off: off}
+	return true
+}
+
+func (s *series) contains(ts int64) bool {
+	i := sort.Search(len(s.index), func(i int) bool { return s.index[i].ts >= ts })
+	return i < len(s.index) && s.index[i].ts == ts
+}
+
+// active returns the segment that takes appends, starting a new one when
+// the current one has reached size.
+func (s *series) active(size int64) (*segment, error) {
+	if n := len(s.segments); n > 0 && (size <= 0 || s.segments[n-1].size < size) {
+		return s.segments[n-1], nil
+	}
+	if err := s.createDir(); err != nil {
+		return nil, err
+	}
+	seg, err := openSegment(s.dir, s.nextSeq)
+	if err != nil {
+		return nil, err
+	}
+	if err := syncDir(s.dir); err != nil {
+		seg.file.Close()
+		return nil, fmt.Errorf("failed to sync %s: %w", s.dir, err)
+	}
+	s.nextSeq++
+	s.segments = append(s.segments, seg)
+	return seg, nil
+}
+
+// createDir creates the series directory if it is missing. The exchange
+// directory and the store root are synced too, so that the new entries in
+// them survive a crash along with the segments.
+func (s *series) createDir() error {
+	if _, err := os.Stat(s.dir); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", s.dir, err)
+	}
+	exchangeDir := filepath.Dir(s.dir)
+	for _, dir := range []string{exchangeDir, filepath.Dir(exchangeDir)} {
+		if err := syncDir(dir); err != nil {
+			return fmt.Errorf("failed to sync %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// append writes stats to the active segment and fsyncs it before indexing
+// them. On failure the segment is cut back, so a series is never left with
+// a half-written batch.
+func (s *series) append(stats []domain.PriceStats, segmentSize int64) (int, error) {
+	seen := make(map[int64]bool, len(stats))
+	buf := make([]byte, 0, len(stats)*recordSize)
+	var keep []int64
+	for _, stat := range stats {
+		ts := stat.Timestamp.UnixNano()
+		if seen[ts] || s.contains(ts) {
+			continue
+		}
+		seen[ts] = true
+		rec := make([]byte, recordSize)
+		encodeRecord(rec, stat)
+		buf = append(buf, rec...)
+		keep = append(keep, ts)
+	}
+	if len(keep) == 0 {
+		return 0, nil
+	}
+
+	seg, err := s.active(segmentSize)
+	if err != nil {
+		return 0, err
+	}
+	start := seg.size
+	if _, err := seg.file.WriteAt(buf, start); err != nil {
+		seg.truncate(start)
+		return 0, fmt.Errorf("failed to write %s: %w", seg.path, err)
+	}
+	if err := seg.file.Sync(); err != nil {
+		seg.truncate(start)
+		return 0, fmt.Errorf("failed to sync %s: %w", seg.path, err)
+	}
+	seg.size = start + int64(len(buf))
+
+	for i, ts := range keep {
+		s.insert(ts, seg, start+int64(i*recordSize))
+	}
+	return len(keep), nil
+}
+
+func (s *series) close() {
+	for _, seg := range s.segments {
+		seg.file.Close()
+	}
+}
+
+func (r *Repository) StoreStats(ctx context.Context, stat domain.PriceStats) error {
+	return r.StoreStatsBatch(ctx, []domain.PriceStats{stat})
+}
+
+// StoreStatsBatch writes each exchange and pair of the batch atomically;
+// when it fails, series written before the failure stay stored.
+func (r *Repository) StoreStatsBatch(ctx context.Context, stats []domain.PriceStats) error {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	grouped := make(map[string][]domain.PriceStats)
+	var order []string
+	for _, stat := range stats {
+		key := seriesKey(stat.Exchange, stat.Pair)
+		if _, ok := grouped[key]; !ok {
+			order = append(order, key)
+		}
+		grouped[key] = append(grouped[key], stat)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := 0
+	for _, key := range order {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		batch := grouped[key]
+		s, ok := r.series[key]
+		if !ok {
+			s = &series{exchange: batch[0].Exchange, pair: batch[0].Pair, dir: r.seriesDir(batch[0].Exchange, batch[0].Pair), nextSeq: 1}
+			r.series[key] = s
+		}
+		n, err := s.append(batch, r.segmentSize)
+		if err != nil {
+			logger.Error("failed to store stats", "exchange", s.exchange, "pair", s.pair, "error", err)
+			return fmt.Errorf("failed to store stats for %s: %w", key, err)
+		}
+		stored += n
+	}
+
+	logger.Debug("stored batch stats", "count", stored)
+	return nil
+}
+
+// read loads the stats of exchange and pair at or after since. The caller
+// holds mu for reading.
+func (r *Repository) read(exchange, pair string, since time.Time) ([]domain.PriceStats, error) {
+	s, ok := r.series[seriesKey(exchange, pair)]
+	if !ok {
+		return nil, nil
+	}
+
+	ts := since.UnixNano()
+	i := sort.Search(len(s.index), func(i int) bool { return s.index[i].ts >= ts })
+	if i == len(s.index) {
+		return nil, nil
+	}
+
+	stats := make([]domain.PriceStats, 0, len(s.index)-i)
+	for _, e := range s.index[i:] {
+		stat := domain.PriceStats{Exchange: exchange, Pair: pair}
+		if err := e.seg.read(e.off, &stat); err != nil {
+			logger.Error("failed to read stats", "exchange", exchange, "pair", pair, "error", err)
+			return nil, err
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
+func (r *Repository) GetStats(ctx context.Context, pair, exchange string, since time.Time) ([]domain.PriceStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.read(exchange, pair, since)
+}
+
+func (r *Repository) GetByPeriod(ctx context.Context, exchange, pair string, period time.Duration) ([]domain.PriceStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.read(exchange, pair, time.Now().Add(-period))
+}
+
+func (r *Repository) GetLatest(ctx context.Context, exchange, pair string) (domain.PriceStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, ok := r.series[seriesKey(exchange, pair)]
+	if !ok || len(s.index) == 0 {
+		return domain.PriceStats{}, fmt.Errorf("no latest price for %s:%s: %w", exchange, pair, domain.ErrNotFound)
+	}
+	e := s.index[len(s.index)-1]
+	stat := domain.PriceStats{Exchange: exchange, Pair: pair}
+	if err := e.seg.read(e.off, &stat); err != nil {
+		return domain.PriceStats{}, err
+	}
+	return stat, nil
+}
+
+func (r *Repository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, s := range r.series {
+		s.close()
+	}
+	r.series = make(map[string]*series)
+	logger.Info("file store closed", "dir", r.dir)
+	return nil
+}
@@ -0,0 +1,259 @@
+package filestore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.InitWithWriter(io.Discard, "test", "error")
+	os.Exit(m.Run())
+}
+
+var base = time.Now().Add(-3 * time.Hour).Truncate(time.Minute).UTC()
+
+// minute returns the window of BTCUSDT on ex1 ending i minutes after base.
+func minute(i int, avg float64) domain.PriceStats {
+	return domain.PriceStats{Exchange: "ex1", Pair: "BTCUSDT", Timestamp: base.Add(time.Duration(i) * time.Minute), Average: avg, Min: avg, Max: avg}
+}
+
+func open(t *testing.T, dir string, segmentSize int64) *Repository {
+	t.Helper()
+	r, err := Open(dir, Options{SegmentSize: segmentSize})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func store(t *testing.T, r *Repository, stats ...domain.PriceStats) {
+	t.Helper()
+	if err := r.StoreStatsBatch(context.Background(), stats); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// averages returns the averages of every stored window, oldest first.
+func averages(t *testing.T, r *Repository) []float64 {
+	t.Helper()
+	stats, err := r.GetStats(context.Background(), "BTCUSDT", "ex1", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []float64
+	for _, s := range stats {
+		out = append(out, s.Average)
+	}
+	return out
+}
+
+func wantAverages(t *testing.T, r *Repository, want ...float64) {
+	t.Helper()
+	got := averages(t, r)
+	if len(got) != len(want) {
+		t.Fatalf("averages = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("averages = %v, want %v", got, want)
+		}
+	}
+}
+
+func segmentPath(dir string, seq uint64) string {
+	return filepath.Join(dir, "ex1", "BTCUSDT", segmentName(seq))
+}
+
+func appendFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Size()
+}
+
+func TestReopenTruncatesTornTail(t *testing.T) {
+	dir := t.TempDir()
+	r := open(t, dir, 0)
+	store(t, r, minute(1, 100), minute(2, 110), minute(3, 120))
+	r.Close()
+
+	// A crash in the middle of the fourth record.
+	path := segmentPath(dir, 1)
+	appendFile(t, path, []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10})
+
+	r = open(t, dir, 0)
+	wantAverages(t, r, 100, 110, 120)
+	if size := fileSize(t, path); size != 3*recordSize {
+		t.Errorf("segment is %d bytes after reopening, want the torn tail cut to %d", size, 3*recordSize)
+	}
+	store(t, r, minute(4, 130))
+	r.Close()
+
+	wantAverages(t, open(t, dir, 0), 100, 110, 120, 130)
+}
+
+func TestReopenSkipsGarbageRecords(t *testing.T) {
+	dir := t.TempDir()
+	r := open(t, dir, 0)
+	store(t, r, minute(1, 100), minute(2, 110))
+	r.Close()
+
+	// A whole record of garbage fails its checksum.
+	garbage := make([]byte, recordSize)
+	for i := range garbage {
+		garbage[i] = byte(i * 7)
+	}
+	appendFile(t, segmentPath(dir, 1), garbage)
+
+	r = open(t, dir, 0)
+	wantAverages(t, r, 100, 110)
+	store(t, r, minute(3, 120))
+	r.Close()
+
+	r = open(t, dir, 0)
+	wantAverages(t, r, 100, 110, 120)
+
+	// Compaction drops the garbage.
+	if _, err := r.Maintain(context.Background(), domain.RetentionPolicy{}); err != nil {
+		t.Fatal(err)
+	}
+	if size := fileSize(t, segmentPath(dir, 1)); size != 3*recordSize {
+		t.Errorf("segment is %d bytes after compaction, want %d", size, 3*recordSize)
+	}
+	wantAverages(t, r, 100, 110, 120)
+}
+
+func TestDuplicatesAcrossSegmentsAreIgnored(t *testing.T) {
+	dir := t.TempDir()
+	r := open(t, dir, 2*recordSize)
+	store(t, r, minute(1, 100), minute(2, 110))
+	store(t, r, minute(3, 120))
+	// minute 1 is in the first segment, and minute 3 twice in this batch.
+	store(t, r, minute(1, 999), minute(3, 999), minute(4, 130))
+	wantAverages(t, r, 100, 110, 120, 130)
+	r.Close()
+
+	// A segment repeating stored timestamps, as another writer or a
+	// restored backup could leave, loses to the older one on Open.
+	buf := make([]byte, 2*recordSize)
+	encodeRecord(buf, minute(2, 999))
+	encodeRecord(buf[recordSize:], minute(5, 140))
+	appendFile(t, segmentPath(dir, 3), buf)
+
+	r = open(t, dir, 2*recordSize)
+	wantAverages(t, r, 100, 110, 120, 130, 140)
+
+	if _, err := r.Maintain(context.Background(), domain.RetentionPolicy{}); err != nil {
+		t.Fatal(err)
+	}
+	if size := fileSize(t, segmentPath(dir, 3)); size != recordSize {
+		t.Errorf("segment 3 is %d bytes after compaction, want only minute 5 left", size)
+	}
+	r.Close()
+	wantAverages(t, open(t, dir, 2*recordSize), 100, 110, 120, 130, 140)
+}
+
+func TestCompactionKeepsSegmentOrder(t *testing.T) {
+	dir := t.TempDir()
+	r := open(t, dir, 2*recordSize)
+	// Segments 1 {0, 1}, 2 {2, 3} and 3 {4}; minutes 0 to 2 are older
+	// than the hour kept below.
+	old := base.Add(-time.Hour)
+	stats := []domain.PriceStats{minute(0, 100), minute(1, 110), minute(2, 120), minute(3, 130), minute(4, 140)}
+	for i := range stats {
+		if i < 3 {
+			stats[i].Timestamp = old.Add(time.Duration(i) * time.Minute)
+		} else {
+			stats[i].Timestamp = time.Now().Add(time.Duration(i-5) * time.Minute)
+		}
+		store(t, r, stats[i])
+	}
+
+	report, err := r.Maintain(context.Background(), domain.RetentionPolicy{Raw: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.PurgedRows != 3 {
+		t.Errorf("purged %d rows, want 3", report.PurgedRows)
+	}
+	if len(report.DroppedPartitions) != 2 || report.DroppedPartitions[0] != segmentPath(dir, 1) {
+		t.Errorf("dropped %v, want segment 1 removed and segment 2 rewritten", report.DroppedPartitions)
+	}
+	if _, err := os.Stat(segmentPath(dir, 1)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("segment 1 still exists: %v", err)
+	}
+	if size := fileSize(t, segmentPath(dir, 2)); size != recordSize {
+		t.Errorf("segment 2 is %d bytes, want one record", size)
+	}
+	wantAverages(t, r, 130, 140)
+
+	// The rewritten segment keeps its place, so appends still go to the
+	// last one.
+	next := minute(0, 150)
+	next.Timestamp = time.Now()
+	store(t, r, next)
+	if size := fileSize(t, segmentPath(dir, 3)); size != 2*recordSize {
+		t.Errorf("segment 3 is %d bytes, want the new record appended to it", size)
+	}
+	if size := fileSize(t, segmentPath(dir, 2)); size != recordSize {
+		t.Errorf("segment 2 is %d bytes, want it untouched", size)
+	}
+	r.Close()
+
+	wantAverages(t, open(t, dir, 2*recordSize), 130, 140, 150)
+}
+
+func TestRetentionDropsExpiredSeries(t *testing.T) {
+	dir := t.TempDir()
+	r := open(t, dir, 0)
+	store(t, r, minute(1, 100), minute(2, 110))
+	kept := domain.PriceStats{Exchange: "ex2", Pair: "ETHUSDT", Timestamp: time.Now(), Average: 10}
+	store(t, r, kept)
+
+	report, err := r.Maintain(context.Background(), domain.RetentionPolicy{Raw: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.PurgedRows != 2 || len(report.DroppedPartitions) != 1 {
+		t.Errorf("report = %+v, want 2 rows purged and 1 segment dropped", report)
+	}
+	if _, err := r.GetLatest(context.Background(), "ex1", "BTCUSDT"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GetLatest of the expired series = %v, want ErrNotFound", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ex1", "BTCUSDT")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expired series directory still exists: %v", err)
+	}
+	if got, err := r.GetLatest(context.Background(), "ex2", "ETHUSDT"); err != nil || got.Average != 10 {
+		t.Errorf("GetLatest of the kept series = %+v, %v", got, err)
+	}
+
+	// A series written again after expiry starts over.
+	now := minute(0, 200)
+	now.Timestamp = time.Now()
+	store(t, r, now)
+	r.Close()
+	wantAverages(t, open(t, dir, 0), 200)
+}
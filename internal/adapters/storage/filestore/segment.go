@@ -0,0 +1,154 @@
+package filestore
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+// A segment is an append-only file of fixed-size records:
+//
+//	timestamp (int64 unix nanos) | average | min | max (float64) | crc32
+//
+// all little-endian. The exchange and pair are implied by the directory.
+const (
+	recordSize    = 36
+	segmentSuffix = ".seg"
+)
+
+var errBadRecord = errors.New("bad record")
+
+func encodeRecord(buf []byte, stat domain.PriceStats) {
+	binary.LittleEndian.PutUint64(buf[0:], uint64(stat.Timestamp.UnixNano()))
+	binary.LittleEndian.PutUint64(buf[8:], math.Float64bits(stat.Average))
+	binary.LittleEndian.PutUint64(buf[16:], math.Float64bits(stat.Min))
+	binary.LittleEndian.PutUint64(buf[24:], math.Float64bits(stat.Max))
+	binary.LittleEndian.PutUint32(buf[32:], crc32.ChecksumIEEE(buf[:32]))
+}
+
+func decodeRecord(buf []byte, stat *domain.PriceStats) error {
+	if crc32.ChecksumIEEE(buf[:32]) != binary.LittleEndian.Uint32(buf[32:]) {
+		return errBadRecord
+	}
+	stat.Timestamp = time.Unix(0, int64(binary.LittleEndian.Uint64(buf[0:]))).UTC()
+	stat.Average = math.Float64frombits(binary.LittleEndian.Uint64(buf[8:]))
+	stat.Min = math.Float64frombits(binary.LittleEndian.Uint64(buf[16:]))
+	stat.Max = math.Float64frombits(binary.LittleEndian.Uint64(buf[24:]))
+	return nil
+}
+
+type segment struct {
+	seq  uint64
+	path string
+	file *os.File
+	size int64
+}
+
+func segmentName(seq uint64) string {
+	return fmt.Sprintf("%016d%s", seq, segmentSuffix)
+}
+
+// listSegments returns the sequence numbers of the segments in dir, oldest
+// first. Leftover temporary files from an interrupted compaction are removed.
+func listSegments(dir string) ([]uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var seqs []uint64
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasSuffix(name, ".tmp") {
+			os.Remove(filepath.Join(dir, name))
+			continue
+		}
+		num, ok := strings.CutSuffix(name, segmentSuffix)
+		if !ok {
+			continue
+		}
+		seq, err := strconv.ParseUint(num, 10, 64)
+		if err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	return seqs, nil
+}
+
+func openSegment(dir string, seq uint64) (*segment, error) {
+	path := filepath.Join(dir, segmentName(seq))
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open segment %s: %w", path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to stat segment %s: %w", path, err)
+	}
+	return &segment{seq: seq, path: path, file: f, size: info.Size()}, nil
+}
+
+// scan calls fn for every valid record and corrupt for every full record
+// whose checksum does not match. It returns the offset just past the last
+// full record; anything after it is a torn write.
+func (s *segment) scan(fn func(off int64, stat domain.PriceStats), corrupt func()) (int64, error) {
+	buf := make([]byte, recordSize)
+	var off int64
+	for ; off+recordSize <= s.size; off += recordSize {
+		if _, err := s.file.ReadAt(buf, off); err != nil {
+			return off, fmt.Errorf("failed to read segment %s: %w", s.path, err)
+		}
+		var stat domain.PriceStats
+		if err := decodeRecord(buf, &stat); err != nil {
+			corrupt()
+			continue
+		}
+		fn(off, stat)
+	}
+	return off, nil
+}
+
+func (s *segment) truncate(size int64) error {
+	if err := s.file.Truncate(size); err != nil {
+		return fmt.Errorf("failed to truncate segment %s: %w", s.path, err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync segment %s: %w", s.path, err)
+	}
+	s.size = size
+	return nil
+}
+
+func (s *segment) read(off int64, stat *domain.PriceStats) error {
+	buf := make([]byte, recordSize)
+	if _, err := s.file.ReadAt(buf, off); err != nil {
+		return fmt.Errorf("failed to read segment %s at %d: %w", s.path, off, err)
+	}
+	if err := decodeRecord(buf, stat); err != nil {
+		return fmt.Errorf("segment %s at %d: %w", s.path, off, err)
+	}
+	return nil
+}
+
+// syncDir makes file creations, renames and removals in dir durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"marketflow/internal/domain"
+)
+
+const alertColumns = `id, name, kind, exchange_name, pair_name, threshold, period_ms, webhook_url, secret, cooldown_ms, created_at`
+
+func (r *PostgresRepository) CreateAlert(ctx context.Context, alert domain.Alert) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO alerts (`+alertColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, alert.ID, alert.Name, alert.Kind, alert.Exchange, alert.Pair, alert.Threshold, alert.Period.Milliseconds(),
+		alert.WebhookURL, alert.Secret, alert.Cooldown.Milliseconds(), alert.CreatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return fmt.Errorf("alert %s: %w", alert.ID, domain.ErrConflict)
+	}
+	if err != nil {
+		return finish(ctx, "create alert", r.timeouts.Write, fmt.Errorf("failed to create alert: %w", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetAlert(ctx context.Context, id string) (domain.Alert, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	alert, err := scanAlert(r.db.QueryRowContext(ctx, `SELECT `+alertColumns+` FROM alerts WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Alert{}, fmt.Errorf("alert %s: %w", id, domain.ErrNotFound)
+	}
+	if err != nil {
+		return domain.Alert{}, finish(ctx, "get alert", r.timeouts.Read, fmt.Errorf("failed to get alert: %w", err))
+	}
+	return alert, nil
+}
+
+func (r *PostgresRepository) ListAlerts(ctx context.Context) ([]domain.Alert, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+alertColumns+` FROM alerts ORDER BY created_at`)
+	if err != nil {
+		return nil, finish(ctx, "list alerts", r.timeouts.Read, fmt.Errorf("failed to list alerts: %w", err))
+	}
+	defer rows.Close()
+
+	var alerts []domain.Alert
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, finish(ctx, "list alerts", r.timeouts.Read, fmt.Errorf("failed to scan alert: %w", err))
+		}
+		alerts = append(alerts, alert)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, finish(ctx, "list alerts", r.timeouts.Read, err)
+	}
+	return alerts, nil
+}
+
+// DeleteAlert also deletes the alert's delivery history.
+func (r *PostgresRepository) DeleteAlert(ctx context.Context, id string) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM alerts WHERE id = $1`, id)
+	if err != nil {
+		return finish(ctx, "delete alert", r.timeouts.Write, fmt.Errorf("failed to delete alert: %w", err))
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("alert %s: %w", id, domain.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) RecordDelivery(ctx context.Context, d domain.AlertDelivery) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO alert_deliveries (alert_id, event_id, time, attempts, status_code, error)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, d.AlertID, d.EventID, d.Time, d.Attempts, d.StatusCode, d.Error)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return fmt.Errorf("alert %s: %w", d.AlertID, domain.ErrNotFound) // deleted while delivering
+	}
+	if err != nil {
+		return finish(ctx, "record delivery", r.timeouts.Write, fmt.Errorf("failed to record alert delivery: %w", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) ListDeliveries(ctx context.Context, alertID string, limit int) ([]domain.AlertDelivery, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT alert_id, event_id, time, attempts, status_code, error
+		FROM alert_deliveries
+		WHERE alert_id = $1
+		ORDER BY time DESC
+		LIMIT $2
+	`, alertID, limit)
+	if err != nil {
+		return nil, finish(ctx, "list deliveries", r.timeouts.Read, fmt.Errorf("failed to list alert deliveries: %w", err))
+	}
+	defer rows.Close()
+
+	var deliveries []domain.AlertDelivery
+	for rows.Next() {
+		var d domain.AlertDelivery
+		if err := rows.Scan(&d.AlertID, &d.EventID, &d.Time, &d.Attempts, &d.StatusCode, &d.Error); err != nil {
+			return nil, finish(ctx, "list deliveries", r.timeouts.Read, fmt.Errorf("failed to scan alert delivery: %w", err))
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, finish(ctx, "list deliveries", r.timeouts.Read, err)
+	}
+	return deliveries, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAlert(row rowScanner) (domain.Alert, error) {
+	var a domain.Alert
+	var periodMs, cooldownMs int64
+	err := row.Scan(&a.ID, &a.Name, &a.Kind, &a.Exchange, &a.Pair, &a.Threshold, &periodMs,
+		&a.WebhookURL, &a.Secret, &cooldownMs, &a.CreatedAt)
+	a.Period = time.Duration(periodMs) * time.Millisecond
+	a.Cooldown = time.Duration(cooldownMs) * time.Millisecond
+	return a, err
+}
@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+// copyStatsBatch streams stats into a temporary staging table with COPY and
+// merges them into price_stats in one statement. Unlike a multi-VALUES
+// INSERT it has no parameter limit, so replays and backfills of any size go
+// through in a single transaction.
+func (r *PostgresRepository) copyStatsBatch(ctx context.Context, stats []domain.PriceStats) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error("failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		CREATE TEMP TABLE price_stats_staging (LIKE price_stats INCLUDING DEFAULTS) ON COMMIT DROP
+	`); err != nil {
+		logger.Error("failed to create staging table", "error", err)
+		return fmt.Errorf("failed to create staging table: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("price_stats_staging",
+		"pair_name", "exchange", "timestamp", "average_price", "min_price", "max_price"))
+	if err != nil {
+		logger.Error("failed to prepare copy", "error", err)
+		return fmt.Errorf("failed to prepare copy: %w", err)
+	}
+
+	for i, stat := range stats {
+		if _, err := stmt.ExecContext(ctx, stat.Pair, stat.Exchange, stat.Timestamp,
+			stat.Average, stat.Min, stat.Max); err != nil {
+			stmt.Close()
+			logger.Error("failed to copy row", "index", i, "pair", stat.Pair, "exchange", stat.Exchange, "error", err)
+			return fmt.Errorf("failed to copy row %d: %w", i, err)
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		stmt.Close()
+		logger.Error("failed to flush copy", "error", err)
+		return fmt.Errorf("failed to flush copy: %w", err)
+	}
+	if err := stmt.Close(); err != nil {
+		return fmt.Errorf("failed to close copy: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO price_stats (pair_name, exchange, timestamp, average_price, min_price, max_price)
+		SELECT pair_name, exchange, timestamp, average_price, min_price, max_price
+		FROM price_stats_staging
+		ON CONFLICT (pair_name, exchange, timestamp) DO NOTHING
+	`)
+	if err != nil {
+		logger.Error("failed to merge staged stats", "error", err)
+		return fmt.Errorf("failed to merge staged stats: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("failed to commit transaction", "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	inserted, _ := res.RowsAffected()
+	logger.Info("stored batch stats via copy", "count", len(stats), "inserted", inserted)
+	return nil
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+// testRepo opens the database named by TEST_POSTGRES_DSN and migrates it,
+// or skips tb when the variable is unset.
+func testRepo(tb testing.TB, copyThreshold int) *PostgresRepository {
+	tb.Helper()
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		tb.Skip("TEST_POSTGRES_DSN is not set")
+	}
+	repo, err := NewPostgresRepository(dsn, Options{MaxOpenConns: 4, MaxIdleConns: 4, CopyThreshold: copyThreshold})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { repo.Close() })
+
+	migrator, err := repo.Migrator()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		tb.Fatal(err)
+	}
+	return repo
+}
+
+func benchStats(exchange string, n int) []domain.PriceStats {
+	stats := make([]domain.PriceStats, n)
+	base := time.Now().Truncate(time.Second).Add(-time.Duration(n) * time.Second)
+	for i := range stats {
+		p := 100 + float64(i%1000)/10
+		stats[i] = domain.PriceStats{
+			Exchange:  exchange,
+			Pair:      "BENCHUSDT",
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+			Average:   p,
+			Min:       p - 1,
+			Max:       p + 1,
+		}
+	}
+	return stats
+}
+
+// benchmarkBatch stores batches of 1k, 10k and 100k rows through
+// StoreStatsBatch with the given copy threshold, deleting them between
+// iterations outside the timer.
+func benchmarkBatch(b *testing.B, copyThreshold int) {
+	repo := testRepo(b, copyThreshold)
+	ctx := context.Background()
+	exchange := fmt.Sprintf("bench-%d", time.Now().UnixNano())
+	b.Cleanup(func() {
+		repo.db.ExecContext(ctx, `DELETE FROM price_stats WHERE exchange = $1`, exchange)
+	})
+
+	for _, n := range []int{1_000, 10_000, 100_000} {
+		b.Run(fmt.Sprintf("rows=%d", n), func(b *testing.B) {
+			stats := benchStats(exchange, n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := repo.StoreStatsBatch(ctx, stats); err != nil {
+					b.Fatal(err)
+				}
+				b.StopTimer()
+				if _, err := repo.db.ExecContext(ctx, `DELETE FROM price_stats WHERE exchange = $1`, exchange); err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+			}
+			b.ReportMetric(float64(n*b.N)/b.Elapsed().Seconds(), "rows/s")
+		})
+	}
+}
+
+func BenchmarkInsertBatch(b *testing.B) { benchmarkBatch(b, 0) }
+
+func BenchmarkCopyBatch(b *testing.B) { benchmarkBatch(b, 1) }
@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"marketflow/internal/domain"
+)
+
+func (r *PostgresRepository) CreateKey(ctx context.Context, key domain.APIKey) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO api_keys (id, name, key_hash, scopes, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, key.ID, key.Name, key.Hash, pq.Array(key.Scopes), key.CreatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return fmt.Errorf("api key %s: %w", key.ID, domain.ErrConflict)
+	}
+	if err != nil {
+		return finish(ctx, "create api key", r.timeouts.Write, fmt.Errorf("failed to create api key: %w", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) FindKey(ctx context.Context, hash string) (domain.APIKey, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	var key domain.APIKey
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, name, key_hash, scopes, created_at FROM api_keys WHERE key_hash = $1
+	`, hash).Scan(&key.ID, &key.Name, &key.Hash, pq.Array(&key.Scopes), &key.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.APIKey{}, fmt.Errorf("api key: %w", domain.ErrNotFound)
+	}
+	if err != nil {
+		return domain.APIKey{}, finish(ctx, "find api key", r.timeouts.Read, fmt.Errorf("failed to find api key: %w", err))
+	}
+	return key, nil
+}
+
+func (r *PostgresRepository) ListKeys(ctx context.Context) ([]domain.APIKey, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, name, key_hash, scopes, created_at FROM api_keys ORDER BY created_at
+	`)
+	if err != nil {
+		return nil, finish(ctx, "list api keys", r.timeouts.Read, fmt.Errorf("failed to list api keys: %w", err))
+	}
+	defer rows.Close()
+
+	var keys []domain.APIKey
+	for rows.Next() {
+		var key domain.APIKey
+		if err := rows.Scan(&key.ID, &key.Name, &key.Hash, pq.Array(&key.Scopes), &key.CreatedAt); err != nil {
+			return nil, finish(ctx, "list api keys", r.timeouts.Read, fmt.Errorf("failed to scan api key: %w", err))
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, finish(ctx, "list api keys", r.timeouts.Read, err)
+	}
+	return keys, nil
+}
+
+func (r *PostgresRepository) DeleteKey(ctx context.Context, id string) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM api_keys WHERE id = $1`, id)
+	if err != nil {
+		return finish(ctx, "delete api key", r.timeouts.Write, fmt.Errorf("failed to delete api key: %w", err))
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("api key %s: %w", id, domain.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) RecordAudit(ctx context.Context, entry domain.AuditEntry) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO audit_log (time, key_id, key_name, action, target, request_id, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, entry.Time, entry.KeyID, entry.KeyName, entry.Action, entry.Target, entry.RequestID, entry.Error)
+	if err != nil {
+		return finish(ctx, "record audit", r.timeouts.Write, fmt.Errorf("failed to record audit entry: %w", err))
+	}
+	return nil
+}
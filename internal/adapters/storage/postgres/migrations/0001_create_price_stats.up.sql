@@ -9,5 +9,5 @@ CREATE TABLE IF NOT EXISTS price_stats (
     UNIQUE(pair_name, exchange, timestamp)
 );
 
-CREATE INDEX idx_pair_timestamp ON price_stats(pair_name, timestamp);
-CREATE INDEX idx_exchange_pair_timestamp ON price_stats(exchange, pair_name, timestamp);
+CREATE INDEX IF NOT EXISTS idx_pair_timestamp ON price_stats(pair_name, timestamp);
+CREATE INDEX IF NOT EXISTS idx_exchange_pair_timestamp ON price_stats(exchange, pair_name, timestamp);
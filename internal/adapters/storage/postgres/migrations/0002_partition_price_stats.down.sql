@@ -0,0 +1,22 @@
+CREATE TABLE price_stats_plain (
+    id SERIAL PRIMARY KEY,
+    pair_name VARCHAR(20) NOT NULL,
+    exchange VARCHAR(50) NOT NULL,
+    timestamp TIMESTAMP NOT NULL,
+    average_price DECIMAL(24,8) NOT NULL,
+    min_price DECIMAL(24,8) NOT NULL,
+    max_price DECIMAL(24,8) NOT NULL,
+    UNIQUE(pair_name, exchange, timestamp)
+);
+
+INSERT INTO price_stats_plain (pair_name, exchange, timestamp, average_price, min_price, max_price)
+SELECT pair_name, exchange, timestamp, average_price, min_price, max_price
+FROM price_stats
+ORDER BY timestamp;
+
+DROP TABLE price_stats;
+DROP TABLE price_stats_hourly;
+ALTER TABLE price_stats_plain RENAME TO price_stats;
+
+CREATE INDEX idx_pair_timestamp ON price_stats(pair_name, timestamp);
+CREATE INDEX idx_exchange_pair_timestamp ON price_stats(exchange, pair_name, timestamp);
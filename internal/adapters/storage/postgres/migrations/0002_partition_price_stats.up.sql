@@ -0,0 +1,39 @@
+-- price_stats becomes range-partitioned on timestamp. Rows land in the default
+-- partition until the maintenance job creates the dated partition covering
+-- them, at which point they are moved over.
+ALTER TABLE price_stats RENAME TO price_stats_legacy;
+DROP INDEX IF EXISTS idx_pair_timestamp;
+DROP INDEX IF EXISTS idx_exchange_pair_timestamp;
+
+CREATE TABLE price_stats (
+    pair_name VARCHAR(20) NOT NULL,
+    exchange VARCHAR(50) NOT NULL,
+    timestamp TIMESTAMP NOT NULL,
+    average_price DECIMAL(24,8) NOT NULL,
+    min_price DECIMAL(24,8) NOT NULL,
+    max_price DECIMAL(24,8) NOT NULL,
+    PRIMARY KEY (pair_name, exchange, timestamp)
+) PARTITION BY RANGE (timestamp);
+
+CREATE INDEX idx_pair_timestamp ON price_stats (pair_name, timestamp);
+CREATE TABLE price_stats_default PARTITION OF price_stats DEFAULT;
+
+INSERT INTO price_stats (pair_name, exchange, timestamp, average_price, min_price, max_price)
+SELECT pair_name, exchange, timestamp, average_price, min_price, max_price
+FROM price_stats_legacy
+ON CONFLICT DO NOTHING;
+
+DROP TABLE price_stats_legacy;
+
+-- Hourly rollups of price_stats, kept longer than the raw windows.
+CREATE TABLE price_stats_hourly (
+    pair_name VARCHAR(20) NOT NULL,
+    exchange VARCHAR(50) NOT NULL,
+    timestamp TIMESTAMP NOT NULL,
+    average_price DECIMAL(24,8) NOT NULL,
+    min_price DECIMAL(24,8) NOT NULL,
+    max_price DECIMAL(24,8) NOT NULL,
+    PRIMARY KEY (pair_name, exchange, timestamp)
+) PARTITION BY RANGE (timestamp);
+
+CREATE TABLE price_stats_hourly_default PARTITION OF price_stats_hourly DEFAULT;
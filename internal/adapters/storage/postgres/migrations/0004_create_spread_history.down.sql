@@ -0,0 +1 @@
+DROP TABLE IF EXISTS spread_history;
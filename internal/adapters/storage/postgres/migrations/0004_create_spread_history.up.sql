@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS spread_history (
+    pair_name VARCHAR(20) NOT NULL,
+    time TIMESTAMPTZ NOT NULL,
+    high_price DECIMAL(24,8) NOT NULL,
+    high_exchange VARCHAR(50) NOT NULL,
+    low_price DECIMAL(24,8) NOT NULL,
+    low_exchange VARCHAR(50) NOT NULL,
+    spread_bps DOUBLE PRECISION NOT NULL,
+    exchanges INT NOT NULL,
+    PRIMARY KEY (pair_name, time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_spread_history_time ON spread_history(time);
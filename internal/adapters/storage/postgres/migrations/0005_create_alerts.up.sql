@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS alerts (
+    id VARCHAR(32) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(10) NOT NULL,
+    exchange_name VARCHAR(50) NOT NULL,
+    pair_name VARCHAR(20) NOT NULL,
+    threshold DOUBLE PRECISION NOT NULL,
+    period_ms BIGINT NOT NULL,
+    webhook_url TEXT NOT NULL,
+    secret VARCHAR(64) NOT NULL,
+    cooldown_ms BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS alert_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    alert_id VARCHAR(32) NOT NULL REFERENCES alerts(id) ON DELETE CASCADE,
+    event_id VARCHAR(64) NOT NULL,
+    time TIMESTAMPTZ NOT NULL,
+    attempts INT NOT NULL,
+    status_code INT NOT NULL,
+    error TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_deliveries_alert_time ON alert_deliveries(alert_id, time);
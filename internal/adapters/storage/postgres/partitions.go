@@ -0,0 +1,268 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+// partitionedTable describes one range-partitioned table and the size of
+// its partitions. Partition names carry their start, e.g. price_stats_p20261019.
+type partitionedTable struct {
+	name   string
+	unit   string // "day" or "month"
+	layout string
+}
+
+var (
+	rawTable    = partitionedTable{name: "price_stats", unit: "day", layout: "20060102"}
+	hourlyTable = partitionedTable{name: "price_stats_hourly", unit: "month", layout: "200601"}
+)
+
+func (t partitionedTable) start(ts time.Time) time.Time {
+	ts = ts.UTC()
+	if t.unit == "month" {
+		return time.Date(ts.Year(), ts.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(ts.Year(), ts.Month(), ts.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (t partitionedTable) next(start time.Time) time.Time {
+	if t.unit == "month" {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+func (t partitionedTable) partitionName(start time.Time) string {
+	return t.name + "_p" + start.Format(t.layout)
+}
+
+func (t partitionedTable) parse(partition string) (time.Time, bool) {
+	suffix, ok := strings.CutPrefix(partition, t.name+"_p")
+	if !ok {
+		return time.Time{}, false
+	}
+	start, err := time.ParseInLocation(t.layout, suffix, time.UTC)
+	return start, err == nil
+}
+
+// Maintain creates upcoming partitions, moves rows out of the default
+// partitions, rolls finished hours up into price_stats_hourly and drops
+// partitions that fall entirely outside the retention policy.
+func (r *PostgresRepository) Maintain(ctx context.Context, policy domain.RetentionPolicy) (domain.MaintenanceReport, error) {
+	var report domain.MaintenanceReport
+	now := time.Now().UTC()
+
+	for _, t := range []partitionedTable{rawTable, hourlyTable} {
+		created, err := r.ensurePartitions(ctx, t, now, policy.PartitionsAhead)
+		report.CreatedPartitions = append(report.CreatedPartitions, created...)
+		if err != nil {
+			return report, err
+		}
+	}
+
+	rolled, err := r.rollupHourly(ctx, now)
+	report.RolledUpRows = rolled
+	if err != nil {
+		return report, err
+	}
+
+	for _, rule := range []struct {
+		table     partitionedTable
+		retention time.Duration
+	}{
+		{rawTable, policy.Raw},
+		{hourlyTable, policy.Hourly},
+	} {
+		dropped, purged, err := r.applyRetention(ctx, rule.table, now.Add(-rule.retention))
+		report.DroppedPartitions = append(report.DroppedPartitions, dropped...)
+		report.PurgedRows += purged
+		if err != nil {
+			return report, err
+		}
+	}
+
+	logger.Debug("storage maintenance finished",
+		"created", len(report.CreatedPartitions), "dropped", len(report.DroppedPartitions),
+		"rolled_up", report.RolledUpRows, "purged", report.PurgedRows)
+	return report, nil
+}
+
+// ensurePartitions creates partitions for the current period, the next
+// `ahead` periods and every period that has rows parked in the default
+// partition.
+func (r *PostgresRepository) ensurePartitions(ctx context.Context, t partitionedTable, now time.Time, ahead int) ([]string, error) {
+	existing, err := r.partitions(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[time.Time]bool)
+	start := t.start(now)
+	for i := 0; i <= ahead; i++ {
+		wanted[start] = true
+		start = t.next(start)
+	}
+
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(
+		`SELECT DISTINCT date_trunc('%s', timestamp) FROM %s_default`, t.unit, t.name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s_default: %w", t.name, err)
+	}
+	for rows.Next() {
+		var ts time.Time
+		if err := rows.Scan(&ts); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan %s_default: %w", t.name, err)
+		}
+		wanted[t.start(ts)] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan %s_default: %w", t.name, err)
+	}
+
+	var created []string
+	for start := range wanted {
+		name := t.partitionName(start)
+		if existing[name] {
+			continue
+		}
+		if err := r.createPartition(ctx, t, name, start, t.next(start)); err != nil {
+			return created, err
+		}
+		created = append(created, name)
+	}
+	return created, nil
+}
+
+func (r *PostgresRepository) createPartition(ctx context.Context, t partitionedTable, name string, from, to time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The partition is filled from the default partition before it is
+	// attached; attaching a range that still has rows in the default fails.
+	stmts := []string{
+		fmt.Sprintf(`CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS INCLUDING CONSTRAINTS)`, name, t.name),
+		fmt.Sprintf(`WITH moved AS (
+			DELETE FROM %s_default WHERE timestamp >= $1 AND timestamp < $2 RETURNING *
+		) INSERT INTO %s SELECT * FROM moved`, t.name, name),
+		fmt.Sprintf(`ALTER TABLE %s ATTACH PARTITION %s FOR VALUES FROM ('%s') TO ('%s')`,
+			t.name, name, from.Format(time.DateTime), to.Format(time.DateTime)),
+	}
+	for i, stmt := range stmts {
+		var err error
+		if i == 1 {
+			_, err = tx.ExecContext(ctx, stmt, from, to)
+		} else {
+			_, err = tx.ExecContext(ctx, stmt)
+		}
+		if err != nil {
+			logger.Error("failed to create partition", "partition", name, "error", err)
+			return fmt.Errorf("failed to create partition %s: %w", name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit partition %s: %w", name, err)
+	}
+	logger.Info("created partition", "partition", name, "from", from, "to", to)
+	return nil
+}
+
+// rollupHourly aggregates the finished hours of price_stats into
+// price_stats_hourly. Each exchange and pair resumes from its own last
+// hourly row, so a series that stalled is not skipped past when others move
+// on. That last hour is computed again, and rewritten if it changed, to take
+// in windows that were stored after it was first rolled up. Hourly rows are
+// stamped with the end of their hour, like the aggregator stamps windows.
+func (r *PostgresRepository) rollupHourly(ctx context.Context, now time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO price_stats_hourly (pair_name, exchange, timestamp, average_price, min_price, max_price)
+		SELECT s.pair_name, s.exchange, date_trunc('hour', s.timestamp) + INTERVAL '1 hour',
+		       AVG(s.average_price), MIN(s.min_price), MAX(s.max_price)
+		FROM price_stats s
+		LEFT JOIN (
+			SELECT pair_name, exchange, MAX(timestamp) AS last
+			FROM price_stats_hourly
+			GROUP BY pair_name, exchange
+		) h ON h.pair_name = s.pair_name AND h.exchange = s.exchange
+		WHERE s.timestamp >= COALESCE(h.last - INTERVAL '1 hour', '-infinity')
+		  AND s.timestamp < $1
+		GROUP BY s.pair_name, s.exchange, date_trunc('hour', s.timestamp)
+		ON CONFLICT (pair_name, exchange, timestamp) DO UPDATE
+		SET average_price = EXCLUDED.average_price,
+		    min_price = EXCLUDED.min_price,
+		    max_price = EXCLUDED.max_price
+		WHERE (price_stats_hourly.average_price, price_stats_hourly.min_price, price_stats_hourly.max_price)
+		      IS DISTINCT FROM (EXCLUDED.average_price, EXCLUDED.min_price, EXCLUDED.max_price)
+	`, now.Truncate(time.Hour))
+	if err != nil {
+		logger.Error("failed to roll up hourly stats", "error", err)
+		return 0, fmt.Errorf("failed to roll up hourly stats: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// applyRetention drops partitions that end before cutoff and deletes expired
+// rows still sitting in the default partition.
+func (r *PostgresRepository) applyRetention(ctx context.Context, t partitionedTable, cutoff time.Time) ([]string, int64, error) {
+	existing, err := r.partitions(ctx, t)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var dropped []string
+	for name := range existing {
+		start, ok := t.parse(name)
+		if !ok || t.next(start).After(cutoff) {
+			continue
+		}
+		if _, err := r.db.ExecContext(ctx, fmt.Sprintf(`DROP TABLE %s`, name)); err != nil {
+			logger.Error("failed to drop partition", "partition", name, "error", err)
+			return dropped, 0, fmt.Errorf("failed to drop partition %s: %w", name, err)
+		}
+		logger.Info("dropped expired partition", "partition", name)
+		dropped = append(dropped, name)
+	}
+
+	res, err := r.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s_default WHERE timestamp < $1`, t.name), cutoff)
+	if err != nil {
+		return dropped, 0, fmt.Errorf("failed to purge %s_default: %w", t.name, err)
+	}
+	purged, _ := res.RowsAffected()
+	return dropped, purged, nil
+}
+
+func (r *PostgresRepository) partitions(ctx context.Context, t partitionedTable) (map[string]bool, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		JOIN pg_class p ON p.oid = i.inhparent
+		WHERE p.relname = $1
+	`, t.name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions of %s: %w", t.name, err)
+	}
+	defer rows.Close()
+
+	out := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to list partitions of %s: %w", t.name, err)
+		}
+		out[name] = true
+	}
+	return out, rows.Err()
+}
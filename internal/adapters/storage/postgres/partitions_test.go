@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+// hourly returns the hourly average of exchange and pair ending at end, or
+// -1 when there is no such row.
+func hourly(t *testing.T, repo *PostgresRepository, exchange string, end time.Time) float64 {
+	t.Helper()
+	var avg float64
+	err := repo.db.QueryRowContext(context.Background(), `
+		SELECT COALESCE(MAX(average_price), -1) FROM price_stats_hourly
+		WHERE exchange = $1 AND pair_name = 'ROLLUSDT' AND timestamp = $2
+	`, exchange, end).Scan(&avg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return avg
+}
+
+func TestRollupHourlyPerSeries(t *testing.T) {
+	repo := testRepo(t, 0)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Hour)
+	run := time.Now().UnixNano()
+	busy, stalled := fmt.Sprintf("busy-%d", run), fmt.Sprintf("stalled-%d", run)
+	t.Cleanup(func() {
+		for _, table := range []string{"price_stats", "price_stats_hourly"} {
+			repo.db.ExecContext(ctx, "DELETE FROM "+table+" WHERE exchange IN ($1, $2)", busy, stalled)
+		}
+	})
+
+	// window stores one window of exchange in the hour ending end.
+	window := func(exchange string, end time.Time, minute int, avg float64) {
+		t.Helper()
+		stat := domain.PriceStats{
+			Exchange: exchange, Pair: "ROLLUSDT", Timestamp: end.Add(-time.Hour + time.Duration(minute)*time.Minute),
+			Average: avg, Min: avg, Max: avg,
+		}
+		if err := repo.StoreStats(ctx, stat); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h1, h2, h3 := now.Add(-2*time.Hour), now.Add(-time.Hour), now
+
+	window(busy, h1, 10, 100)
+	window(busy, h2, 10, 110)
+	window(busy, h3, 10, 120)
+	window(stalled, h1, 10, 200)
+	if _, err := repo.rollupHourly(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The stalled exchange comes back with the hours the busy one has
+	// already rolled up, and a late window lands in the busy one's last
+	// hour.
+	window(stalled, h2, 10, 210)
+	window(stalled, h3, 10, 220)
+	window(busy, h3, 20, 140)
+	if _, err := repo.rollupHourly(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		exchange string
+		end      time.Time
+		want     float64
+	}{
+		{busy, h1, 100},
+		{busy, h2, 110},
+		{busy, h3, 130},
+		{stalled, h1, 200},
+		{stalled, h2, 210},
+		{stalled, h3, 220},
+	} {
+		if got := hourly(t, repo, tc.exchange, tc.end); got != tc.want {
+			t.Errorf("%s hour ending %s: average %v, want %v", tc.exchange, tc.end.Format(time.TimeOnly), got, tc.want)
+		}
+	}
+
+	// Nothing changed, so nothing is written.
+	if n, err := repo.rollupHourly(ctx, time.Now()); err != nil || n != 0 {
+		t.Errorf("third rollup wrote %d rows (%v), want 0", n, err)
+	}
+}
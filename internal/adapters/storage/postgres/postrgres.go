@@ -3,44 +3,108 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
-	"strings"
+	"net"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 
 	"marketflow/internal/domain"
 	"marketflow/internal/logger"
 )
 
 type PostgresRepository struct {
-	db *sql.DB
+	db            *sql.DB
+	copyThreshold int
+	timeouts      Timeouts
 }
 
-func NewPostgresRepository(dsn string) (*PostgresRepository, error) {
+// Timeouts bound each repository call on top of the caller's context. Zero
+// means no extra deadline.
+type Timeouts struct {
+	Read  time.Duration
+	Write time.Duration
+	Batch time.Duration
+}
+
+type Options struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	// CopyThreshold is the batch size from which StoreStatsBatch switches
+	// from prepared INSERTs to COPY. Zero disables COPY.
+	CopyThreshold int
+	Timeouts      Timeouts
+}
+
+func NewPostgresRepository(dsn string, opts Options) (*PostgresRepository, error) {
 	db, err := sql.Open("postgres", dsn)
 	if err != nil {
 		logger.Error("failed to connect to postgres", "error", err)
 		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
 	}
 
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(5 * time.Minute)
+	db.SetMaxOpenConns(opts.MaxOpenConns)
+	db.SetMaxIdleConns(opts.MaxIdleConns)
+	db.SetConnMaxLifetime(opts.ConnMaxLifetime)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := db.PingContext(ctx); err != nil {
 		logger.Error("failed to ping postgres", "error", err)
-		return nil, fmt.Errorf("failed to ping postgres: %w", err)
+		return nil, fmt.Errorf("failed to ping postgres: %w: %w", domain.ErrUnavailable, err)
 	}
 
 	logger.Info("postgres connection established")
-	return &PostgresRepository{db: db}, nil
+	return &PostgresRepository{db: db, copyThreshold: opts.CopyThreshold, timeouts: opts.Timeouts}, nil
+}
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// finish classifies err for callers: a *domain.TimeoutError when ctx's
+// deadline caused it, domain.ErrUnavailable when Postgres could not be
+// reached, and err unchanged otherwise.
+func finish(ctx context.Context, op string, timeout time.Duration, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		logger.Error("postgres operation timed out", "op", op, "timeout", timeout)
+		return &domain.TimeoutError{Op: op, Timeout: timeout}
+	}
+	if isConnError(err) {
+		return fmt.Errorf("postgres %w: %w", domain.ErrUnavailable, err)
+	}
+	return err
 }
 
-func (r *PostgresRepository) StoreStats(stat domain.PriceStats) error {
-	ctx := context.Background()
+func isConnError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// 08: connection exception; 57P01-03: server shutting down or starting.
+		return pqErr.Code.Class() == "08" || pqErr.Code == "57P01" || pqErr.Code == "57P02" || pqErr.Code == "57P03"
+	}
+	return false
+}
+
+func (r *PostgresRepository) StoreStats(ctx context.Context, stat domain.PriceStats) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
 	query := `
 		INSERT INTO price_stats (pair_name, exchange, timestamp, average_price, min_price, max_price)
 		VALUES ($1, $2, $3, $4, $5, $6)
@@ -49,19 +113,31 @@ func (r *PostgresRepository) StoreStats(stat domain.PriceStats) error {
 	_, err := r.db.ExecContext(ctx, query, stat.Pair, stat.Exchange, stat.Timestamp, stat.Average, stat.Min, stat.Max)
 	if err != nil {
 		logger.Error("failed to store stats", "pair", stat.Pair, "exchange", stat.Exchange, "error", err)
-		return fmt.Errorf("failed to store stats: %w", err)
+		return finish(ctx, "store stats", r.timeouts.Write, fmt.Errorf("failed to store stats: %w", err))
 	}
 
 	logger.Info("stored stats", "pair", stat.Pair, "exchange", stat.Exchange, "timestamp", stat.Timestamp)
 	return nil
 }
 
-func (r *PostgresRepository) StoreStatsBatch(stats []domain.PriceStats) error {
+func (r *PostgresRepository) StoreStatsBatch(ctx context.Context, stats []domain.PriceStats) error {
 	if len(stats) == 0 {
 		return nil
 	}
 
-	ctx := context.Background()
+	ctx, cancel := withTimeout(ctx, r.timeouts.Batch)
+	defer cancel()
+
+	var err error
+	if r.copyThreshold > 0 && len(stats) >= r.copyThreshold {
+		err = r.copyStatsBatch(ctx, stats)
+	} else {
+		err = r.insertStatsBatch(ctx, stats)
+	}
+	return finish(ctx, "store batch stats", r.timeouts.Batch, err)
+}
+
+func (r *PostgresRepository) insertStatsBatch(ctx context.Context, stats []domain.PriceStats) error {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		logger.Error("failed to begin transaction", "error", err)
@@ -99,83 +175,61 @@ func (r *PostgresRepository) StoreStatsBatch(stats []domain.PriceStats) error {
 	return nil
 }
 
-func (r *PostgresRepository) StoreLargeStatsBatch(stats []domain.PriceStats) error {
-	if len(stats) == 0 {
-		return nil
-	}
+func (r *PostgresRepository) GetStats(ctx context.Context, pair, exchange string, since time.Time) ([]domain.PriceStats, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
 
-	ctx := context.Background()
-	tx, err := r.db.BeginTx(ctx, nil)
+	query := `
+		SELECT pair_name, exchange, timestamp, average_price, min_price, max_price
+		FROM price_stats
+		WHERE pair_name = $1 AND exchange = $2 AND timestamp >= $3
+		ORDER BY timestamp ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, pair, exchange, since)
 	if err != nil {
-		logger.Error("failed to begin transaction", "error", err)
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback()
-
-	valueStrings := make([]string, 0, len(stats))
-	valueArgs := make([]interface{}, 0, len(stats)*6)
-	for i, stat := range stats {
-		valueStrings = append(valueStrings, fmt.Sprintf("($%d,$%d,$%d,$%d,$%d,$%d)",
-			i*6+1, i*6+2, i*6+3, i*6+4, i*6+5, i*6+6))
-		valueArgs = append(valueArgs, stat.Pair, stat.Exchange, stat.Timestamp,
-			stat.Average, stat.Min, stat.Max)
+		logger.Error("failed to get stats", "pair", pair, "exchange", exchange, "error", err)
+		return nil, finish(ctx, "get stats", r.timeouts.Read, fmt.Errorf("failed to get stats: %w", err))
 	}
+	defer rows.Close()
 
-	query := fmt.Sprintf(`
-		INSERT INTO price_stats (pair_name, exchange, timestamp, average_price, min_price, max_price)
-		VALUES %s
-		ON CONFLICT (pair_name, exchange, timestamp) DO NOTHING
-	`, strings.Join(valueStrings, ","))
-
-	_, err = tx.ExecContext(ctx, query, valueArgs...)
+	stats, err := scanStats(rows)
 	if err != nil {
-		logger.Error("failed to execute bulk insert", "error", err)
-		return fmt.Errorf("failed to execute bulk insert: %w", err)
-	}
-
-	if err := tx.Commit(); err != nil {
-		logger.Error("failed to commit transaction", "error", err)
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return nil, finish(ctx, "get stats", r.timeouts.Read, err)
 	}
 
-	logger.Info("stored batch stats", "count", len(stats))
-	return nil
+	logger.Info("retrieved stats", "pair", pair, "exchange", exchange, "count", len(stats))
+	return stats, nil
 }
 
-func (r *PostgresRepository) GetStats(pair, exchange string, since time.Time) ([]domain.PriceStats, error) {
-	ctx := context.Background()
+// GetHourly reads the hourly rollups stamped after since, oldest first.
+func (r *PostgresRepository) GetHourly(ctx context.Context, exchange, pair string, since time.Time) ([]domain.PriceStats, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
 	query := `
 		SELECT pair_name, exchange, timestamp, average_price, min_price, max_price
-		FROM price_stats
-		WHERE pair_name = $1 AND exchange = $2 AND timestamp >= $3
+		FROM price_stats_hourly
+		WHERE pair_name = $1 AND exchange = $2 AND timestamp > $3
 		ORDER BY timestamp ASC
 	`
 	rows, err := r.db.QueryContext(ctx, query, pair, exchange, since)
 	if err != nil {
-		logger.Error("failed to get stats", "pair", pair, "exchange", exchange, "error", err)
-		return nil, fmt.Errorf("failed to get stats: %w", err)
+		logger.Error("failed to get hourly stats", "pair", pair, "exchange", exchange, "error", err)
+		return nil, finish(ctx, "get hourly stats", r.timeouts.Read, fmt.Errorf("failed to get hourly stats: %w", err))
 	}
 	defer rows.Close()
 
-	var stats []domain.PriceStats
-	for rows.Next() {
-		var s domain.PriceStats
-		if err := rows.Scan(&s.Pair, &s.Exchange, &s.Timestamp, &s.Average, &s.Min, &s.Max); err != nil {
-			logger.Error("failed to scan stats", "error", err)
-			return nil, fmt.Errorf("failed to scan stats: %w", err)
-		}
-		stats = append(stats, s)
-	}
-	if err := rows.Err(); err != nil {
-		logger.Error("rows error", "error", err)
-		return nil, fmt.Errorf("rows error: %w", err)
+	stats, err := scanStats(rows)
+	if err != nil {
+		return nil, finish(ctx, "get hourly stats", r.timeouts.Read, err)
 	}
-
-	logger.Info("retrieved stats", "pair", pair, "exchange", exchange, "count", len(stats))
 	return stats, nil
 }
 
 func (r *PostgresRepository) GetLatest(ctx context.Context, exchange, pair string) (domain.PriceStats, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
 	query := `
 		SELECT pair_name, exchange, timestamp, average_price, min_price, max_price
 		FROM price_stats
@@ -189,31 +243,158 @@ func (r *PostgresRepository) GetLatest(ctx context.Context, exchange, pair strin
 	)
 	if err == sql.ErrNoRows {
 		logger.Warn("no latest price found", "pair", pair, "exchange", exchange)
-		return domain.PriceStats{}, fmt.Errorf("no latest price for %s:%s", exchange, pair)
+		return domain.PriceStats{}, fmt.Errorf("no latest price for %s:%s: %w", exchange, pair, domain.ErrNotFound)
 	}
 	if err != nil {
 		logger.Error("failed to get latest price", "pair", pair, "exchange", exchange, "error", err)
-		return domain.PriceStats{}, fmt.Errorf("failed to get latest price: %w", err)
+		return domain.PriceStats{}, finish(ctx, "get latest price", r.timeouts.Read, fmt.Errorf("failed to get latest price: %w", err))
 	}
 
 	logger.Info("got latest price", "pair", pair, "exchange", exchange, "price", stats.Average)
 	return stats, nil
 }
 
+// GetByPeriod reads the windows within period, oldest first. The part of
+// the period older than the oldest window, which retention has dropped, is
+// answered from the hourly rollups.
 func (r *PostgresRepository) GetByPeriod(ctx context.Context, exchange, pair string, period time.Duration) ([]domain.PriceStats, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	// An hourly row covers the hour before its timestamp, so those stamped
+	// up to the oldest window do not overlap the windows.
 	query := `
 		SELECT pair_name, exchange, timestamp, average_price, min_price, max_price
 		FROM price_stats
 		WHERE pair_name = $1 AND exchange = $2 AND timestamp >= NOW() - $3::interval
+		UNION ALL
+		SELECT pair_name, exchange, timestamp, average_price, min_price, max_price
+		FROM price_stats_hourly
+		WHERE pair_name = $1 AND exchange = $2 AND timestamp >= NOW() - $3::interval
+		  AND timestamp <= (
+			SELECT COALESCE(MIN(timestamp), 'infinity')
+			FROM price_stats
+			WHERE pair_name = $1 AND exchange = $2
+		  )
 		ORDER BY timestamp ASC
 	`
 	rows, err := r.db.QueryContext(ctx, query, pair, exchange, fmt.Sprintf("%d seconds", int64(period/time.Second)))
 	if err != nil {
 		logger.Error("failed to get stats by period", "pair", pair, "exchange", exchange, "period", period, "error", err)
-		return nil, fmt.Errorf("failed to get stats by period: %w", err)
+		return nil, finish(ctx, "get stats by period", r.timeouts.Read, fmt.Errorf("failed to get stats by period: %w", err))
+	}
+	defer rows.Close()
+
+	stats, err := scanStats(rows)
+	if err != nil {
+		return nil, finish(ctx, "get stats by period", r.timeouts.Read, err)
+	}
+
+	logger.Info("retrieved stats by period", "pair", pair, "exchange", exchange, "period", period, "count", len(stats))
+	return stats, nil
+}
+
+// pairArrays splits keys into the parallel exchange and pair arrays the
+// batch queries unnest.
+func pairArrays(keys []domain.PairKey) (interface{}, interface{}) {
+	exchanges := make([]string, len(keys))
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		exchanges[i], pairs[i] = k.Exchange, k.Pair
+	}
+	return pq.Array(exchanges), pq.Array(pairs)
+}
+
+// GetLatestMany reads the newest window of every key in one query; each
+// lateral lookup uses the same index as GetLatest.
+func (r *PostgresRepository) GetLatestMany(ctx context.Context, keys []domain.PairKey) (map[domain.PairKey]domain.PriceStats, error) {
+	if len(keys) == 0 {
+		return map[domain.PairKey]domain.PriceStats{}, nil
+	}
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	query := `
+		SELECT s.pair_name, s.exchange, s.timestamp, s.average_price, s.min_price, s.max_price
+		FROM unnest($1::text[], $2::text[]) AS k(exchange, pair_name)
+		CROSS JOIN LATERAL (
+			SELECT pair_name, exchange, timestamp, average_price, min_price, max_price
+			FROM price_stats
+			WHERE pair_name = k.pair_name AND exchange = k.exchange
+			ORDER BY timestamp DESC
+			LIMIT 1
+		) s
+	`
+	exchanges, pairs := pairArrays(keys)
+	rows, err := r.db.QueryContext(ctx, query, exchanges, pairs)
+	if err != nil {
+		logger.Error("failed to get latest prices", "pairs", len(keys), "error", err)
+		return nil, finish(ctx, "get latest prices", r.timeouts.Read, fmt.Errorf("failed to get latest prices: %w", err))
 	}
 	defer rows.Close()
 
+	stats, err := scanStats(rows)
+	if err != nil {
+		return nil, finish(ctx, "get latest prices", r.timeouts.Read, err)
+	}
+	out := make(map[domain.PairKey]domain.PriceStats, len(stats))
+	for _, s := range stats {
+		out[domain.PairKey{Exchange: s.Exchange, Pair: s.Pair}] = s
+	}
+	logger.Info("got latest prices", "requested", len(keys), "found", len(out))
+	return out, nil
+}
+
+// GetByPeriodMany reads the windows of every key within period in one
+// query, each key's oldest first. Like GetByPeriod, hourly rollups answer
+// the part of the period older than a key's oldest window.
+func (r *PostgresRepository) GetByPeriodMany(ctx context.Context, keys []domain.PairKey, period time.Duration) (map[domain.PairKey][]domain.PriceStats, error) {
+	if len(keys) == 0 {
+		return map[domain.PairKey][]domain.PriceStats{}, nil
+	}
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	query := `
+		SELECT s.pair_name, s.exchange, s.timestamp, s.average_price, s.min_price, s.max_price
+		FROM price_stats s
+		JOIN unnest($1::text[], $2::text[]) AS k(exchange, pair_name)
+			ON s.pair_name = k.pair_name AND s.exchange = k.exchange
+		WHERE s.timestamp >= NOW() - $3::interval
+		UNION ALL
+		SELECT h.pair_name, h.exchange, h.timestamp, h.average_price, h.min_price, h.max_price
+		FROM unnest($1::text[], $2::text[]) AS k(exchange, pair_name)
+		CROSS JOIN LATERAL (
+			SELECT COALESCE(MIN(timestamp), 'infinity') AS oldest
+			FROM price_stats
+			WHERE pair_name = k.pair_name AND exchange = k.exchange
+		) raw
+		JOIN price_stats_hourly h ON h.pair_name = k.pair_name AND h.exchange = k.exchange
+		WHERE h.timestamp >= NOW() - $3::interval AND h.timestamp <= raw.oldest
+		ORDER BY exchange, pair_name, timestamp ASC
+	`
+	exchanges, pairs := pairArrays(keys)
+	rows, err := r.db.QueryContext(ctx, query, exchanges, pairs, fmt.Sprintf("%d seconds", int64(period/time.Second)))
+	if err != nil {
+		logger.Error("failed to get stats by period", "pairs", len(keys), "period", period, "error", err)
+		return nil, finish(ctx, "get stats by period", r.timeouts.Read, fmt.Errorf("failed to get stats by period: %w", err))
+	}
+	defer rows.Close()
+
+	stats, err := scanStats(rows)
+	if err != nil {
+		return nil, finish(ctx, "get stats by period", r.timeouts.Read, err)
+	}
+	out := make(map[domain.PairKey][]domain.PriceStats)
+	for _, s := range stats {
+		k := domain.PairKey{Exchange: s.Exchange, Pair: s.Pair}
+		out[k] = append(out[k], s)
+	}
+	logger.Info("retrieved stats by period", "pairs", len(keys), "period", period, "count", len(stats))
+	return out, nil
+}
+
+func scanStats(rows *sql.Rows) ([]domain.PriceStats, error) {
 	var stats []domain.PriceStats
 	for rows.Next() {
 		var s domain.PriceStats
@@ -227,8 +408,6 @@ func (r *PostgresRepository) GetByPeriod(ctx context.Context, exchange, pair str
 		logger.Error("rows error", "error", err)
 		return nil, fmt.Errorf("rows error: %w", err)
 	}
-
-	logger.Info("retrieved stats by period", "pair", pair, "exchange", exchange, "period", period, "count", len(stats))
 	return stats, nil
 }
 
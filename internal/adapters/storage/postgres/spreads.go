@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"marketflow/internal/domain"
+)
+
+// StoreSpreads inserts samples in one statement; a second sample for the
+// same pair and time is ignored.
+func (r *PostgresRepository) StoreSpreads(ctx context.Context, samples []domain.SpreadSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	n := len(samples)
+	pairs, highExchanges, lowExchanges := make([]string, n), make([]string, n), make([]string, n)
+	times := make([]string, n) // pq has no typed array for timestamps
+	highs, lows, bps := make([]float64, n), make([]float64, n), make([]float64, n)
+	exchanges := make([]int64, n)
+	for i, s := range samples {
+		pairs[i], times[i] = s.Pair, s.Time.Format(time.RFC3339Nano)
+		highs[i], highExchanges[i] = s.High, s.HighExchange
+		lows[i], lowExchanges[i] = s.Low, s.LowExchange
+		bps[i], exchanges[i] = s.Bps, int64(s.Exchanges)
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO spread_history (pair_name, time, high_price, high_exchange, low_price, low_exchange, spread_bps, exchanges)
+		SELECT * FROM unnest($1::text[], $2::timestamptz[], $3::float8[], $4::text[], $5::float8[], $6::text[], $7::float8[], $8::int[])
+		ON CONFLICT (pair_name, time) DO NOTHING
+	`, pq.Array(pairs), pq.Array(times), pq.Array(highs), pq.Array(highExchanges),
+		pq.Array(lows), pq.Array(lowExchanges), pq.Array(bps), pq.Array(exchanges))
+	if err != nil {
+		return finish(ctx, "store spreads", r.timeouts.Write, fmt.Errorf("failed to store spreads: %w", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetSpreads(ctx context.Context, pair string, since time.Time) ([]domain.SpreadSample, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT pair_name, time, high_price, high_exchange, low_price, low_exchange, spread_bps, exchanges
+		FROM spread_history
+		WHERE pair_name = $1 AND time >= $2
+		ORDER BY time ASC
+	`, pair, since)
+	if err != nil {
+		return nil, finish(ctx, "get spreads", r.timeouts.Read, fmt.Errorf("failed to get spreads: %w", err))
+	}
+	defer rows.Close()
+
+	var samples []domain.SpreadSample
+	for rows.Next() {
+		var s domain.SpreadSample
+		if err := rows.Scan(&s.Pair, &s.Time, &s.High, &s.HighExchange, &s.Low, &s.LowExchange, &s.Bps, &s.Exchanges); err != nil {
+			return nil, finish(ctx, "get spreads", r.timeouts.Read, fmt.Errorf("failed to scan spread: %w", err))
+		}
+		samples = append(samples, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, finish(ctx, "get spreads", r.timeouts.Read, err)
+	}
+	return samples, nil
+}
+
+func (r *PostgresRepository) PruneSpreads(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM spread_history WHERE time < $1`, before)
+	if err != nil {
+		return 0, finish(ctx, "prune spreads", r.timeouts.Write, fmt.Errorf("failed to prune spreads: %w", err))
+	}
+	return res.RowsAffected()
+}
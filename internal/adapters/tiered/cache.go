@@ -0,0 +1,282 @@
+package tiered
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"marketflow/internal/adapters/memory"
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+// Remote is the shared cache behind the in-process tier, i.e. Redis. It
+// holds what the tier reads and writes through; leases, rate limits and
+// subscriptions are taken from the Redis adapter itself.
+type Remote interface {
+	domain.Cache
+	domain.BatchCache
+	domain.TickWindow
+	domain.CacheCleaner
+	SetLatestBatch(ctx context.Context, updates []domain.PriceUpdate) error
+	AddTicks(ctx context.Context, ticks []domain.PriceUpdate) error
+	SetTTL(ttl time.Duration)
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// Cache serves latest prices from process memory and writes them behind to
+// a shared remote cache. Ticks only touch the local map; every interval the
+// latest update per key since the last flush is sent to the remote in one
+// batch. Keys this process has not seen are read from the remote, so
+// instances share prices.
+//
+// Ticks for the rolling window are recorded the same way: kept locally and
+// appended to (and published by) the remote on flush. GetTicks answers from memory when this
+// process has been recording for the whole requested period. With a tick
+// horizon of 0 there is no window and no ticks are kept.
+//
+// When the remote fails, local reads keep working, unsent updates are kept
+// for the next flush and Health reports the failure until a flush or ping
+// succeeds.
+type Cache struct {
+	local    *memory.Cache
+	remote   Remote
+	interval time.Duration
+	horizon  time.Duration
+
+	started time.Time
+
+	mu    sync.Mutex
+	dirty map[string]domain.PriceUpdate
+	ticks []domain.PriceUpdate
+
+	remoteErr atomic.Pointer[error]
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+func New(remote Remote, ttl, interval time.Duration) *Cache {
+	return &Cache{
+		local:    memory.NewCache(ttl, remote.TickHorizon()),
+		remote:   remote,
+		interval: interval,
+		horizon:  remote.TickHorizon(),
+		started:  time.Now(),
+		dirty:    make(map[string]domain.PriceUpdate),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+func key(exchange, pair string) string {
+	return exchange + ":" + pair
+}
+
+func (c *Cache) SetLatest(ctx context.Context, update domain.PriceUpdate) error {
+	if err := c.local.SetLatest(ctx, update); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.dirty[key(update.Exchange, update.Pair)] = update
+	if c.horizon > 0 {
+		c.ticks = append(c.ticks, update)
+	}
+	c.mu.Unlock()
+	return nil
+}
+
+// Observe records an update received from another instance in the local
+// tier only; it is already in the remote.
+func (c *Cache) Observe(update domain.PriceUpdate) {
+	c.local.SetLatest(context.Background(), update)
+}
+
+func (c *Cache) GetLatest(ctx context.Context, exchange, pair string) (domain.PriceUpdate, error) {
+	if update, err := c.local.GetLatest(ctx, exchange, pair); err == nil {
+		return update, nil
+	}
+	return c.remote.GetLatest(ctx, exchange, pair)
+}
+
+// GetLatestMany answers what it can from memory and reads the rest from the
+// remote in one round trip.
+func (c *Cache) GetLatestMany(ctx context.Context, keys []domain.PairKey) (map[domain.PairKey]domain.PriceUpdate, error) {
+	out := make(map[domain.PairKey]domain.PriceUpdate, len(keys))
+	var missing []domain.PairKey
+	for _, k := range keys {
+		if update, err := c.local.GetLatest(ctx, k.Exchange, k.Pair); err == nil {
+			out[k] = update
+		} else {
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) == 0 {
+		return out, nil
+	}
+	remote, err := c.remote.GetLatestMany(ctx, missing)
+	for k, update := range remote {
+		out[k] = update
+	}
+	return out, err
+}
+
+func (c *Cache) GetTicks(ctx context.Context, exchange, pair string, since time.Time) ([]domain.PriceUpdate, error) {
+	if !since.Before(c.started) {
+		return c.local.GetTicks(ctx, exchange, pair, since)
+	}
+	ticks, err := c.remote.GetTicks(ctx, exchange, pair, since)
+	if err != nil {
+		return c.local.GetTicks(ctx, exchange, pair, since)
+	}
+	return ticks, nil
+}
+
+func (c *Cache) TickHorizon() time.Duration {
+	return c.remote.TickHorizon()
+}
+
+func (c *Cache) SetTTL(ttl time.Duration) {
+	c.local.SetTTL(ttl)
+	c.remote.SetTTL(ttl)
+}
+
+// CleanStale cleans the local tier and then the remote; the report adds up
+// both.
+func (c *Cache) CleanStale(ctx context.Context, staleAfter time.Duration) (domain.CacheCleanupReport, error) {
+	report, _ := c.local.CleanStale(ctx, staleAfter)
+	remote, err := c.remote.CleanStale(ctx, staleAfter)
+	report.Checked += remote.Checked
+	report.Removed += remote.Removed
+	return report, err
+}
+
+// Health returns nil while the remote is reachable and the last remote
+// error otherwise.
+func (c *Cache) Health() error {
+	if err := c.remoteErr.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+// Start flushes pending updates to the remote every interval until ctx is
+// done or Close is called.
+func (c *Cache) Start(ctx context.Context) {
+	defer close(c.stopped)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	logger.Info("starting cache write-behind", "interval", c.interval)
+	for {
+		select {
+		case <-ctx.Done():
+			c.flush(context.WithoutCancel(ctx))
+			return
+		case <-c.done:
+			c.flush(context.Background())
+			return
+		case <-ticker.C:
+			c.flush(ctx)
+		}
+	}
+}
+
+func (c *Cache) flush(ctx context.Context) {
+	c.mu.Lock()
+	pending, ticks := c.dirty, c.ticks
+	c.dirty = make(map[string]domain.PriceUpdate, len(pending))
+	c.ticks = nil
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.interval+time.Second)
+	defer cancel()
+
+	var err error
+	if len(pending) == 0 && len(ticks) == 0 {
+		if c.Health() == nil {
+			return
+		}
+		err = c.remote.Ping(ctx)
+	} else {
+		err = c.push(ctx, pending, ticks)
+	}
+	c.setRemoteErr(err)
+}
+
+// push writes the latest prices and then the ticks. Only what failed is
+// requeued, so ticks that fail to go out do not send the latest prices
+// again.
+func (c *Cache) push(ctx context.Context, pending map[string]domain.PriceUpdate, ticks []domain.PriceUpdate) error {
+	if len(pending) > 0 {
+		updates := make([]domain.PriceUpdate, 0, len(pending))
+		for _, update := range pending {
+			updates = append(updates, update)
+		}
+		if err := c.remote.SetLatestBatch(ctx, updates); err != nil {
+			c.requeue(pending, ticks)
+			return err
+		}
+	}
+	if len(ticks) == 0 {
+		return nil
+	}
+	if err := c.remote.AddTicks(ctx, ticks); err != nil {
+		c.requeue(nil, ticks)
+		return err
+	}
+	return nil
+}
+
+// requeue puts failed updates back unless a newer tick replaced them. Ticks
+// that have left the window by now are dropped, which bounds what an outage
+// can pile up.
+func (c *Cache) requeue(pending map[string]domain.PriceUpdate, ticks []domain.PriceUpdate) {
+	cutoff := time.Now().Add(-c.horizon)
+	kept := ticks[:0]
+	for _, t := range ticks {
+		if !t.Time.Before(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, update := range pending {
+		if _, ok := c.dirty[k]; !ok {
+			c.dirty[k] = update
+		}
+	}
+	c.ticks = append(kept, c.ticks...)
+}
+
+func (c *Cache) setRemoteErr(err error) {
+	prev := c.remoteErr.Load()
+	switch {
+	case err == nil && prev != nil:
+		c.remoteErr.Store(nil)
+		logger.Info("redis recovered, write-behind resumed")
+	case err != nil && prev == nil:
+		c.remoteErr.Store(&err)
+		logger.Warn("redis unavailable, serving latest prices from memory", "error", err)
+	case err != nil:
+		c.remoteErr.Store(&err)
+	}
+}
+
+// Close flushes what is pending and closes the remote.
+func (c *Cache) Close() error {
+	select {
+	case <-c.done:
+	default:
+		close(c.done)
+	}
+	select {
+	case <-c.stopped:
+	case <-time.After(c.interval + 2*time.Second):
+		logger.Warn("cache write-behind did not stop in time")
+	}
+	return c.remote.Close()
+}
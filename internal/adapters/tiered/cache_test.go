@@ -0,0 +1,115 @@
+package tiered
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.InitWithWriter(io.Discard, "test", "error")
+	os.Exit(m.Run())
+}
+
+// fakeRemote records what the tier writes and fails AddTicks while
+// failTicks is set. Its tick horizon is a minute unless noWindow is set.
+type fakeRemote struct {
+	domain.Cache
+	domain.BatchCache
+	domain.CacheCleaner
+
+	failTicks bool
+	noWindow  bool
+	latest    [][]domain.PriceUpdate
+	ticks     [][]domain.PriceUpdate
+}
+
+func (f *fakeRemote) SetLatestBatch(ctx context.Context, updates []domain.PriceUpdate) error {
+	f.latest = append(f.latest, updates)
+	return nil
+}
+
+func (f *fakeRemote) AddTicks(ctx context.Context, ticks []domain.PriceUpdate) error {
+	if f.failTicks {
+		return errors.New("redis down")
+	}
+	f.ticks = append(f.ticks, ticks)
+	return nil
+}
+
+func (f *fakeRemote) GetTicks(ctx context.Context, exchange, pair string, since time.Time) ([]domain.PriceUpdate, error) {
+	return nil, nil
+}
+
+func (f *fakeRemote) TickHorizon() time.Duration {
+	if f.noWindow {
+		return 0
+	}
+	return time.Minute
+}
+
+func (f *fakeRemote) SetTTL(ttl time.Duration)       {}
+func (f *fakeRemote) Ping(ctx context.Context) error { return nil }
+func (f *fakeRemote) Close() error                   { return nil }
+
+func TestFlushRequeuesOnlyFailedTicks(t *testing.T) {
+	remote := &fakeRemote{failTicks: true}
+	c := New(remote, time.Minute, time.Second)
+	ctx := context.Background()
+	tick := domain.PriceUpdate{Exchange: "ex1", Pair: "BTCUSDT", Price: 100, Time: time.Now()}
+	c.SetLatest(ctx, tick)
+
+	c.flush(ctx)
+	if len(remote.latest) != 1 || len(remote.ticks) != 0 {
+		t.Fatalf("first flush: %d latest batches, %d tick batches; want 1 and 0", len(remote.latest), len(remote.ticks))
+	}
+	if c.Health() == nil {
+		t.Error("Health is nil after a failed flush")
+	}
+
+	remote.failTicks = false
+	c.flush(ctx)
+	if len(remote.latest) != 1 {
+		t.Errorf("latest prices were sent again: %d batches", len(remote.latest))
+	}
+	if len(remote.ticks) != 1 || len(remote.ticks[0]) != 1 || remote.ticks[0][0] != tick {
+		t.Errorf("ticks = %v, want the requeued tick once", remote.ticks)
+	}
+	if err := c.Health(); err != nil {
+		t.Errorf("Health = %v after a successful flush", err)
+	}
+
+	c.flush(ctx)
+	if len(remote.latest) != 1 || len(remote.ticks) != 1 {
+		t.Errorf("an idle flush wrote again: %d latest, %d tick batches", len(remote.latest), len(remote.ticks))
+	}
+}
+
+func TestNoTicksWithoutWindow(t *testing.T) {
+	remote := &fakeRemote{noWindow: true}
+	c := New(remote, time.Minute, time.Second)
+	ctx := context.Background()
+	for i := range 3 {
+		c.SetLatest(ctx, domain.PriceUpdate{Exchange: "ex1", Pair: "BTCUSDT", Price: float64(100 + i), Time: time.Now()})
+	}
+	if len(c.ticks) != 0 {
+		t.Errorf("%d ticks buffered with a tick horizon of 0", len(c.ticks))
+	}
+
+	c.flush(ctx)
+	if len(remote.latest) != 1 || len(remote.latest[0]) != 1 || remote.latest[0][0].Price != 102 {
+		t.Errorf("latest batches = %v, want the last price once", remote.latest)
+	}
+	if len(remote.ticks) != 0 {
+		t.Errorf("ticks were sent: %v", remote.ticks)
+	}
+	if err := c.Health(); err != nil {
+		t.Errorf("Health = %v after a successful flush", err)
+	}
+}
@@ -0,0 +1,127 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"marketflow/internal/app/mode"
+	"marketflow/internal/config"
+	"marketflow/internal/domain"
+	"marketflow/pkg/api"
+)
+
+func keyResponse(key domain.APIKey) api.Key {
+	return api.Key{ID: key.ID, Name: key.Name, Scopes: key.Scopes, CreatedAt: key.CreatedAt}
+}
+
+// handleKeys serves GET and POST /admin/keys and DELETE /admin/keys/{id}.
+func (s *Server) handleKeys(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/keys"), "/")
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		s.listKeys(w, r)
+	case id == "" && r.Method == http.MethodPost:
+		s.createKey(w, r)
+	case id != "" && r.Method == http.MethodDelete:
+		s.deleteKey(w, r, id)
+	default:
+		respondError(w, r, errMethodNotAllowed)
+	}
+}
+
+func (s *Server) listKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := s.auth.Keys.ListKeys(r.Context())
+	s.audit(r, "keys.list", "", err)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+
+	out := make([]api.Key, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, keyResponse(key))
+	}
+	respondJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) createKey(w http.ResponseWriter, r *http.Request) {
+	var req api.KeyRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		respondError(w, r, invalidArgument(fmt.Errorf("invalid request body: %w", err)))
+		return
+	}
+
+	key, secret, err := s.auth.Create(r.Context(), req.Name, req.Scopes)
+	target := req.Name
+	if err == nil {
+		target = key.ID
+	}
+	s.audit(r, "keys.create", target, err)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+
+	resp := keyResponse(key)
+	resp.Secret = secret
+	respondJSON(w, http.StatusCreated, resp)
+}
+
+func (s *Server) deleteKey(w http.ResponseWriter, r *http.Request, id string) {
+	err := s.auth.Keys.DeleteKey(r.Context(), id)
+	s.audit(r, "keys.delete", id, err)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleExchanges serves GET and PUT /admin/exchanges.
+func (s *Server) handleExchanges(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.listExchanges(w, r)
+	case http.MethodPut:
+		s.updateExchanges(w, r)
+	default:
+		respondError(w, r, errMethodNotAllowed)
+	}
+}
+
+func (s *Server) listExchanges(w http.ResponseWriter, r *http.Request) {
+	s.audit(r, "exchanges.list", "", nil)
+	respondJSON(w, http.StatusOK, exchangesResponse(s.manager.Exchanges()))
+}
+
+func (s *Server) updateExchanges(w http.ResponseWriter, r *http.Request) {
+	var req []api.Exchange
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		respondError(w, r, invalidArgument(fmt.Errorf("invalid request body: %w", err)))
+		return
+	}
+
+	exchanges := make([]config.Exchange, len(req))
+	names := make([]string, len(req))
+	for i, ex := range req {
+		exchanges[i] = config.Exchange{Name: ex.Name, Address: ex.Address}
+		names[i] = ex.Name
+	}
+	err := s.manager.SetExchanges(exchanges)
+	s.audit(r, "exchanges.update", strings.Join(names, ","), err)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, exchangesResponse(s.manager.Exchanges()))
+}
+
+func exchangesResponse(states []mode.ExchangeState) []api.Exchange {
+	out := make([]api.Exchange, 0, len(states))
+	for _, st := range states {
+		out = append(out, api.Exchange{Name: st.Name, Address: st.Address, Active: st.Active})
+	}
+	return out
+}
@@ -0,0 +1,108 @@
+package web_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/memory"
+	"marketflow/internal/adapters/web"
+	"marketflow/internal/app/auth"
+	"marketflow/internal/app/hub"
+	"marketflow/internal/app/mode"
+	"marketflow/internal/config"
+	"marketflow/internal/domain"
+	"marketflow/pkg/api"
+)
+
+// scopedKeys grants each secret the scope of the same name.
+type scopedKeys struct{ domain.KeyStore }
+
+func (scopedKeys) FindKey(ctx context.Context, hash string) (domain.APIKey, error) {
+	for _, scope := range auth.Scopes {
+		if hash == auth.Hash(scope) {
+			return domain.APIKey{ID: scope, Scopes: []string{scope}}, nil
+		}
+	}
+	return domain.APIKey{}, domain.ErrNotFound
+}
+
+type auditLog struct{ entries []domain.AuditEntry }
+
+func (a *auditLog) RecordAudit(ctx context.Context, entry domain.AuditEntry) error {
+	a.entries = append(a.entries, entry)
+	return nil
+}
+
+func send(t *testing.T, method, url, key, body string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("X-API-Key", key)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestExchangeManagement(t *testing.T) {
+	manager := mode.NewManager(&config.Config{
+		Exchanges: []config.Exchange{{Name: "ex1", Address: "127.0.0.1:40101"}},
+		Ingest:    config.IngestConfig{TestInterval: time.Hour},
+	})
+	t.Cleanup(manager.Stop)
+	audit := &auditLog{}
+	opts := web.Options{Auth: auth.NewService(scopedKeys{}, audit)}
+	srv := httptest.NewServer(web.NewServer(memory.NewRepository(), memory.NewCache(time.Minute, 0), manager, hub.New(), opts).Router(nil))
+	t.Cleanup(srv.Close)
+	url := srv.URL + "/admin/exchanges"
+
+	if resp := send(t, http.MethodGet, url, auth.AdminMode, ""); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("GET with an admin:mode key: status %d, want 403", resp.StatusCode)
+	}
+
+	var listed []api.Exchange
+	resp := send(t, http.MethodGet, url, auth.AdminExchanges, "")
+	if err := json.NewDecoder(resp.Body).Decode(&listed); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET: status %d, %v", resp.StatusCode, err)
+	}
+	if len(listed) != 1 || listed[0].Name != "ex1" || listed[0].Active {
+		t.Errorf("GET = %+v, want ex1, inactive outside live mode", listed)
+	}
+
+	for _, body := range []string{`[]`, `[{"name": "ex2", "address": "nowhere"}]`, `[{"name": "a", "address": "h:1"}, {"name": "a", "address": "h:2"}]`, `{`} {
+		if resp := send(t, http.MethodPut, url, auth.AdminExchanges, body); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("PUT %s: status %d, want 400", body, resp.StatusCode)
+		}
+	}
+
+	resp = send(t, http.MethodPut, url, auth.AdminExchanges, `[{"name": "ex2", "address": "127.0.0.1:40102"}]`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("PUT: status %d", resp.StatusCode)
+	}
+	if got := manager.Exchanges(); len(got) != 1 || got[0].Name != "ex2" || got[0].Address != "127.0.0.1:40102" {
+		t.Errorf("manager exchanges = %+v, want ex2 only", got)
+	}
+
+	manager.Standby()
+	if resp := send(t, http.MethodPut, url, auth.AdminExchanges, `[{"name": "ex3", "address": "127.0.0.1:40103"}]`); resp.StatusCode != http.StatusConflict {
+		t.Errorf("PUT on a follower: status %d, want 409", resp.StatusCode)
+	}
+
+	var actions []string
+	for _, e := range audit.entries {
+		if e.KeyID != auth.AdminExchanges {
+			t.Errorf("audit entry %+v by another key", e)
+		}
+		actions = append(actions, e.Action+" "+e.Target)
+	}
+	want := "exchanges.list |exchanges.update |exchanges.update ex2|exchanges.update a,a|exchanges.update ex2|exchanges.update ex3"
+	if got := strings.Join(actions, "|"); got != want {
+		t.Errorf("audit = %s\nwant    %s", got, want)
+	}
+}
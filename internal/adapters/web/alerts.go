@@ -0,0 +1,162 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/pkg/api"
+)
+
+const (
+	defaultDeliveries = 50
+	maxDeliveries     = 500
+)
+
+func alertResponse(a domain.Alert) api.Alert {
+	out := api.Alert{
+		ID:         a.ID,
+		Name:       a.Name,
+		Kind:       a.Kind,
+		Exchange:   a.Exchange,
+		Pair:       a.Pair,
+		Threshold:  a.Threshold,
+		WebhookURL: a.WebhookURL,
+		Cooldown:   a.Cooldown.String(),
+		CreatedAt:  a.CreatedAt,
+	}
+	if a.Period > 0 {
+		out.Period = a.Period.String()
+	}
+	return out
+}
+
+// handleAlerts serves GET and POST /alerts, GET and DELETE /alerts/{id} and
+// GET /alerts/{id}/deliveries.
+func (s *Server) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/alerts"), "/")
+	id, sub, _ := strings.Cut(rest, "/")
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		s.listAlerts(w, r)
+	case id == "" && r.Method == http.MethodPost:
+		s.createAlert(w, r)
+	case id != "" && sub == "" && r.Method == http.MethodGet:
+		s.getAlert(w, r, id)
+	case id != "" && sub == "" && r.Method == http.MethodDelete:
+		s.deleteAlert(w, r, id)
+	case id != "" && sub == "deliveries" && r.Method == http.MethodGet:
+		s.listDeliveries(w, r, id)
+	case sub != "" && sub != "deliveries":
+		respondError(w, r, fmt.Errorf("invalid URL %q: %w", r.URL.Path, domain.ErrNotFound))
+	default:
+		respondError(w, r, errMethodNotAllowed)
+	}
+}
+
+func (s *Server) listAlerts(w http.ResponseWriter, r *http.Request) {
+	alerts, err := s.alerts.Store.ListAlerts(r.Context())
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	out := make([]api.Alert, 0, len(alerts))
+	for _, a := range alerts {
+		out = append(out, alertResponse(a))
+	}
+	respondJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) createAlert(w http.ResponseWriter, r *http.Request) {
+	var req api.AlertRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		respondError(w, r, invalidArgument(fmt.Errorf("invalid request body: %w", err)))
+		return
+	}
+	alert := domain.Alert{
+		Name:       req.Name,
+		Kind:       req.Kind,
+		Exchange:   req.Exchange,
+		Pair:       req.Pair,
+		Threshold:  req.Threshold,
+		WebhookURL: req.WebhookURL,
+	}
+	var err error
+	if req.Period != "" {
+		if alert.Period, err = time.ParseDuration(req.Period); err != nil {
+			respondError(w, r, invalidArgument(fmt.Errorf("invalid period %q", req.Period)))
+			return
+		}
+	}
+	if req.Cooldown != "" {
+		if alert.Cooldown, err = time.ParseDuration(req.Cooldown); err != nil {
+			respondError(w, r, invalidArgument(fmt.Errorf("invalid cooldown %q", req.Cooldown)))
+			return
+		}
+	}
+
+	alert, err = s.alerts.Create(r.Context(), alert)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	resp := alertResponse(alert)
+	resp.Secret = alert.Secret
+	respondJSON(w, http.StatusCreated, resp)
+}
+
+func (s *Server) getAlert(w http.ResponseWriter, r *http.Request, id string) {
+	alert, err := s.alerts.Store.GetAlert(r.Context(), id)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, alertResponse(alert))
+}
+
+func (s *Server) deleteAlert(w http.ResponseWriter, r *http.Request, id string) {
+	if err := s.alerts.Store.DeleteAlert(r.Context(), id); err != nil {
+		respondError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listDeliveries returns the alert's latest deliveries, newest first, up to
+// ?limit= (default 50, at most 500).
+func (s *Server) listDeliveries(w http.ResponseWriter, r *http.Request, id string) {
+	limit := defaultDeliveries
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxDeliveries {
+			respondError(w, r, invalidArgument(fmt.Errorf("limit must be between 1 and %d, got %q", maxDeliveries, v)))
+			return
+		}
+		limit = n
+	}
+	if _, err := s.alerts.Store.GetAlert(r.Context(), id); err != nil {
+		respondError(w, r, err)
+		return
+	}
+
+	deliveries, err := s.alerts.Store.ListDeliveries(r.Context(), id, limit)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	out := make([]api.AlertDelivery, 0, len(deliveries))
+	for _, d := range deliveries {
+		out = append(out, api.AlertDelivery{
+			EventID:    d.EventID,
+			Time:       d.Time,
+			Attempts:   d.Attempts,
+			StatusCode: d.StatusCode,
+			Error:      d.Error,
+		})
+	}
+	respondJSON(w, http.StatusOK, out)
+}
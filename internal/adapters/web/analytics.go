@@ -0,0 +1,71 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/pkg/api"
+)
+
+// handleSpread serves GET /analytics/spread/{pair}[?period=]. Without a
+// period only the current spread is returned.
+func (s *Server) handleSpread(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, r, errMethodNotAllowed)
+		return
+	}
+	pair := strings.Trim(strings.TrimPrefix(r.URL.Path, "/analytics/spread"), "/")
+	if pair == "" || strings.Contains(pair, "/") {
+		respondError(w, r, fmt.Errorf("invalid URL %q: %w", r.URL.Path, domain.ErrInvalidArgument))
+		return
+	}
+
+	resp := api.Spread{Pair: pair}
+	current, prices, err := s.spreads.Spread(pair)
+	if err == nil {
+		point := spreadPoint(current)
+		resp.Current = &point
+		resp.Prices = make([]api.ExchangePrice, len(prices))
+		for i, p := range prices {
+			resp.Prices[i] = api.ExchangePrice{Exchange: p.Exchange, Price: p.Price, Time: p.Time}
+		}
+	}
+
+	if r.URL.Query().Get("period") != "" && s.spreadHistory != nil {
+		period, err := periodParam(r)
+		if err != nil {
+			respondError(w, r, err)
+			return
+		}
+		samples, err := s.spreadHistory.GetSpreads(r.Context(), pair, time.Now().Add(-period))
+		if err != nil {
+			respondError(w, r, err)
+			return
+		}
+		resp.History = make([]api.SpreadPoint, len(samples))
+		for i, sample := range samples {
+			resp.History[i] = spreadPoint(sample)
+		}
+	}
+
+	if resp.Current == nil && len(resp.History) == 0 {
+		respondError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func spreadPoint(s domain.SpreadSample) api.SpreadPoint {
+	return api.SpreadPoint{
+		Time:         s.Time,
+		Bps:          s.Bps,
+		High:         s.High,
+		HighExchange: s.HighExchange,
+		Low:          s.Low,
+		LowExchange:  s.LowExchange,
+		Exchanges:    s.Exchanges,
+	}
+}
@@ -0,0 +1,82 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"marketflow/internal/domain"
+)
+
+const apiKeyKey ctxKey = requestIDKey + 1
+
+// requireScope lets a request through only if it carries an API key with
+// scope, in "Authorization: Bearer <key>" or "X-API-Key". Browsers cannot
+// set headers on a WebSocket handshake, so that also accepts ?api_key=.
+// Everything passes when authentication is disabled. With rate limiting on,
+// requests whose key is missing or unknown are charged to the peer's IP
+// address, and once it runs dry they are refused before the key is looked up.
+func (s *Server) requireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	if s.auth == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.admitUnauthenticated(w, r) {
+			return
+		}
+		key, err := s.auth.Authenticate(r.Context(), apiKeySecret(r))
+		if err != nil {
+			if errors.Is(err, domain.ErrUnauthenticated) {
+				s.chargeAuthFailure(r)
+			}
+			respondError(w, r, err)
+			return
+		}
+		if !key.HasScope(scope) {
+			respondError(w, r, fmt.Errorf("api key %s lacks scope %s: %w", key.ID, scope, domain.ErrForbidden))
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), apiKeyKey, key)))
+	}
+}
+
+func apiKeySecret(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); h != "" {
+		if token, ok := strings.CutPrefix(h, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	if h := r.Header.Get("X-API-Key"); h != "" {
+		return h
+	}
+	if headerContains(r.Header, "Upgrade", "websocket") {
+		return r.URL.Query().Get("api_key")
+	}
+	return ""
+}
+
+func apiKeyFrom(ctx context.Context) domain.APIKey {
+	key, _ := ctx.Value(apiKeyKey).(domain.APIKey)
+	return key
+}
+
+// audit records an admin action taken by the request's key.
+func (s *Server) audit(r *http.Request, action, target string, err error) {
+	if s.auth == nil {
+		return
+	}
+	key := apiKeyFrom(r.Context())
+	entry := domain.AuditEntry{
+		KeyID:     key.ID,
+		KeyName:   key.Name,
+		Action:    action,
+		Target:    target,
+		RequestID: requestID(r.Context()),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	s.auth.Record(context.WithoutCancel(r.Context()), entry)
+}
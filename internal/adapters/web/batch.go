@@ -0,0 +1,135 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"marketflow/internal/app/query"
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+	"marketflow/pkg/api"
+)
+
+// handleBatch serves POST /prices/batch: many latest prices and period
+// metrics in one request. Items that fail carry their own error and the
+// rest are answered; only a malformed or oversized request fails as a whole.
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, r, errMethodNotAllowed)
+		return
+	}
+	var req api.BatchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		respondError(w, r, invalidArgument(fmt.Errorf("invalid request body: %w", err)))
+		return
+	}
+	if len(req.Items) == 0 {
+		respondError(w, r, invalidArgument(errors.New("items must not be empty")))
+		return
+	}
+	if s.batchLimit > 0 && len(req.Items) > s.batchLimit {
+		respondError(w, r, invalidArgument(fmt.Errorf("%d items exceed the limit of %d per request", len(req.Items), s.batchLimit)))
+		return
+	}
+	results := make([]api.BatchResult, len(req.Items))
+	items := make([]query.Item, 0, len(req.Items))
+	index := make([]int, 0, len(req.Items)) // result index of each item
+	for i, in := range req.Items {
+		item, err := batchItem(in)
+		results[i] = api.BatchResult{Exchange: item.Exchange, Pair: item.Pair, Metric: in.Metric}
+		if err != nil {
+			results[i].Error = itemError(r, err)
+			continue
+		}
+		if item.Metric != query.MetricLatest {
+			results[i].Period = item.Period.String()
+		}
+		items = append(items, item)
+		index = append(index, i)
+	}
+	if !s.take(w, r, clientKey(r), s.batchCost(items)) {
+		return
+	}
+
+	for j, res := range s.query.Batch(r.Context(), items) {
+		out := &results[index[j]]
+		if res.Err != nil {
+			out.Error = itemError(r, res.Err)
+			continue
+		}
+		price := res.Price
+		out.Price = &price
+		if !res.Time.IsZero() {
+			t := res.Time
+			out.Time = &t
+		}
+		out.Source = res.Source
+	}
+	respondJSON(w, http.StatusOK, api.BatchResponse{Results: results})
+}
+
+// batchCost charges a batch for the round trips query.Batch makes: the
+// latest cost for all latest prices and the history cost for each distinct
+// period. It never exceeds a full bucket, so any batch within the item limit
+// can be admitted.
+func (s *Server) batchCost(items []query.Item) int {
+	if s.rateLimit == nil {
+		return 0
+	}
+	cost := 0
+	latest := false
+	periods := make(map[time.Duration]bool)
+	for _, item := range items {
+		switch {
+		case item.Metric == query.MetricLatest:
+			latest = true
+		case !periods[item.Period]:
+			periods[item.Period] = true
+			cost += s.rateLimit.HistoryCost
+		}
+	}
+	if latest {
+		cost += costLatest
+	}
+	if s.rateLimit.Burst > 0 {
+		cost = min(cost, s.rateLimit.Burst)
+	}
+	return cost
+}
+
+func batchItem(in api.BatchItem) (query.Item, error) {
+	item := query.Item{Exchange: in.Exchange, Pair: in.Pair, Metric: query.Metric(in.Metric)}
+	if item.Exchange == "" {
+		item.Exchange = "ex1" // default exchange, as on the GET routes
+	}
+	if item.Pair == "" {
+		return item, fmt.Errorf("pair must not be empty: %w", domain.ErrInvalidArgument)
+	}
+	switch item.Metric {
+	case query.MetricLatest:
+		return item, nil
+	case query.MetricHighest, query.MetricLowest, query.MetricAverage:
+	default:
+		return item, fmt.Errorf("invalid metric %q: %w", in.Metric, domain.ErrInvalidArgument)
+	}
+	period, err := parsePeriod(in.Period)
+	if err != nil {
+		return item, err
+	}
+	item.Period = period
+	return item, nil
+}
+
+// itemError is respondError for one item of a batch.
+func itemError(r *http.Request, err error) *api.ErrorDetail {
+	status, code := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		logger.Error("batch item failed", "request_id", requestID(r.Context()), "error", err)
+	} else if status >= http.StatusInternalServerError {
+		logger.Warn("batch item failed", "request_id", requestID(r.Context()), "error", err)
+	}
+	return &api.ErrorDetail{Code: code, Message: clientMessage(status, err)}
+}
@@ -0,0 +1,128 @@
+package web_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/memory"
+	"marketflow/internal/adapters/web"
+	"marketflow/internal/app/aggregator"
+	"marketflow/internal/app/hub"
+	"marketflow/internal/app/pipeline"
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+	"marketflow/pkg/api"
+)
+
+func TestMain(m *testing.M) {
+	logger.InitWithWriter(io.Discard, "test", "error")
+	os.Exit(m.Run())
+}
+
+// TestPipelineToHandlers feeds ticks through a worker and the aggregator
+// into the memory adapters and reads them back over HTTP.
+func TestPipelineToHandlers(t *testing.T) {
+	cache := memory.NewCache(time.Minute, 0)
+	repo := memory.NewRepository()
+	ticks := []domain.PriceUpdate{
+		{Exchange: "ex1", Pair: "BTCUSDT", Price: 100},
+		{Exchange: "ex1", Pair: "BTCUSDT", Price: 104},
+		{Exchange: "ex1", Pair: "BTCUSDT", Price: 98},
+		{Exchange: "ex1", Pair: "BTCUSDT", Price: 102},
+	}
+
+	// The worker's output is forwarded to the aggregator, whose input is
+	// closed once every tick has passed; closing makes it flush one window.
+	input := make(chan domain.PriceUpdate, len(ticks))
+	workerOut := make(chan domain.PriceUpdate)
+	aggIn := make(chan domain.PriceUpdate, len(ticks))
+	go func() {
+		for i := 0; i < len(ticks); i++ {
+			aggIn <- <-workerOut
+		}
+		close(aggIn)
+	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	(&pipeline.Worker{Input: input, Cache: cache, Output: workerOut}).Start(ctx)
+	agg := aggregator.NewAggregator(aggIn, repo, time.Hour)
+	aggDone := make(chan struct{})
+	go func() {
+		defer close(aggDone)
+		agg.Start(ctx)
+	}()
+
+	for _, tick := range ticks {
+		tick.Time = time.Now()
+		input <- tick
+	}
+	close(input)
+	select {
+	case <-aggDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("aggregator did not flush")
+	}
+
+	srv := httptest.NewServer(web.NewServer(repo, cache, nil, hub.New(), web.Options{}).Router(nil))
+	defer srv.Close()
+
+	var latest api.LatestPrice
+	get(t, srv.URL+"/prices/latest/ex1/BTCUSDT", http.StatusOK, &latest)
+	if latest.Price != 102 || latest.Source != "cache" {
+		t.Errorf("latest = %v from %q, want 102 from cache", latest.Price, latest.Source)
+	}
+
+	for _, tc := range []struct {
+		path string
+		want float64
+	}{
+		{"/prices/highest/BTCUSDT?period=1m", 104},
+		{"/prices/lowest/ex1/BTCUSDT?period=1m", 98},
+		{"/prices/average/ex1/BTCUSDT", 101},
+	} {
+		var price api.Price
+		get(t, srv.URL+tc.path, http.StatusOK, &price)
+		if price.Price != tc.want {
+			t.Errorf("GET %s = %v, want %v", tc.path, price.Price, tc.want)
+		}
+	}
+
+	var history api.History
+	get(t, srv.URL+"/prices/history/BTCUSDT?period=1m", http.StatusOK, &history)
+	if len(history.Windows) != 1 {
+		t.Fatalf("history has %d windows, want 1", len(history.Windows))
+	}
+	if w := history.Windows[0]; w.Average != 101 || w.Min != 98 || w.Max != 104 {
+		t.Errorf("window = %+v, want average 101, min 98, max 104", w)
+	}
+
+	var apiErr api.Error
+	get(t, srv.URL+"/prices/average/ex1/ETHUSDT", http.StatusNotFound, &apiErr)
+	if apiErr.Error.Code != "not_found" {
+		t.Errorf("unknown pair: code %q, want not_found", apiErr.Error.Code)
+	}
+	get(t, srv.URL+"/prices/average/ex1/BTCUSDT?period=-1m", http.StatusBadRequest, &apiErr)
+}
+
+// get fetches url, checks the status and decodes the JSON body into out.
+func get(t *testing.T, url string, status int, out any) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != status {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("GET %s: status %d, want %d: %s", url, resp.StatusCode, status, body)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+}
@@ -0,0 +1,82 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+	"marketflow/pkg/api"
+)
+
+var errMethodNotAllowed = errors.New("method not allowed")
+
+func invalidArgument(err error) error {
+	return fmt.Errorf("%w: %w", domain.ErrInvalidArgument, err)
+}
+
+// errorStatus maps domain errors to an HTTP status and a stable code.
+func errorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, errMethodNotAllowed):
+		return http.StatusMethodNotAllowed, "method_not_allowed"
+	case errors.Is(err, errRateLimited):
+		return http.StatusTooManyRequests, "rate_limited"
+	case errors.Is(err, domain.ErrInvalidArgument):
+		return http.StatusBadRequest, "invalid_argument"
+	case errors.Is(err, domain.ErrUnauthenticated):
+		return http.StatusUnauthorized, "unauthenticated"
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden, "forbidden"
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound, "not_found"
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict, "conflict"
+	case domain.IsTimeout(err):
+		return http.StatusGatewayTimeout, "timeout"
+	case errors.Is(err, domain.ErrUnavailable):
+		return http.StatusServiceUnavailable, "unavailable"
+	default:
+		return http.StatusInternalServerError, "internal"
+	}
+}
+
+// clientMessage is what the client is told about err. Server-side failures
+// are reported generically, since storage and cache errors carry driver
+// text such as internal addresses; their details only go to the log.
+func clientMessage(status int, err error) string {
+	switch status {
+	case http.StatusInternalServerError:
+		return "internal error"
+	case http.StatusServiceUnavailable:
+		return "service unavailable"
+	case http.StatusGatewayTimeout:
+		return "timed out"
+	}
+	return err.Error()
+}
+
+// respondError writes err as a JSON error envelope. Server-side errors are
+// logged with their details and the request ID, and reported to the client
+// generically.
+func respondError(w http.ResponseWriter, r *http.Request, err error) {
+	status, code := errorStatus(err)
+	id := requestID(r.Context())
+
+	if status == http.StatusInternalServerError {
+		logger.Error("request failed", "request_id", id, "method", r.Method, "path", r.URL.Path, "error", err)
+	} else if status >= http.StatusInternalServerError {
+		logger.Warn("request failed", "request_id", id, "method", r.Method, "path", r.URL.Path, "error", err)
+	}
+	message := clientMessage(status, err)
+
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="marketflow"`)
+	}
+	respondJSON(w, status, api.Error{Error: api.ErrorDetail{
+		Code:      code,
+		Message:   message,
+		RequestID: id,
+	}})
+}
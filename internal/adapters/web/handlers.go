@@ -1,195 +1,271 @@
 package web
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
-	"marketflow/internal/adapters/redis"
+	"marketflow/internal/app/alerts"
+	"marketflow/internal/app/analytics"
+	"marketflow/internal/app/auth"
+	"marketflow/internal/app/hub"
+	"marketflow/internal/app/indicators"
 	"marketflow/internal/app/mode"
+	"marketflow/internal/app/query"
+	"marketflow/internal/app/statscache"
 	"marketflow/internal/domain"
 	"marketflow/internal/logger"
+	"marketflow/pkg/api"
 )
 
 type Server struct {
-	repo    domain.PriceRepository
-	cache   *redis.RedisCache
-	manager *mode.Manager
+	repo          domain.PriceRepository
+	cache         domain.Cache
+	manager       *mode.Manager // nil on api-only instances
+	query         *query.Service
+	hub           *hub.Hub
+	auth          *auth.Service
+	rateLimit     *RateLimit
+	batchLimit    int
+	wsOrigins     []string
+	spreads       *analytics.SpreadMonitor
+	spreadHistory domain.SpreadStore
+	alerts        *alerts.Service
+	indicators    *indicators.Service
 }
 
-func NewServer(repo domain.PriceRepository, cache *redis.RedisCache, manager *mode.Manager) *Server {
-	return &Server{
-		repo:    repo,
-		cache:   cache,
-		manager: manager,
-	}
+// Options are the optional parts of a Server; each nil field is disabled.
+type Options struct {
+	Auth      *auth.Service
+	RateLimit *RateLimit
+	// Results caches and coalesces period queries.
+	Results *statscache.Cache
+	// BatchLimit caps the items of one batch request; 0 means no cap.
+	BatchLimit int
+	// WSOrigins are the origins besides the server's own whose pages may
+	// open /ws/prices; "*" allows any.
+	WSOrigins []string
+	// Spreads serves /analytics/spread, with history from SpreadHistory.
+	Spreads       *analytics.SpreadMonitor
+	SpreadHistory domain.SpreadStore
+	// Alerts serves /alerts.
+	Alerts *alerts.Service
+	// Indicators serves /indicators.
+	Indicators *indicators.Service
 }
 
-func (s *Server) handleLowestPrice(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
+func NewServer(repo domain.PriceRepository, cache domain.Cache, manager *mode.Manager, ticks *hub.Hub, opts Options) *Server {
+	return &Server{
+		repo:          repo,
+		cache:         cache,
+		manager:       manager,
+		query:         query.New(repo, cache, opts.Results),
+		hub:           ticks,
+		auth:          opts.Auth,
+		rateLimit:     opts.RateLimit,
+		batchLimit:    opts.BatchLimit,
+		wsOrigins:     opts.WSOrigins,
+		spreads:       opts.Spreads,
+		spreadHistory: opts.SpreadHistory,
+		alerts:        opts.Alerts,
+		indicators:    opts.Indicators,
 	}
+}
 
-	ctx := r.Context()
+// pricePath parses /prices/{kind}/{symbol} and /prices/{kind}/{exchange}/{symbol}.
+func pricePath(r *http.Request) (exchange, symbol string, err error) {
 	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
-
-	var exchange, symbol string
-	if len(parts) == 3 {
-		// /prices/lowest/{symbol}
-		symbol = parts[2]
-		exchange = "ex1" // default
-	} else if len(parts) == 4 {
-		// /prices/lowest/{exchange}/{symbol}
-		exchange = parts[2]
-		symbol = parts[3]
-	} else {
-		http.Error(w, "invalid URL", http.StatusBadRequest)
-		return
+	switch len(parts) {
+	case 3:
+		return "ex1", parts[2], nil // default exchange
+	case 4:
+		return parts[2], parts[3], nil
+	default:
+		return "", "", fmt.Errorf("invalid URL %q: %w", r.URL.Path, domain.ErrInvalidArgument)
 	}
+}
+
+func periodParam(r *http.Request) (time.Duration, error) {
+	return parsePeriod(r.URL.Query().Get("period"))
+}
 
-	periodStr := r.URL.Query().Get("period")
+// parsePeriod reads a positive duration, defaulting to one minute.
+func parsePeriod(periodStr string) (time.Duration, error) {
 	if periodStr == "" {
-		periodStr = "1m"
+		return time.Minute, nil
 	}
-
 	period, err := time.ParseDuration(periodStr)
-	if err != nil {
-		http.Error(w, "invalid period format", http.StatusBadRequest)
-		return
+	if err != nil || period <= 0 {
+		return 0, fmt.Errorf("invalid period %q: %w", periodStr, domain.ErrInvalidArgument)
 	}
+	return period, nil
+}
 
-	stats, err := s.repo.GetByPeriod(ctx, exchange, symbol, period)
+// periodStats resolves the exchange, symbol and period of a request and
+// loads the matching stats. An empty result is reported as not found.
+func (s *Server) periodStats(r *http.Request) (string, string, []domain.PriceStats, error) {
+	if r.Method != http.MethodGet {
+		return "", "", nil, errMethodNotAllowed
+	}
+	exchange, symbol, err := pricePath(r)
 	if err != nil {
-		http.Error(w, "failed to get stats", http.StatusInternalServerError)
-		return
+		return "", "", nil, err
 	}
-
-	var minPrice float64
-	var minTime time.Time
-	for i, stat := range stats {
-		if i == 0 || stat.Min < minPrice {
-			minPrice = stat.Min
-			minTime = stat.Timestamp
-		}
+	period, err := periodParam(r)
+	if err != nil {
+		return "", "", nil, err
+	}
+	stats, err := s.query.Period(r.Context(), exchange, symbol, period)
+	if err != nil {
+		return "", "", nil, err
 	}
+	return exchange, symbol, stats, nil
+}
 
-	if len(stats) == 0 {
-		http.Error(w, "no data for period", http.StatusNotFound)
+func (s *Server) handleLowestPrice(w http.ResponseWriter, r *http.Request) {
+	exchange, symbol, stats, err := s.periodStats(r)
+	if err != nil {
+		respondError(w, r, err)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"exchange": exchange,
-		"pair":     symbol,
-		"price":    minPrice,
-		"time":     minTime,
+	minPrice, minTime := query.Lowest(stats)
+	respondCacheable(w, r, api.Price{
+		Exchange: exchange,
+		Pair:     symbol,
+		Price:    minPrice,
+		Time:     &minTime,
 	})
 }
 
 func (s *Server) handleAveragePrice(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	exchange, symbol, stats, err := s.periodStats(r)
+	if err != nil {
+		respondError(w, r, err)
 		return
 	}
 
-	ctx := r.Context()
-	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	respondCacheable(w, r, api.Price{
+		Exchange: exchange,
+		Pair:     symbol,
+		Price:    query.Average(stats),
+	})
+}
 
-	var exchange, symbol string
-	if len(parts) == 3 {
-		// /prices/average/{symbol}
-		symbol = parts[2]
-		exchange = "ex1" // default
-	} else if len(parts) == 4 {
-		// /prices/average/{exchange}/{symbol}
-		exchange = parts[2]
-		symbol = parts[3]
-	} else {
-		http.Error(w, "invalid URL", http.StatusBadRequest)
+func (s *Server) handleHighestPrice(w http.ResponseWriter, r *http.Request) {
+	exchange, symbol, stats, err := s.periodStats(r)
+	if err != nil {
+		respondError(w, r, err)
 		return
 	}
 
-	periodStr := r.URL.Query().Get("period")
-	if periodStr == "" {
-		periodStr = "1m" // default to 1 minute
-	}
+	maxPrice, maxTime := query.Highest(stats)
+	respondCacheable(w, r, api.Price{
+		Exchange: exchange,
+		Pair:     symbol,
+		Price:    maxPrice,
+		Time:     &maxTime,
+	})
+}
 
-	period, err := time.ParseDuration(periodStr)
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	exchange, symbol, stats, err := s.periodStats(r)
 	if err != nil {
-		http.Error(w, "invalid period format", http.StatusBadRequest)
+		respondError(w, r, err)
 		return
 	}
+	period, _ := periodParam(r)
 
-	stats, err := s.repo.GetByPeriod(ctx, exchange, symbol, period)
+	windows := make([]api.Window, len(stats))
+	for i, stat := range stats {
+		windows[i] = api.Window{Time: stat.Timestamp, Average: stat.Average, Min: stat.Min, Max: stat.Max}
+	}
+	respondCacheable(w, r, api.History{
+		Exchange: exchange,
+		Pair:     symbol,
+		Period:   period.String(),
+		Windows:  windows,
+	})
+}
+
+// handleStats serves /prices/stats/[{exchange}/]{symbol}?period=.
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, r, errMethodNotAllowed)
+		return
+	}
+	exchange, symbol, err := pricePath(r)
 	if err != nil {
-		http.Error(w, "failed to get stats", http.StatusInternalServerError)
+		respondError(w, r, err)
 		return
 	}
-
-	var sum float64
-	for _, stat := range stats {
-		sum += stat.Average
+	period, err := periodParam(r)
+	if err != nil {
+		respondError(w, r, err)
+		return
 	}
-	if len(stats) == 0 {
-		http.Error(w, "no data for period", http.StatusNotFound)
+	sum, err := s.query.Summary(r.Context(), exchange, symbol, period)
+	if err != nil {
+		respondError(w, r, err)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"exchange": exchange,
-		"pair":     symbol,
-		"price":    sum / float64(len(stats)),
+	respondCacheable(w, r, api.Stats{
+		Exchange:             exchange,
+		Pair:                 symbol,
+		Period:               period.String(),
+		Source:               sum.Source,
+		Resolution:           sum.Resolution.Round(time.Millisecond).String(),
+		Samples:              sum.Samples,
+		From:                 sum.From,
+		To:                   sum.To,
+		First:                sum.First,
+		Last:                 sum.Last,
+		Mean:                 sum.Mean,
+		StdDev:               sum.StdDev,
+		P5:                   sum.P5,
+		P50:                  sum.P50,
+		P95:                  sum.P95,
+		LogReturn:            sum.LogReturn,
+		MeanLogReturn:        sum.MeanLogReturn,
+		LogReturnStdDev:      sum.LogReturnStdDev,
+		RealizedVolatility:   sum.RealizedVolatility,
+		AnnualizedVolatility: sum.AnnualizedVolatility,
+		MaxDrawdown:          sum.MaxDrawdown,
 	})
 }
 
 func (s *Server) handleLatestPrice() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			respondError(w, r, errMethodNotAllowed)
 			return
 		}
 
-		ctx := r.Context()
-		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
-
-		var exchange, symbol string
-		if len(parts) == 3 {
-			// /prices/latest/{symbol}
-			symbol = parts[2]
-			exchange = "ex1" // default
-		} else if len(parts) == 4 {
-			// /prices/latest/{exchange}/{symbol}
-			exchange = parts[2]
-			symbol = parts[3]
-		} else {
-			http.Error(w, "invalid URL", http.StatusBadRequest)
+		exchange, symbol, err := pricePath(r)
+		if err != nil {
+			respondError(w, r, err)
 			return
 		}
 
-		update, err := s.cache.GetLatest(ctx, exchange, symbol)
+		update, source, err := s.query.Latest(r.Context(), exchange, symbol)
 		if err != nil {
-			logger.Warn("cache miss, falling back to postgres", "symbol", symbol, "exchange", exchange)
-			stats, err := s.repo.GetLatest(ctx, exchange, symbol)
-			if err != nil {
-				logger.Error("failed to get latest price", "symbol", symbol, "exchange", exchange, "error", err)
-				http.Error(w, "failed to get latest price", http.StatusInternalServerError)
-				return
-			}
-			update = domain.PriceUpdate{
-				Exchange: stats.Exchange,
-				Pair:     stats.Pair,
-				Price:    stats.Average,
-				Time:     stats.Timestamp,
-			}
+			respondError(w, r, err)
+			return
 		}
 
-		respondJSON(w, http.StatusOK, map[string]interface{}{
-			"exchange": update.Exchange,
-			"pair":     update.Pair,
-			"price":    update.Price,
-			"time":     update.Time,
+		respondJSON(w, http.StatusOK, api.LatestPrice{
+			Exchange: update.Exchange,
+			Pair:     update.Pair,
+			Price:    update.Price,
+			Time:     update.Time,
+			Source:   source,
+			Degraded: s.query.CacheHealth() != nil,
 		})
 	}
 }
@@ -197,125 +273,75 @@ func (s *Server) handleLatestPrice() http.HandlerFunc {
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	_, errRedis := s.cache.GetLatest(ctx, "ex1", "BTCUSDT")
-	_, errPg := s.repo.GetLatest(ctx, "ex1", "BTCUSDT")
-
-	status := map[string]string{
-		"redis":    "ok",
-		"postgres": "ok",
-	}
-	if errRedis != nil {
-		status["redis"] = "unavailable"
-	}
-	if errPg != nil {
-		status["postgres"] = "unavailable"
-	}
-
-	respondJSON(w, http.StatusOK, status)
-}
-
-func (s *Server) handleHighestPrice(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	ctx := r.Context()
-	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
-
-	var exchange, symbol string
-	if len(parts) == 3 {
-		// /prices/highest/{symbol}
-		symbol = parts[2]
-		exchange = "ex1" // default
-	} else if len(parts) == 4 {
-		// /prices/highest/{exchange}/{symbol}
-		exchange = parts[2]
-		symbol = parts[3]
-	} else {
-		http.Error(w, "invalid URL", http.StatusBadRequest)
-		return
-	}
-
-	periodStr := r.URL.Query().Get("period")
-	if periodStr == "" {
-		periodStr = "1m" // default to 1 minute
-	}
-
-	period, err := time.ParseDuration(periodStr)
-	if err != nil {
-		logger.Error("invalid period", "period", periodStr, "error", err)
-		http.Error(w, "invalid period format", http.StatusBadRequest)
-		return
-	}
-
-	stats, err := s.repo.GetByPeriod(ctx, exchange, symbol, period)
-	if err != nil {
-		logger.Error("failed to get stats by period", "symbol", symbol, "exchange", exchange, "period", period, "error", err)
-		http.Error(w, "failed to get stats", http.StatusInternalServerError)
-		return
+	status := api.Health{
+		Redis:    "ok",
+		Postgres: "ok",
 	}
-
-	var maxPrice float64
-	var maxTime time.Time
-	for _, stat := range stats {
-		if stat.Max > maxPrice {
-			maxPrice = stat.Max
-			maxTime = stat.Timestamp
+	if h, ok := s.cache.(interface{ Health() error }); ok {
+		// Latest prices are still served from memory; only sharing is down.
+		if err := h.Health(); err != nil {
+			status.Redis = "degraded"
+			logger.Warn("health check: redis degraded", "request_id", requestID(ctx), "error", err)
 		}
+	} else if _, err := s.cache.GetLatest(ctx, "ex1", "BTCUSDT"); err != nil && !errors.Is(err, domain.ErrNotFound) {
+		// A missing key still proves the store answered.
+		status.Redis = "unavailable"
 	}
-
-	if maxPrice == 0 {
-		http.Error(w, "no data for period", http.StatusNotFound)
-		return
+	if _, err := s.repo.GetLatest(ctx, "ex1", "BTCUSDT"); err != nil && !errors.Is(err, domain.ErrNotFound) {
+		status.Postgres = "unavailable"
 	}
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"exchange": exchange,
-		"pair":     symbol,
-		"price":    maxPrice,
-		"time":     maxTime,
-	})
+	respondJSON(w, http.StatusOK, status)
 }
 
-func (s *Server) handleSetTestMode(input chan<- domain.PriceUpdate) http.HandlerFunc {
+func (s *Server) handleSetMode(input chan<- domain.PriceUpdate, m mode.Mode) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			respondError(w, r, errMethodNotAllowed)
 			return
 		}
 
-		if err := s.manager.Start(input, mode.Test); err != nil {
-			if err.Error() == "mode already set" {
-				http.Error(w, "mode already set", http.StatusConflict)
-				return
-			}
-			logger.Error("failed to set test mode", "error", err)
-			http.Error(w, "failed to set test mode", http.StatusInternalServerError)
+		err := s.manager.Start(input, m)
+		s.audit(r, "mode.set", string(m), err)
+		if err != nil {
+			logger.Error("failed to set mode", "mode", m, "error", err)
+			respondError(w, r, err)
 			return
 		}
-		respondJSON(w, http.StatusOK, map[string]string{"mode": "test"})
+		respondJSON(w, http.StatusOK, api.Mode{Mode: string(m)})
 	}
 }
 
-func (s *Server) handleSetLiveMode(input chan<- domain.PriceUpdate) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
+// respondCacheable writes data with a strong ETag of its encoding, and
+// answers 304 Not Modified when the client already has that version.
+func respondCacheable(w http.ResponseWriter, r *http.Request, data interface{}) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		respondError(w, r, fmt.Errorf("failed to encode response: %w", err))
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
 
-		if err := s.manager.Start(input, mode.Live); err != nil {
-			if err.Error() == "mode already set" {
-				http.Error(w, "mode already set", http.StatusConflict)
-				return
-			}
-			logger.Error("failed to set live mode", "error", err)
-			http.Error(w, "failed to set live mode", http.StatusInternalServerError)
-			return
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(append(body, '\n'))
+}
+
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
 		}
-		respondJSON(w, http.StatusOK, map[string]string{"mode": "live"})
 	}
+	return false
 }
 
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
@@ -0,0 +1,81 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketflow/internal/app/indicators"
+	"marketflow/internal/domain"
+	"marketflow/pkg/api"
+)
+
+const defaultIndicatorWindow = 20
+
+// handleIndicator serves GET /indicators/[{exchange}/]{symbol}?type=&window=&resolution=.
+func (s *Server) handleIndicator(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, r, errMethodNotAllowed)
+		return
+	}
+	spec, err := indicatorSpec(r)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+
+	v, err := s.indicators.Get(r.Context(), spec)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+	resp := api.Indicator{
+		Exchange:   spec.Exchange,
+		Pair:       spec.Pair,
+		Type:       spec.Type,
+		Window:     spec.Window,
+		Resolution: spec.Resolution.String(),
+		Time:       v.Time,
+		Value:      v.Value,
+		Buckets:    v.Buckets,
+		Missing:    v.Missing,
+		Source:     v.Source,
+	}
+	if spec.Type == indicators.Bollinger {
+		resp.Upper, resp.Lower = &v.Upper, &v.Lower
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func indicatorSpec(r *http.Request) (indicators.Spec, error) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/indicators"), "/"), "/")
+	spec := indicators.Spec{Exchange: "ex1", Window: defaultIndicatorWindow, Resolution: time.Minute}
+	switch {
+	case len(parts) == 1 && parts[0] != "":
+		spec.Pair = parts[0]
+	case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+		spec.Exchange, spec.Pair = parts[0], parts[1]
+	default:
+		return spec, fmt.Errorf("invalid URL %q: %w", r.URL.Path, domain.ErrInvalidArgument)
+	}
+
+	q := r.URL.Query()
+	spec.Type = strings.ToLower(q.Get("type"))
+	if v := q.Get("window"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return spec, invalidArgument(fmt.Errorf("invalid window %q", v))
+		}
+		spec.Window = n
+	}
+	if v := q.Get("resolution"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return spec, invalidArgument(fmt.Errorf("invalid resolution %q", v))
+		}
+		spec.Resolution = d
+	}
+	return spec, spec.Validate()
+}
@@ -0,0 +1,21 @@
+package web
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPISpec describes every route in Router and the bodies in pkg/api.
+// Update it together with them; openapi_test.go fails when they drift apart.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+func handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, r, errMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
@@ -0,0 +1,160 @@
+package web
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"marketflow/internal/app/alerts"
+	"marketflow/internal/app/analytics"
+	"marketflow/internal/app/auth"
+	"marketflow/internal/app/indicators"
+	"marketflow/internal/app/mode"
+)
+
+type openAPIDoc struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Type       string                     `json:"type"`
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+func loadOpenAPI(t *testing.T) openAPIDoc {
+	t.Helper()
+	var doc openAPIDoc
+	if err := json.Unmarshal(openAPISpec, &doc); err != nil {
+		t.Fatalf("openapi.json: %v", err)
+	}
+	return doc
+}
+
+// TestOpenAPIPathsMatchRouter resolves every documented path against the
+// routes of a server with every optional part enabled, and fails on a path
+// no route serves or a route no path documents.
+func TestOpenAPIPathsMatchRouter(t *testing.T) {
+	s := &Server{
+		manager:    &mode.Manager{},
+		auth:       &auth.Service{},
+		spreads:    &analytics.SpreadMonitor{},
+		alerts:     &alerts.Service{},
+		indicators: &indicators.Service{},
+	}
+	mux := http.NewServeMux()
+	unused := make(map[string]bool)
+	for _, rt := range s.routes(nil) {
+		mux.HandleFunc(rt.pattern, rt.handler)
+		unused[rt.pattern] = true
+	}
+
+	param := regexp.MustCompile(`\{[^}]+\}`)
+	for path := range loadOpenAPI(t).Paths {
+		req := httptest.NewRequest(http.MethodGet, param.ReplaceAllString(path, "x"), nil)
+		if _, pattern := mux.Handler(req); pattern == "" {
+			t.Errorf("openapi.json documents %s, which Router does not serve", path)
+		} else {
+			delete(unused, pattern)
+		}
+	}
+	for pattern := range unused {
+		t.Errorf("Router serves %s, which openapi.json does not document", pattern)
+	}
+}
+
+// TestOpenAPISchemasMatchTypes compares the JSON fields of every struct in
+// pkg/api with the properties of the schema of the same name.
+func TestOpenAPISchemasMatchTypes(t *testing.T) {
+	schemas := loadOpenAPI(t).Components.Schemas
+	types := apiStructFields(t, "../../../pkg/api")
+
+	for name, fields := range types {
+		schema, ok := schemas[name]
+		if !ok {
+			t.Errorf("api.%s has no schema in openapi.json", name)
+			continue
+		}
+		var props []string
+		for prop := range schema.Properties {
+			props = append(props, prop)
+		}
+		sort.Strings(props)
+		if !reflect.DeepEqual(fields, props) {
+			t.Errorf("schema %s has properties %v, api.%s has JSON fields %v", name, props, name, fields)
+		}
+	}
+	for name, schema := range schemas {
+		if _, ok := types[name]; !ok && schema.Type == "object" {
+			t.Errorf("openapi.json has schema %s with no struct in pkg/api", name)
+		}
+	}
+}
+
+// apiStructFields parses the package in dir and returns the sorted JSON
+// field names of each struct type.
+func apiStructFields(t *testing.T, dir string) map[string][]string {
+	t.Helper()
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := make(map[string][]string)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			ast.Inspect(file, func(n ast.Node) bool {
+				spec, ok := n.(*ast.TypeSpec)
+				if !ok {
+					return true
+				}
+				st, ok := spec.Type.(*ast.StructType)
+				if !ok {
+					return false
+				}
+				var fields []string
+				for _, f := range st.Fields.List {
+					for _, ident := range f.Names {
+						if name := jsonName(f, ident.Name); name != "" {
+							fields = append(fields, name)
+						}
+					}
+				}
+				sort.Strings(fields)
+				types[spec.Name.Name] = fields
+				return false
+			})
+		}
+	}
+	return types
+}
+
+// jsonName is the key encoding/json uses for a field, or "" if it skips it.
+func jsonName(f *ast.Field, goName string) string {
+	if !ast.IsExported(goName) {
+		return ""
+	}
+	if f.Tag == nil {
+		return goName
+	}
+	tag, err := strconv.Unquote(f.Tag.Value)
+	if err != nil {
+		return goName
+	}
+	name, _, _ := strings.Cut(reflect.StructTag(tag).Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return goName
+	}
+	return name
+}
@@ -0,0 +1,143 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+var errRateLimited = errors.New("rate limit exceeded")
+
+// RateLimit meters requests per API key, or per IP address without one. A
+// latest price costs one token; queries that read stored history cost
+// HistoryCost, as does opening a stream. Burst is the bucket size; a batch
+// is never charged more.
+type RateLimit struct {
+	Limiter     domain.RateLimiter
+	HistoryCost int
+	Burst       int
+}
+
+const (
+	costLatest = 1
+
+	// costAuthFailure is charged to the peer's IP address for a request
+	// with a missing or unknown API key.
+	costAuthFailure = 1
+)
+
+// limit takes cost tokens from the client's bucket and answers 429 when it
+// runs dry.
+func (s *Server) limit(cost int, next http.HandlerFunc) http.HandlerFunc {
+	if s.rateLimit == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.take(w, r, clientKey(r), cost) {
+			next(w, r)
+		}
+	}
+}
+
+// take charges cost tokens to the bucket of key and reports whether the
+// request may go on; if not, the response has been written. A cost larger
+// than the whole bucket could never be met and is refused with 400. A
+// limiter that fails lets the request through, so an outage of the shared
+// store does not take the API down with it.
+func (s *Server) take(w http.ResponseWriter, r *http.Request, key string, cost int) bool {
+	if s.rateLimit == nil {
+		return true
+	}
+	res, err := s.rateLimit.Limiter.Take(r.Context(), key, cost)
+	if err != nil {
+		logger.Warn("rate limiter failed, allowing request", "request_id", requestID(r.Context()), "error", err)
+		return true
+	}
+
+	setRateLimitHeaders(w, res)
+	switch {
+	case res.Allowed:
+		return true
+	case cost > res.Limit:
+		respondError(w, r, invalidArgument(fmt.Errorf("request costs %d tokens, more than the rate limit allows at once (%d)", cost, res.Limit)))
+	default:
+		w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+		respondError(w, r, fmt.Errorf("%w: request costs %d, %d left", errRateLimited, cost, res.Remaining))
+	}
+	return false
+}
+
+// admitUnauthenticated is asked before an API key is looked up. It answers
+// 429 when the peer's IP address has spent its bucket on failed attempts,
+// so a flood of missing or made-up keys never reaches the key store. It
+// only looks at the bucket; chargeAuthFailure spends it.
+func (s *Server) admitUnauthenticated(w http.ResponseWriter, r *http.Request) bool {
+	if s.rateLimit == nil {
+		return true
+	}
+	res, err := s.rateLimit.Limiter.Take(r.Context(), peerKey(r), 0)
+	if err != nil {
+		logger.Warn("rate limiter failed, allowing request", "request_id", requestID(r.Context()), "error", err)
+		return true
+	}
+	if res.Remaining >= costAuthFailure {
+		return true
+	}
+	setRateLimitHeaders(w, res)
+	// The rate is at least one token per second.
+	w.Header().Set("Retry-After", "1")
+	respondError(w, r, fmt.Errorf("%w: too many requests without a valid api key", errRateLimited))
+	return false
+}
+
+func (s *Server) chargeAuthFailure(r *http.Request) {
+	if s.rateLimit == nil {
+		return
+	}
+	if _, err := s.rateLimit.Limiter.Take(r.Context(), peerKey(r), costAuthFailure); err != nil {
+		logger.Warn("rate limiter failed", "request_id", requestID(r.Context()), "error", err)
+	}
+}
+
+func setRateLimitHeaders(w http.ResponseWriter, res domain.RateLimitResult) {
+	h := w.Header()
+	h.Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+}
+
+func (s *Server) historyCost() int {
+	if s.rateLimit == nil {
+		return 0
+	}
+	return s.rateLimit.HistoryCost
+}
+
+// clientKey identifies who is metered: the API key when authentication is
+// on, otherwise the peer address.
+func clientKey(r *http.Request) string {
+	if key := apiKeyFrom(r.Context()); key.ID != "" {
+		return "key:" + key.ID
+	}
+	return peerKey(r)
+}
+
+// peerKey is the bucket of the peer address. Proxy headers are not trusted.
+func peerKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,131 @@
+package web_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/memory"
+	"marketflow/internal/adapters/web"
+	"marketflow/internal/app/auth"
+	"marketflow/internal/app/hub"
+	"marketflow/internal/domain"
+)
+
+// countingKeys knows one key, with the secret "good", and counts lookups.
+type countingKeys struct {
+	domain.KeyStore
+	lookups int
+}
+
+func (k *countingKeys) FindKey(ctx context.Context, hash string) (domain.APIKey, error) {
+	k.lookups++
+	if hash == auth.Hash("good") {
+		return domain.APIKey{ID: "k1", Scopes: []string{auth.ReadPrices}}, nil
+	}
+	return domain.APIKey{}, domain.ErrNotFound
+}
+
+func rateLimitedServer(t *testing.T, keys domain.KeyStore, burst, historyCost int) *httptest.Server {
+	t.Helper()
+	repo := memory.NewRepository()
+	repo.StoreStats(context.Background(), domain.PriceStats{
+		Exchange: "ex1", Pair: "BTCUSDT", Timestamp: time.Now(), Average: 100, Min: 100, Max: 100,
+	})
+	opts := web.Options{
+		RateLimit: &web.RateLimit{Limiter: memory.NewRateLimiter(1, burst), HistoryCost: historyCost, Burst: burst},
+	}
+	if keys != nil {
+		opts.Auth = auth.NewService(keys, nil)
+	}
+	srv := httptest.NewServer(web.NewServer(repo, memory.NewCache(time.Minute, 0), nil, hub.New(), opts).Router(nil))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFailedAuthIsLimitedBeforeKeyLookup(t *testing.T) {
+	keys := &countingKeys{}
+	srv := rateLimitedServer(t, keys, 3, 1)
+
+	status := func(secret string) int {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/prices/latest/ex1/BTCUSDT", nil)
+		if secret != "" {
+			req.Header.Set("X-API-Key", secret)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if got := status("good"); got != http.StatusOK {
+		t.Fatalf("valid key: status %d, want 200", got)
+	}
+	for i := 0; i < 3; i++ {
+		if got := status("bad"); got != http.StatusUnauthorized {
+			t.Fatalf("bad key #%d: status %d, want 401", i+1, got)
+		}
+	}
+	before := keys.lookups
+	for _, secret := range []string{"bad", "", "other"} {
+		if got := status(secret); got != http.StatusTooManyRequests {
+			t.Errorf("key %q after the IP ran dry: status %d, want 429", secret, got)
+		}
+	}
+	if keys.lookups != before {
+		t.Errorf("%d key lookups after the IP ran dry, want none", keys.lookups-before)
+	}
+}
+
+func TestBatchIsChargedPerRoundTrip(t *testing.T) {
+	srv := rateLimitedServer(t, nil, 10, 2)
+
+	// post sends one latest price and averages over the given periods.
+	post := func(periods ...string) *http.Response {
+		t.Helper()
+		items := []string{`{"pair": "BTCUSDT", "metric": "latest"}`}
+		for _, p := range periods {
+			items = append(items, fmt.Sprintf(`{"pair": "BTCUSDT", "metric": "average", "period": %q}`, p))
+		}
+		body := `{"items": [` + strings.Join(items, ", ") + `]}`
+		resp, err := http.Post(srv.URL+"/prices/batch", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	remaining := func(resp *http.Response) string { return resp.Header.Get("X-RateLimit-Remaining") }
+
+	// 150 items over one period are one cache read and one period query.
+	periods := make([]string, 149)
+	for i := range periods {
+		periods[i] = "1m"
+	}
+	if resp := post(periods...); resp.StatusCode != http.StatusOK || remaining(resp) != "7" {
+		t.Fatalf("150 items over one period: status %d, %s left; want 200 and 7 of 10", resp.StatusCode, remaining(resp))
+	}
+	if resp := post("1m", "5m", "1h"); resp.StatusCode != http.StatusOK || remaining(resp) != "0" {
+		t.Errorf("three periods: status %d, %s left; want 200 and 0", resp.StatusCode, remaining(resp))
+	}
+	if resp := post(); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("latest prices with an empty bucket: status %d, want 429", resp.StatusCode)
+	}
+
+	// More distinct periods than the bucket pays for cost the whole bucket.
+	srv = rateLimitedServer(t, nil, 10, 2)
+	many := make([]string, 20)
+	for i := range many {
+		many[i] = fmt.Sprintf("%dm", i+1)
+	}
+	if resp := post(many...); resp.StatusCode != http.StatusOK || remaining(resp) != "0" {
+		t.Errorf("20 periods: status %d, %s left; want 200 and a drained bucket", resp.StatusCode, remaining(resp))
+	}
+}
@@ -3,19 +3,61 @@ package web
 import (
 	"net/http"
 
+	"marketflow/internal/app/auth"
+	"marketflow/internal/app/mode"
 	"marketflow/internal/domain"
 )
 
 func (s *Server) Router(input chan<- domain.PriceUpdate) http.Handler {
 	mux := http.NewServeMux()
+	for _, rt := range s.routes(input) {
+		mux.HandleFunc(rt.pattern, rt.handler)
+	}
+	return withRequestID(mux)
+}
+
+type route struct {
+	pattern string
+	handler http.HandlerFunc
+}
 
-	mux.HandleFunc("/prices/latest/", s.handleLatestPrice())
-	mux.HandleFunc("/prices/highest/", s.handleHighestPrice)
-	mux.HandleFunc("/prices/lowest/", s.handleLowestPrice)
-	mux.HandleFunc("/prices/average/", s.handleAveragePrice)
-	mux.HandleFunc("/mode/test", s.handleSetTestMode(input))
-	mux.HandleFunc("/mode/live", s.handleSetLiveMode(input))
-	mux.HandleFunc("/health", s.handleHealth)
+// routes lists what Router serves; openapi.json documents each of them.
+func (s *Server) routes(input chan<- domain.PriceUpdate) []route {
+	var routes []route
+	handle := func(pattern string, handler http.HandlerFunc) {
+		routes = append(routes, route{pattern, handler})
+	}
 
-	return mux
+	history := s.historyCost()
+	handle("/prices/latest/", s.requireScope(auth.ReadPrices, s.limit(costLatest, s.handleLatestPrice())))
+	handle("/prices/highest/", s.requireScope(auth.ReadPrices, s.limit(history, s.handleHighestPrice)))
+	handle("/prices/lowest/", s.requireScope(auth.ReadPrices, s.limit(history, s.handleLowestPrice)))
+	handle("/prices/average/", s.requireScope(auth.ReadPrices, s.limit(history, s.handleAveragePrice)))
+	handle("/prices/history/", s.requireScope(auth.ReadPrices, s.limit(history, s.handleHistory)))
+	handle("/prices/stats/", s.requireScope(auth.ReadPrices, s.limit(history, s.handleStats)))
+	handle("/prices/batch", s.requireScope(auth.ReadPrices, s.handleBatch))
+	handle("/ws/prices", s.requireScope(auth.ReadPrices, s.limit(history, s.handleStream)))
+	if s.spreads != nil {
+		handle("/analytics/spread/", s.requireScope(auth.ReadPrices, s.limit(history, s.handleSpread)))
+	}
+	if s.indicators != nil {
+		handle("/indicators/", s.requireScope(auth.ReadPrices, s.limit(history, s.handleIndicator)))
+	}
+	if s.alerts != nil {
+		handle("/alerts", s.requireScope(auth.ManageAlerts, s.limit(costLatest, s.handleAlerts)))
+		handle("/alerts/", s.requireScope(auth.ManageAlerts, s.limit(costLatest, s.handleAlerts)))
+	}
+	// api-only instances have no exchange clients to switch
+	if s.manager != nil {
+		handle("/mode/test", s.requireScope(auth.AdminMode, s.handleSetMode(input, mode.Test)))
+		handle("/mode/live", s.requireScope(auth.AdminMode, s.handleSetMode(input, mode.Live)))
+		handle("/admin/exchanges", s.requireScope(auth.AdminExchanges, s.handleExchanges))
+	}
+	if s.auth != nil {
+		handle("/admin/keys", s.requireScope(auth.AdminKeys, s.handleKeys))
+		handle("/admin/keys/", s.requireScope(auth.AdminKeys, s.handleKeys))
+	}
+	handle("/health", s.handleHealth)
+	handle("/openapi.json", handleOpenAPI)
+	return routes
 }
@@ -0,0 +1,92 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+	"marketflow/pkg/api"
+)
+
+const streamPingInterval = 30 * time.Second
+
+// checkOrigin refuses browser pages from other origins unless they are
+// listed in wsOrigins. Browsers send Origin on every WebSocket handshake and
+// do not apply CORS to it, so without this check any site could open a
+// stream, with a key it was given for ?api_key=. Clients that are not
+// browsers send no Origin and pass.
+func (s *Server) checkOrigin(r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return nil
+	}
+	for _, allowed := range s.wsOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return nil
+		}
+	}
+	return fmt.Errorf("origin %s may not open a stream: %w", origin, domain.ErrForbidden)
+}
+
+// handleStream upgrades to a WebSocket and sends every live tick as a JSON
+// message, optionally filtered with ?exchange= and ?pair=.
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, r, errMethodNotAllowed)
+		return
+	}
+	if err := s.checkOrigin(r); err != nil {
+		respondError(w, r, err)
+		return
+	}
+	conn, err := upgrade(w, r)
+	if err != nil {
+		respondError(w, r, invalidArgument(err))
+		return
+	}
+	defer conn.Close()
+
+	q := r.URL.Query()
+	sub := s.hub.Subscribe("ws "+requestID(r.Context()), q.Get("exchange"), q.Get("pair"))
+	defer s.hub.Unsubscribe(sub)
+	logger.Info("stream client connected", "request_id", requestID(r.Context()),
+		"exchange", sub.Exchange, "pair", sub.Pair)
+
+	done := make(chan struct{})
+	go conn.readLoop(done)
+
+	ping := time.NewTicker(streamPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-done:
+			logger.Info("stream client disconnected", "request_id", requestID(r.Context()))
+			return
+		case <-ping.C:
+			if err := conn.writeFrame(opPing, nil); err != nil {
+				return
+			}
+		case update := <-sub.Updates:
+			data, err := json.Marshal(api.Tick{
+				Exchange: update.Exchange,
+				Pair:     update.Pair,
+				Price:    update.Price,
+				Time:     update.Time,
+			})
+			if err != nil {
+				continue
+			}
+			if err := conn.writeFrame(opText, data); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package web
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// pipe returns a wsConn whose readLoop is running, and the client end.
+func pipe(t *testing.T) (*wsConn, net.Conn, <-chan struct{}) {
+	t.Helper()
+	server, client := net.Pipe()
+	t.Cleanup(func() { server.Close(); client.Close() })
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+	c := &wsConn{conn: server, rw: bufio.NewReadWriter(bufio.NewReader(server), bufio.NewWriter(server))}
+	done := make(chan struct{})
+	go c.readLoop(done)
+	return c, client, done
+}
+
+// clientFrame encodes a frame as a client sends it, masked.
+func clientFrame(first byte, payload []byte) []byte {
+	mask := [4]byte{0x12, 0x34, 0x56, 0x78}
+	frame := []byte{first, 0x80}
+	switch n := len(payload); {
+	case n < 126:
+		frame[1] |= byte(n)
+	case n <= 0xFFFF:
+		frame[1] |= 126
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame[1] |= 127
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	return frame
+}
+
+type frame struct {
+	fin     bool
+	op      byte
+	payload []byte
+}
+
+// readFrame reads one server frame, which must not be masked.
+func readFrame(t *testing.T, r io.Reader) frame {
+	t.Helper()
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(r, head); err != nil {
+		t.Fatal(err)
+	}
+	if head[1]&0x80 != 0 {
+		t.Fatal("server frame is masked")
+	}
+	n := uint64(head[1] & 0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		io.ReadFull(r, ext[:])
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		io.ReadFull(r, ext[:])
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatal(err)
+	}
+	return frame{fin: head[0]&0x80 != 0, op: head[0] & 0x0F, payload: payload}
+}
+
+func write(t *testing.T, w io.Writer, frames ...[]byte) {
+	t.Helper()
+	for _, f := range frames {
+		if _, err := w.Write(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func waitDone(t *testing.T, done <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("readLoop did not return")
+	}
+}
+
+func TestWriteFrameLengths(t *testing.T) {
+	c, client, _ := pipe(t)
+	for _, n := range []int{0, 125, 126, 0xFFFF, 0x10000} {
+		payload := bytes.Repeat([]byte{'x'}, n)
+		go c.writeFrame(opText, payload)
+		f := readFrame(t, client)
+		if !f.fin || f.op != opText || !bytes.Equal(f.payload, payload) {
+			t.Errorf("%d-byte frame: fin %v, op %#x, %d bytes back", n, f.fin, f.op, len(f.payload))
+		}
+	}
+}
+
+func TestReadLoopUnmasksAndAnswersPings(t *testing.T) {
+	_, client, done := pipe(t)
+
+	// The masked ping payload comes back unmasked in the pong.
+	write(t, client, clientFrame(0x80|opPing, []byte("hello")))
+	if f := readFrame(t, client); f.op != opPong || string(f.payload) != "hello" {
+		t.Errorf("answer to ping = %#x %q, want a pong with hello", f.op, f.payload)
+	}
+
+	// A fragmented text message with a ping between its fragments: the
+	// message is dropped and the ping still answered. The pipe is
+	// unbuffered, so the frames go in while the pongs are read.
+	var fragmented []byte
+	for _, f := range [][]byte{
+		clientFrame(opText, []byte("first ")),
+		clientFrame(0x0, bytes.Repeat([]byte{'m'}, 300)),
+		clientFrame(0x80|opPing, []byte("mid")),
+		clientFrame(0x80|0x0, []byte(" last")),
+		clientFrame(0x80|opPing, []byte("after")),
+	} {
+		fragmented = append(fragmented, f...)
+	}
+	go client.Write(fragmented)
+	for _, want := range []string{"mid", "after"} {
+		if f := readFrame(t, client); f.op != opPong || string(f.payload) != want {
+			t.Errorf("answer = %#x %q, want a pong with %s", f.op, f.payload, want)
+		}
+	}
+
+	select {
+	case <-done:
+		t.Fatal("readLoop returned on valid frames")
+	default:
+	}
+}
+
+func TestReadLoopCloseHandshake(t *testing.T) {
+	_, client, done := pipe(t)
+	write(t, client, clientFrame(0x80|opClose, []byte{0x03, 0xE8, 'b', 'y', 'e'}))
+	if f := readFrame(t, client); f.op != opClose || !bytes.Equal(f.payload, []byte{0x03, 0xE8}) {
+		t.Errorf("answer to close = %#x %v, want close 1000", f.op, f.payload)
+	}
+	waitDone(t, done)
+}
+
+func TestReadLoopRejectsProtocolErrors(t *testing.T) {
+	unmasked := clientFrame(0x80|opText, []byte("hi"))
+	unmasked[1] &^= 0x80
+	unmasked = append(unmasked[:2], []byte("hi")...)
+
+	for name, f := range map[string][]byte{
+		"unmasked":             unmasked,
+		"extension bit":        clientFrame(0x80|0x40|opText, []byte("hi")),
+		"fragmented ping":      clientFrame(opPing, []byte("hi")),
+		"long ping":            clientFrame(0x80|opPing, bytes.Repeat([]byte{'p'}, 126)),
+		"oversized text":       clientFrame(0x80|opText, make([]byte, wsMaxFrame+1)),
+		"fragmented close":     clientFrame(opClose, nil),
+		"long close payload":   clientFrame(0x80|opClose, bytes.Repeat([]byte{'c'}, 200)),
+		"oversized fragment":   clientFrame(0x0, make([]byte, wsMaxFrame+1)),
+		"reserved bit on ping": clientFrame(0x80|0x10|opPing, nil),
+	} {
+		_, client, done := pipe(t)
+		go client.Write(f)
+		if got := readFrame(t, client); got.op != opClose || !bytes.Equal(got.payload, []byte{0x03, 0xEA}) {
+			t.Errorf("%s: answer = %#x %v, want close 1002", name, got.op, got.payload)
+		}
+		waitDone(t, done)
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	s := &Server{wsOrigins: []string{"https://dash.example.com/"}}
+	for _, tc := range []struct {
+		origin string
+		ok     bool
+	}{
+		{"", true},
+		{"http://api.example.com:8080", true},
+		{"https://dash.example.com", true},
+		{"HTTPS://DASH.example.com", true},
+		{"https://evil.example.com", false},
+		{"null", false},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "http://api.example.com:8080/ws/prices", nil)
+		if tc.origin != "" {
+			r.Header.Set("Origin", tc.origin)
+		}
+		if err := s.checkOrigin(r); (err == nil) != tc.ok {
+			t.Errorf("origin %q: err = %v, want allowed %v", tc.origin, err, tc.ok)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "http://api.example.com/ws/prices", nil)
+	r.Header.Set("Origin", "https://anything.example.org")
+	if err := (&Server{wsOrigins: []string{"*"}}).checkOrigin(r); err != nil {
+		t.Errorf("* refused %s: %v", r.Header.Get("Origin"), err)
+	}
+}
@@ -0,0 +1,160 @@
+// Package webhook posts alert events to the URLs their alerts name.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/pkg/api"
+)
+
+// Headers set on every notification.
+const (
+	HeaderEvent     = "X-Marketflow-Event"
+	HeaderTimestamp = "X-Marketflow-Timestamp"
+	HeaderSignature = "X-Marketflow-Signature"
+)
+
+// errPrivateAddress refuses webhooks that would reach into the network
+// marketflow runs in.
+var errPrivateAddress = errors.New("webhook address is loopback, link-local or private")
+
+// Sender is a domain.AlertNotifier that makes one signed POST per call.
+// Unless allowPrivate is set, it refuses to connect to loopback,
+// link-local and private addresses. The check is made on the address
+// actually dialed, after DNS resolution and on every redirect, so a host
+// that resolves differently later cannot get around it.
+type Sender struct {
+	client       *http.Client
+	allowPrivate bool
+}
+
+func NewSender(timeout time.Duration, allowPrivate bool) *Sender {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = refusePrivate
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	return &Sender{
+		client:       &http.Client{Timeout: timeout, Transport: transport},
+		allowPrivate: allowPrivate,
+	}
+}
+
+// CheckURL resolves the host of a webhook URL and fails if any of its
+// addresses is one the Sender refuses.
+func (s *Sender) CheckURL(ctx context.Context, u *url.URL) error {
+	if s.allowPrivate {
+		return nil
+	}
+	host := u.Hostname()
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return checkAddr(ip)
+	}
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("cannot resolve webhook host %s: %w", host, err)
+	}
+	for _, ip := range ips {
+		if err := checkAddr(ip); err != nil {
+			return fmt.Errorf("%s: %w", host, err)
+		}
+	}
+	return nil
+}
+
+// refusePrivate is a net.Dialer Control function, called with the resolved
+// address just before connecting.
+func refusePrivate(network, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("unexpected dial address %q: %w", address, err)
+	}
+	return checkAddr(ap.Addr())
+}
+
+func checkAddr(ip netip.Addr) error {
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("%w: %s", errPrivateAddress, ip)
+	}
+	return nil
+}
+
+// Notify posts event as an api.AlertNotification. Any status outside 2xx
+// is an error.
+func (s *Sender) Notify(ctx context.Context, alert domain.Alert, event domain.AlertEvent) (int, error) {
+	body, err := json.Marshal(notification(alert, event))
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode notification: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, alert.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("invalid webhook request: %w", err)
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "marketflow-webhook")
+	req.Header.Set(HeaderEvent, event.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(alert.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to post to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16)) // lets the connection be reused
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the signature header of body sent at timestamp. Receivers
+// compute the same from the raw body and compare with hmac.Equal.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func notification(alert domain.Alert, event domain.AlertEvent) api.AlertNotification {
+	n := api.AlertNotification{
+		EventID:   event.ID,
+		AlertID:   alert.ID,
+		Name:      alert.Name,
+		Kind:      alert.Kind,
+		Exchange:  alert.Exchange,
+		Pair:      alert.Pair,
+		Threshold: alert.Threshold,
+		Price:     event.Price,
+		Move:      event.Move,
+		Message:   event.Message,
+		Time:      event.Time,
+	}
+	if alert.Period > 0 {
+		n.Period = alert.Period.String()
+	}
+	return n
+}
@@ -0,0 +1,129 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/pkg/api"
+)
+
+// TestNotifySignsBody checks a notification the way the README tells
+// receivers to: the HMAC of "{timestamp}.{body}" compared with hmac.Equal.
+func TestNotifySignsBody(t *testing.T) {
+	const secret = "topsecret"
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	got := make(chan received, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{r.Header, body}
+	}))
+	defer srv.Close()
+
+	alert := domain.Alert{ID: "a1", Kind: domain.AlertAbove, Pair: "BTCUSDT", Threshold: 100, Secret: secret, WebhookURL: srv.URL}
+	event := domain.AlertEvent{ID: "a1-1", AlertID: "a1", Price: 101, Time: time.Now().UTC()}
+	status, err := NewSender(time.Second, true).Notify(context.Background(), alert, event)
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Notify = %d, %v", status, err)
+	}
+	r := <-got
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(r.header.Get(HeaderTimestamp) + "."))
+	mac.Write(r.body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(r.header.Get(HeaderSignature)), []byte(want)) {
+		t.Errorf("signature %q, want %q", r.header.Get(HeaderSignature), want)
+	}
+	if ts, err := strconv.ParseInt(r.header.Get(HeaderTimestamp), 10, 64); err != nil || time.Since(time.Unix(ts, 0)) > time.Minute {
+		t.Errorf("timestamp header %q is not the current Unix time", r.header.Get(HeaderTimestamp))
+	}
+	if r.header.Get(HeaderEvent) != event.ID {
+		t.Errorf("event header %q, want %q", r.header.Get(HeaderEvent), event.ID)
+	}
+
+	var n api.AlertNotification
+	if err := json.Unmarshal(r.body, &n); err != nil {
+		t.Fatal(err)
+	}
+	if n.EventID != event.ID || n.AlertID != alert.ID || n.Kind != domain.AlertAbove || n.Price != 101 {
+		t.Errorf("notification = %+v", n)
+	}
+
+	// A receiver holding another secret rejects it.
+	other := hmac.New(sha256.New, []byte("other"))
+	other.Write([]byte(r.header.Get(HeaderTimestamp) + "."))
+	other.Write(r.body)
+	if hmac.Equal([]byte(r.header.Get(HeaderSignature)), []byte("sha256="+hex.EncodeToString(other.Sum(nil)))) {
+		t.Error("signature matches a different secret")
+	}
+}
+
+func TestCheckURLRefusesPrivateHosts(t *testing.T) {
+	s := NewSender(time.Second, false)
+	for _, raw := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://[::1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[fe80::1]/hook",
+		"http://10.0.0.5/hook",
+		"http://172.16.0.1/hook",
+		"https://192.168.1.10/hook",
+		"http://[fd00::1]/hook",
+		"http://0.0.0.0/hook",
+	} {
+		u, _ := url.Parse(raw)
+		if err := s.CheckURL(context.Background(), u); !errors.Is(err, errPrivateAddress) {
+			t.Errorf("CheckURL(%s) = %v, want errPrivateAddress", raw, err)
+		}
+	}
+
+	u, _ := url.Parse("https://93.184.216.34/hook")
+	if err := s.CheckURL(context.Background(), u); err != nil {
+		t.Errorf("CheckURL of a public address = %v", err)
+	}
+	u, _ = url.Parse("http://127.0.0.1/hook")
+	if err := NewSender(time.Second, true).CheckURL(context.Background(), u); err != nil {
+		t.Errorf("CheckURL with private webhooks allowed = %v", err)
+	}
+}
+
+// TestNotifyRefusesPrivateDial covers a host that passed CheckURL but
+// resolves to an internal address when the notification is sent.
+func TestNotifyRefusesPrivateDial(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	alert := domain.Alert{ID: "a1", Secret: "s", WebhookURL: srv.URL}
+	status, err := NewSender(time.Second, false).Notify(context.Background(), alert, domain.AlertEvent{ID: "e1"})
+	if !errors.Is(err, errPrivateAddress) || status != 0 {
+		t.Errorf("Notify to %s = %d, %v; want 0 and errPrivateAddress", srv.URL, status, err)
+	}
+	if hits.Load() != 0 {
+		t.Error("the request reached the receiver")
+	}
+
+	if _, err := NewSender(time.Second, true).Notify(context.Background(), alert, domain.AlertEvent{ID: "e1"}); err != nil {
+		t.Errorf("Notify with private webhooks allowed: %v", err)
+	}
+}
@@ -11,32 +11,46 @@ import (
 type Aggregator struct {
 	Input  <-chan domain.PriceUpdate
 	Repo   domain.PriceRepository
-	Cache  domain.Cache
 	Window time.Duration
+	// OnFlush, if set, is called with every batch after it was stored.
+	OnFlush  func(stats []domain.PriceStats)
+	windowCh chan time.Duration
 }
 
-func NewAggregator(input <-chan domain.PriceUpdate, repo domain.PriceRepository, cache domain.Cache, window time.Duration) *Aggregator {
+func NewAggregator(input <-chan domain.PriceUpdate, repo domain.PriceRepository, window time.Duration) *Aggregator {
 	return &Aggregator{
-		Input:  input,
-		Repo:   repo,
-		Cache:  cache,
-		Window: window,
+		Input:    input,
+		Repo:     repo,
+		Window:   window,
+		windowCh: make(chan time.Duration, 1),
 	}
 }
 
+// SetWindow schedules a new aggregation window. The current bucket is flushed
+// on its original schedule and the new window applies from the next one.
+func (a *Aggregator) SetWindow(window time.Duration) {
+	select {
+	case <-a.windowCh:
+	default:
+	}
+	a.windowCh <- window
+}
+
 func (a *Aggregator) Start(ctx context.Context) {
 	buffer := make(map[string][]float64)
 	ticker := time.NewTicker(a.Window)
-	cleanTicker := time.NewTicker(5 * time.Minute) // Очистка каждые 5 минут
 	defer ticker.Stop()
-	defer cleanTicker.Stop()
 
 	logger.Info("starting price aggregator", "window", a.Window)
 
+	var pendingWindow time.Duration
+
 	for {
 		select {
 		case <-ctx.Done():
-			a.flush(ctx, buffer, time.Now())
+			// The final flush must outlive the cancelled context; the
+			// repository's own batch deadline still bounds it.
+			a.flush(context.WithoutCancel(ctx), buffer, time.Now())
 			logger.Info("aggregator stopped by context")
 			return
 		case update, ok := <-a.Input:
@@ -45,28 +59,52 @@ func (a *Aggregator) Start(ctx context.Context) {
 				logger.Info("aggregator channel closed, stopping")
 				return
 			}
-			key := update.Exchange + ":" + update.Pair
+			key := Key(update.Exchange, update.Pair)
 			buffer[key] = append(buffer[key], update.Price)
 
 		case tickTime := <-ticker.C:
 			a.flush(ctx, buffer, tickTime)
 			buffer = make(map[string][]float64)
 			logger.Info("flushed aggregation buffer", "time", tickTime)
+			if pendingWindow > 0 {
+				logger.Info("applied new aggregation window", "old", a.Window, "new", pendingWindow)
+				a.Window = pendingWindow
+				ticker.Reset(a.Window)
+				pendingWindow = 0
+			}
 
-		case <-cleanTicker.C:
-			if cache, ok := a.Cache.(interface {
-				CleanOld(ctx context.Context, pattern string) error
-			}); ok {
-				if err := cache.CleanOld(ctx, "latest:*"); err != nil {
-					logger.Error("failed to clean old redis keys", "error", err)
-				}
-				logger.Info("ran cache cleanup")
+		case w := <-a.windowCh:
+			if w == a.Window {
+				pendingWindow = 0
+				continue
 			}
+			pendingWindow = w
+			logger.Info("aggregation window change scheduled for next bucket", "old", a.Window, "new", w)
 		}
 	}
 }
 
 func (a *Aggregator) flush(ctx context.Context, buffer map[string][]float64, ts time.Time) {
+	stats := Summarize(buffer, ts)
+	if len(stats) > 0 {
+		if err := a.Repo.StoreStatsBatch(ctx, stats); err != nil {
+			if domain.IsTimeout(err) {
+				logger.Error("storing batch stats timed out, window dropped", "count", len(stats), "error", err)
+			} else {
+				logger.Error("failed to store batch stats", "error", err)
+			}
+		} else {
+			logger.Info("stored batch stats", "count", len(stats))
+			if a.OnFlush != nil {
+				a.OnFlush(stats)
+			}
+		}
+	}
+}
+
+// Summarize turns a buffer of prices keyed by "exchange:pair" into one
+// PriceStats row per key stamped with ts.
+func Summarize(buffer map[string][]float64, ts time.Time) []domain.PriceStats {
 	var stats []domain.PriceStats
 	for key, prices := range buffer {
 		if len(prices) == 0 {
@@ -100,14 +138,12 @@ func (a *Aggregator) flush(ctx context.Context, buffer map[string][]float64, ts
 		stats = append(stats, stat)
 		logger.Debug("created stat", "exchange", exchange, "pair", pair, "avg", avg, "min", min, "max", max, "count", len(prices))
 	}
+	return stats
+}
 
-	if len(stats) > 0 {
-		if err := a.Repo.StoreStatsBatch(stats); err != nil {
-			logger.Error("failed to store batch stats", "error", err)
-		} else {
-			logger.Info("stored batch stats", "count", len(stats))
-		}
-	}
+// Key builds the buffer key used by Summarize.
+func Key(exchange, pair string) string {
+	return exchange + ":" + pair
 }
 
 func splitKey(key string) []string {
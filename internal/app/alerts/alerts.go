@@ -0,0 +1,109 @@
+// Package alerts registers price alerts and evaluates them against the
+// live ticks and stored stats, posting each firing to the alert's webhook.
+package alerts
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+var Kinds = []string{domain.AlertAbove, domain.AlertBelow, domain.AlertMove, domain.AlertStale}
+
+// Service checks and stores new alerts. Reading and deleting go straight to
+// Store.
+type Service struct {
+	Store domain.AlertStore
+	// DefaultCooldown applies to alerts created without one.
+	DefaultCooldown time.Duration
+	// CheckWebhook, if set, vets the webhook URL of a new alert, such as
+	// webhook.Sender.CheckURL refusing addresses inside the network.
+	CheckWebhook func(ctx context.Context, u *url.URL) error
+}
+
+func NewService(store domain.AlertStore, defaultCooldown time.Duration) *Service {
+	return &Service{Store: store, DefaultCooldown: defaultCooldown}
+}
+
+// Create validates alert, gives it an ID, a signing secret and the default
+// cooldown if it has none, and stores it.
+func (s *Service) Create(ctx context.Context, alert domain.Alert) (domain.Alert, error) {
+	alert.Name = strings.TrimSpace(alert.Name)
+	if alert.Exchange == "" {
+		alert.Exchange = "ex1"
+	}
+	if alert.Cooldown == 0 {
+		alert.Cooldown = s.DefaultCooldown
+	}
+	if err := validate(alert); err != nil {
+		return domain.Alert{}, fmt.Errorf("%w: %w", err, domain.ErrInvalidArgument)
+	}
+	if s.CheckWebhook != nil {
+		u, _ := url.Parse(alert.WebhookURL) // validate has parsed it
+		if err := s.CheckWebhook(ctx, u); err != nil {
+			return domain.Alert{}, fmt.Errorf("webhook_url refused: %w: %w", err, domain.ErrInvalidArgument)
+		}
+	}
+
+	id, err := randomHex(8)
+	if err != nil {
+		return domain.Alert{}, err
+	}
+	secret, err := randomHex(24)
+	if err != nil {
+		return domain.Alert{}, err
+	}
+	alert.ID, alert.Secret = id, secret
+	alert.CreatedAt = time.Now().UTC()
+	if err := s.Store.CreateAlert(ctx, alert); err != nil {
+		return domain.Alert{}, err
+	}
+	return alert, nil
+}
+
+func validate(a domain.Alert) error {
+	switch a.Kind {
+	case domain.AlertAbove, domain.AlertBelow:
+		if a.Threshold <= 0 {
+			return fmt.Errorf("%s alerts need a positive threshold price, got %g", a.Kind, a.Threshold)
+		}
+	case domain.AlertMove:
+		if a.Threshold <= 0 {
+			return fmt.Errorf("move alerts need a positive threshold percent, got %g", a.Threshold)
+		}
+		if a.Period <= 0 {
+			return fmt.Errorf("move alerts need a positive period, got %s", a.Period)
+		}
+	case domain.AlertStale:
+		if a.Period <= 0 {
+			return fmt.Errorf("stale alerts need a positive period, got %s", a.Period)
+		}
+	default:
+		return fmt.Errorf("unknown alert kind %q, want one of %s", a.Kind, strings.Join(Kinds, ", "))
+	}
+	if a.Pair == "" {
+		return fmt.Errorf("pair must not be empty")
+	}
+	if a.Cooldown < 0 {
+		return fmt.Errorf("cooldown must not be negative, got %s", a.Cooldown)
+	}
+	u, err := url.Parse(a.WebhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook_url must be an absolute http or https URL, got %q", a.WebhookURL)
+	}
+	return nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate alert id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
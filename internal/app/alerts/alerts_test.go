@@ -0,0 +1,43 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/memory"
+	"marketflow/internal/adapters/webhook"
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.InitWithWriter(io.Discard, "test", "error")
+	os.Exit(m.Run())
+}
+
+func TestCreateRefusesPrivateWebhooks(t *testing.T) {
+	s := NewService(memory.NewAlertStore(), time.Minute)
+	s.CheckWebhook = webhook.NewSender(time.Second, false).CheckURL
+	ctx := context.Background()
+	alert := domain.Alert{Kind: domain.AlertAbove, Pair: "BTCUSDT", Threshold: 100}
+
+	for _, url := range []string{"http://127.0.0.1:9000/hook", "http://169.254.169.254/", "http://10.0.0.1/hook"} {
+		alert.WebhookURL = url
+		if _, err := s.Create(ctx, alert); !errors.Is(err, domain.ErrInvalidArgument) {
+			t.Errorf("Create with %s = %v, want ErrInvalidArgument", url, err)
+		}
+	}
+
+	alert.WebhookURL = "https://93.184.216.34/hook"
+	created, err := s.Create(ctx, alert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID == "" || created.Secret == "" || created.Cooldown != time.Minute {
+		t.Errorf("created = %+v, want an ID, a secret and the default cooldown", created)
+	}
+}
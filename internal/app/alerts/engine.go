@@ -0,0 +1,308 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"marketflow/internal/app/hub"
+	"marketflow/internal/app/query"
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+const (
+	deliveryWorkers = 4
+	deliveryQueue   = 256
+)
+
+// Engine evaluates the stored alerts and delivers their events. Above and
+// below alerts fire when a tick crosses the threshold, so an alert created
+// while the price is already past it waits for the next crossing. Move and
+// stale alerts are checked every EvalInterval and fire when their condition
+// becomes true. Once fired, an alert stays quiet for its cooldown.
+//
+// Only one instance should run an Engine, or each event is delivered once
+// per instance.
+type Engine struct {
+	Store    domain.AlertStore
+	Notifier domain.AlertNotifier
+	Query    *query.Service
+	Ticks    *hub.Hub
+	// EvalInterval is how often move and stale alerts are checked, and
+	// RefreshInterval how often alerts are reloaded from Store.
+	EvalInterval    time.Duration
+	RefreshInterval time.Duration
+	// Retries is how many times a failed delivery is retried, waiting
+	// RetryBackoff before the first retry and twice as long before each next.
+	Retries      int
+	RetryBackoff time.Duration
+
+	// All state below belongs to the goroutine running Start.
+	started  time.Time
+	alerts   map[string]*state
+	byPair   map[domain.PairKey][]*state
+	lastTick map[domain.PairKey]domain.PriceUpdate
+	queue    chan delivery
+}
+
+type state struct {
+	alert domain.Alert
+	// side is where the last tick was relative to the threshold of an above
+	// or below alert: -1 under, 1 at or over, 0 not seen yet.
+	side int
+	// active is whether the condition of a move or stale alert held at the
+	// last check; it must stop holding before the alert fires again.
+	active    bool
+	lastFired time.Time
+}
+
+type delivery struct {
+	alert domain.Alert
+	event domain.AlertEvent
+}
+
+// Start evaluates alerts until ctx is cancelled.
+func (e *Engine) Start(ctx context.Context) {
+	logger.Info("starting alert engine", "eval_interval", e.EvalInterval, "refresh_interval", e.RefreshInterval)
+
+	e.started = time.Now()
+	e.alerts = make(map[string]*state)
+	e.lastTick = make(map[domain.PairKey]domain.PriceUpdate)
+	e.queue = make(chan delivery, deliveryQueue)
+	e.refresh(ctx)
+
+	for i := 0; i < deliveryWorkers; i++ {
+		go e.deliverLoop(ctx)
+	}
+
+	sub := e.Ticks.SubscribeAll("alerts")
+	defer e.Ticks.Unsubscribe(sub)
+	evalTicker := time.NewTicker(e.EvalInterval)
+	defer evalTicker.Stop()
+	refreshTicker := time.NewTicker(e.RefreshInterval)
+	defer refreshTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("alert engine stopped by context")
+			return
+		case update := <-sub.Updates:
+			e.observe(update)
+		case <-evalTicker.C:
+			e.evaluate(ctx)
+		case <-refreshTicker.C:
+			e.refresh(ctx)
+		}
+	}
+}
+
+// refresh reloads the alerts, keeping the state of those already known. A
+// new alert's cooldown counts from its last recorded delivery, so a restart
+// does not repeat an event.
+func (e *Engine) refresh(ctx context.Context) {
+	list, err := e.Store.ListAlerts(ctx)
+	if err != nil {
+		logger.Warn("failed to load alerts, keeping the previous ones", "error", err)
+		return
+	}
+
+	alerts := make(map[string]*state, len(list))
+	byPair := make(map[domain.PairKey][]*state)
+	for _, a := range list {
+		st, ok := e.alerts[a.ID]
+		if !ok {
+			st = &state{}
+			if last, err := e.Store.ListDeliveries(ctx, a.ID, 1); err == nil && len(last) > 0 {
+				st.lastFired = last[0].Time
+			}
+		}
+		st.alert = a
+		alerts[a.ID] = st
+		key := domain.PairKey{Exchange: a.Exchange, Pair: a.Pair}
+		byPair[key] = append(byPair[key], st)
+	}
+	e.alerts, e.byPair = alerts, byPair
+}
+
+// observe checks the above and below alerts of update's pair.
+func (e *Engine) observe(update domain.PriceUpdate) {
+	key := domain.PairKey{Exchange: update.Exchange, Pair: update.Pair}
+	e.lastTick[key] = update
+
+	for _, st := range e.byPair[key] {
+		a := st.alert
+		if a.Kind != domain.AlertAbove && a.Kind != domain.AlertBelow {
+			continue
+		}
+		side := -1
+		if update.Price >= a.Threshold {
+			side = 1
+		}
+		prev := st.side
+		st.side = side
+		if a.Kind == domain.AlertBelow {
+			side, prev = -side, -prev
+		}
+		if prev == -1 && side == 1 {
+			e.fire(st, domain.AlertEvent{
+				Time:    update.Time,
+				Price:   update.Price,
+				Message: fmt.Sprintf("%s on %s crossed %s %g at %g", a.Pair, a.Exchange, a.Kind, a.Threshold, update.Price),
+			})
+		}
+	}
+}
+
+// evaluate checks the move and stale alerts.
+func (e *Engine) evaluate(ctx context.Context) {
+	now := time.Now()
+	for _, st := range e.alerts {
+		a := st.alert
+		key := domain.PairKey{Exchange: a.Exchange, Pair: a.Pair}
+		switch a.Kind {
+		case domain.AlertStale:
+			last := e.started
+			if tick, ok := e.lastTick[key]; ok && tick.Time.After(last) {
+				last = tick.Time
+			}
+			stale := now.Sub(last) >= a.Period
+			if stale && !st.active {
+				e.fire(st, domain.AlertEvent{
+					Time:    now,
+					Message: fmt.Sprintf("no price for %s on %s since %s", a.Pair, a.Exchange, last.UTC().Format(time.RFC3339)),
+				})
+			}
+			st.active = stale
+
+		case domain.AlertMove:
+			price, move, err := e.move(ctx, a, e.lastTick[key])
+			if err != nil {
+				if !errors.Is(err, domain.ErrNotFound) {
+					logger.Warn("failed to evaluate alert", "alert_id", a.ID, "error", err)
+				}
+				continue
+			}
+			moved := math.Abs(move) >= a.Threshold
+			if moved && !st.active {
+				e.fire(st, domain.AlertEvent{
+					Time:    now,
+					Price:   price,
+					Move:    move,
+					Message: fmt.Sprintf("%s on %s moved %+.2f%% within %s to %g", a.Pair, a.Exchange, move, a.Period, price),
+				})
+			}
+			st.active = moved
+		}
+	}
+}
+
+// move returns the current price and its percent change from the lowest
+// price of the alert's period or, if that is larger, from the highest.
+func (e *Engine) move(ctx context.Context, a domain.Alert, last domain.PriceUpdate) (price, move float64, err error) {
+	stats, err := e.Query.Period(ctx, a.Exchange, a.Pair, a.Period)
+	if err != nil {
+		return 0, 0, err
+	}
+	price = stats[len(stats)-1].Average
+	if !last.Time.IsZero() {
+		price = last.Price
+	}
+	low, _ := query.Lowest(stats)
+	high, _ := query.Highest(stats)
+	var up, down float64
+	if low > 0 {
+		up = (price - low) / low * 100
+	}
+	if high > 0 {
+		down = (price - high) / high * 100
+	}
+	if up >= -down {
+		return price, up, nil
+	}
+	return price, down, nil
+}
+
+// fire queues event unless the alert is cooling down.
+func (e *Engine) fire(st *state, event domain.AlertEvent) {
+	a := st.alert
+	now := time.Now()
+	if !st.lastFired.IsZero() && now.Sub(st.lastFired) < a.Cooldown {
+		logger.Debug("alert in cooldown", "alert_id", a.ID, "message", event.Message)
+		return
+	}
+	st.lastFired = now
+	event.AlertID = a.ID
+	event.ID = fmt.Sprintf("%s-%d", a.ID, now.UnixMilli())
+
+	logger.Info("alert fired", "alert_id", a.ID, "event_id", event.ID, "message", event.Message)
+	select {
+	case e.queue <- delivery{alert: a, event: event}:
+	default:
+		logger.Error("alert delivery queue full, dropping event", "alert_id", a.ID, "event_id", event.ID)
+	}
+}
+
+func (e *Engine) deliverLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case d := <-e.queue:
+			e.deliver(ctx, d)
+		}
+	}
+}
+
+// deliver posts d until the webhook accepts it, the retries run out or the
+// webhook rejects it with a client error, and records the outcome.
+func (e *Engine) deliver(ctx context.Context, d delivery) {
+	rec := domain.AlertDelivery{AlertID: d.alert.ID, EventID: d.event.ID}
+	backoff := e.RetryBackoff
+	for {
+		rec.Attempts++
+		status, err := e.Notifier.Notify(ctx, d.alert, d.event)
+		rec.StatusCode = status
+		if err == nil {
+			rec.Error = ""
+			break
+		}
+		rec.Error = err.Error()
+		if rec.Attempts > e.Retries || !retryable(status) || !sleep(ctx, backoff) {
+			break
+		}
+		backoff *= 2
+	}
+	rec.Time = time.Now().UTC()
+
+	if rec.Error != "" {
+		logger.Warn("alert delivery failed", "alert_id", d.alert.ID, "event_id", d.event.ID, "attempts", rec.Attempts, "error", rec.Error)
+	} else {
+		logger.Info("alert delivered", "alert_id", d.alert.ID, "event_id", d.event.ID, "attempts", rec.Attempts)
+	}
+	if err := e.Store.RecordDelivery(context.WithoutCancel(ctx), rec); err != nil && !errors.Is(err, domain.ErrNotFound) {
+		logger.Error("failed to record alert delivery", "alert_id", d.alert.ID, "error", err)
+	}
+}
+
+// sleep waits for d and reports whether ctx is still live.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// retryable reports whether a failed attempt that got status may succeed
+// later: no response at all, a server error, a timeout or throttling.
+func retryable(status int) bool {
+	return status == 0 || status >= 500 || status == http.StatusRequestTimeout || status == http.StatusTooManyRequests
+}
@@ -0,0 +1,210 @@
+package alerts
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/memory"
+	"marketflow/internal/adapters/webhook"
+	"marketflow/internal/domain"
+)
+
+const backoff = 20 * time.Millisecond
+
+// receiver answers with statuses in turn, repeating the last one, and
+// records when each call came.
+type receiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	calls    []time.Time
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	rcv := &receiver{statuses: statuses}
+	rcv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rcv.mu.Lock()
+		defer rcv.mu.Unlock()
+		status := rcv.statuses[min(len(rcv.calls), len(rcv.statuses)-1)]
+		rcv.calls = append(rcv.calls, time.Now())
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(rcv.Close)
+	return rcv
+}
+
+func (rcv *receiver) callTimes() []time.Time {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	return append([]time.Time(nil), rcv.calls...)
+}
+
+// newEngine prepares an Engine over alerts as Start does, without running
+// its loops.
+func newEngine(t *testing.T, alerts ...domain.Alert) *Engine {
+	t.Helper()
+	store := memory.NewAlertStore()
+	for _, a := range alerts {
+		if err := store.CreateAlert(context.Background(), a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e := &Engine{
+		Store:        store,
+		Notifier:     webhook.NewSender(time.Second, true),
+		Retries:      3,
+		RetryBackoff: backoff,
+	}
+	e.started = time.Now()
+	e.alerts = make(map[string]*state)
+	e.lastTick = make(map[domain.PairKey]domain.PriceUpdate)
+	e.queue = make(chan delivery, deliveryQueue)
+	e.refresh(context.Background())
+	return e
+}
+
+// deliverOne delivers one event of an alert posting to rcv and returns the
+// delivery recorded for it.
+func deliverOne(t *testing.T, rcv *receiver) domain.AlertDelivery {
+	t.Helper()
+	alert := domain.Alert{ID: "a1", Kind: domain.AlertAbove, Pair: "BTCUSDT", Threshold: 100, WebhookURL: rcv.URL}
+	e := newEngine(t, alert)
+	e.deliver(context.Background(), delivery{alert: alert, event: domain.AlertEvent{ID: "a1-1", AlertID: "a1"}})
+	recorded, err := e.Store.ListDeliveries(context.Background(), "a1", 10)
+	if err != nil || len(recorded) != 1 {
+		t.Fatalf("deliveries = %v, %v; want one", recorded, err)
+	}
+	return recorded[0]
+}
+
+func TestDeliverRetriesServerErrorsAndThrottling(t *testing.T) {
+	rcv := newReceiver(t, http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusOK)
+	d := deliverOne(t, rcv)
+	if d.Attempts != 4 || d.StatusCode != http.StatusOK || d.Error != "" {
+		t.Errorf("delivery = %+v, want delivered on the fourth attempt", d)
+	}
+
+	// The waits between attempts double from RetryBackoff.
+	calls := rcv.callTimes()
+	for i := 1; i < len(calls); i++ {
+		want := backoff << (i - 1)
+		if gap := calls[i].Sub(calls[i-1]); gap < want {
+			t.Errorf("wait before attempt %d = %s, want at least %s", i+1, gap, want)
+		}
+	}
+}
+
+func TestDeliverGivesUpAfterRetries(t *testing.T) {
+	rcv := newReceiver(t, http.StatusBadGateway)
+	d := deliverOne(t, rcv)
+	if d.Attempts != 4 || d.StatusCode != http.StatusBadGateway || d.Error == "" {
+		t.Errorf("delivery = %+v, want a failure after 1 attempt and 3 retries", d)
+	}
+}
+
+func TestDeliverDoesNotRetryClientErrors(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound} {
+		rcv := newReceiver(t, status, http.StatusOK)
+		d := deliverOne(t, rcv)
+		if d.Attempts != 1 || d.StatusCode != status || d.Error == "" {
+			t.Errorf("%d: delivery = %+v, want one failed attempt", status, d)
+		}
+		if n := len(rcv.callTimes()); n != 1 {
+			t.Errorf("%d: receiver called %d times, want once", status, n)
+		}
+	}
+}
+
+// queued returns the events fired so far.
+func queued(e *Engine) []domain.AlertEvent {
+	var events []domain.AlertEvent
+	for {
+		select {
+		case d := <-e.queue:
+			events = append(events, d.event)
+		default:
+			return events
+		}
+	}
+}
+
+func tick(price float64) domain.PriceUpdate {
+	return domain.PriceUpdate{Exchange: "ex1", Pair: "BTCUSDT", Price: price, Time: time.Now()}
+}
+
+func TestAboveAndBelowFireOnCrossing(t *testing.T) {
+	above := domain.Alert{ID: "up", Kind: domain.AlertAbove, Exchange: "ex1", Pair: "BTCUSDT", Threshold: 100}
+	below := domain.Alert{ID: "down", Kind: domain.AlertBelow, Exchange: "ex1", Pair: "BTCUSDT", Threshold: 100}
+	e := newEngine(t, above, below)
+
+	steps := []struct {
+		price float64
+		fired []string
+	}{
+		{110, nil},             // first tick: already past above's threshold, no crossing
+		{120, nil},             // still above
+		{95, []string{"down"}}, // crossed below
+		{90, nil},              // still below
+		{100, []string{"up"}},  // at the threshold counts as above
+		{101, nil},
+	}
+	for _, step := range steps {
+		e.observe(tick(step.price))
+		var fired []string
+		for _, ev := range queued(e) {
+			fired = append(fired, ev.AlertID)
+		}
+		if len(fired) != len(step.fired) || (len(fired) == 1 && fired[0] != step.fired[0]) {
+			t.Errorf("tick %g fired %v, want %v", step.price, fired, step.fired)
+		}
+	}
+	e.observe(domain.PriceUpdate{Exchange: "ex2", Pair: "BTCUSDT", Price: 50})
+	if len(queued(e)) != 0 {
+		t.Error("a tick of another exchange fired an alert")
+	}
+}
+
+func TestCooldownSuppressesRepeats(t *testing.T) {
+	alert := domain.Alert{ID: "up", Kind: domain.AlertAbove, Exchange: "ex1", Pair: "BTCUSDT", Threshold: 100, Cooldown: time.Hour}
+	e := newEngine(t, alert)
+
+	for _, price := range []float64{90, 110, 90, 110, 90, 110} {
+		e.observe(tick(price))
+	}
+	if events := queued(e); len(events) != 1 {
+		t.Fatalf("three crossings within the cooldown fired %d events, want 1", len(events))
+	}
+
+	// Once the cooldown has passed, the next crossing fires.
+	e.alerts["up"].lastFired = time.Now().Add(-time.Hour - time.Second)
+	e.observe(tick(90))
+	e.observe(tick(110))
+	if events := queued(e); len(events) != 1 || events[0].Price != 110 {
+		t.Errorf("crossing after the cooldown fired %v, want one event at 110", events)
+	}
+}
+
+func TestCooldownCountsFromRecordedDelivery(t *testing.T) {
+	alert := domain.Alert{ID: "up", Kind: domain.AlertAbove, Exchange: "ex1", Pair: "BTCUSDT", Threshold: 100, Cooldown: time.Hour}
+	store := memory.NewAlertStore()
+	store.CreateAlert(context.Background(), alert)
+	store.RecordDelivery(context.Background(), domain.AlertDelivery{AlertID: "up", EventID: "up-1", Time: time.Now().Add(-time.Minute)})
+
+	// A restarted engine picks up the last delivery.
+	e := &Engine{Store: store}
+	e.started = time.Now()
+	e.lastTick = make(map[domain.PairKey]domain.PriceUpdate)
+	e.queue = make(chan delivery, 1)
+	e.refresh(context.Background())
+
+	e.observe(tick(90))
+	e.observe(tick(110))
+	if events := queued(e); len(events) != 0 {
+		t.Errorf("crossing a minute after the last delivery fired %d events, want none", len(events))
+	}
+}
@@ -0,0 +1,226 @@
+// Package analytics derives figures across exchanges from the live ticks.
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+// SpreadEvent reports that a pair's spread has stayed at or above the
+// threshold for the minimum duration (Wide), or has narrowed again after
+// that.
+type SpreadEvent struct {
+	Sample domain.SpreadSample
+	Wide   bool
+	// Since is when the spread crossed the threshold.
+	Since time.Time
+}
+
+// SpreadMonitor keeps the latest price of every pair on every exchange and
+// compares them. Prices older than MaxAge are left out, so an exchange that
+// stopped sending does not hold the spread open.
+type SpreadMonitor struct {
+	ThresholdBps float64
+	MinDuration  time.Duration
+	MaxAge       time.Duration
+	// OnEvent, if set, is called for every event after it is logged.
+	OnEvent func(SpreadEvent)
+
+	mu      sync.Mutex
+	prices  map[string]map[string]domain.PriceUpdate // pair, then exchange
+	crossed map[string]time.Time                     // when the spread went over the threshold
+	wide    map[string]bool                          // an event has been raised for the crossing
+}
+
+func NewSpreadMonitor(thresholdBps float64, minDuration, maxAge time.Duration) *SpreadMonitor {
+	return &SpreadMonitor{
+		ThresholdBps: thresholdBps,
+		MinDuration:  minDuration,
+		MaxAge:       maxAge,
+		prices:       make(map[string]map[string]domain.PriceUpdate),
+		crossed:      make(map[string]time.Time),
+		wide:         make(map[string]bool),
+	}
+}
+
+// Run observes updates until ctx is cancelled or updates is closed.
+func (m *SpreadMonitor) Run(ctx context.Context, updates <-chan domain.PriceUpdate) {
+	logger.Info("starting spread monitor", "threshold_bps", m.ThresholdBps, "min_duration", m.MinDuration)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			m.Observe(update)
+		}
+	}
+}
+
+// Observe records update and checks its pair's spread against the threshold.
+func (m *SpreadMonitor) Observe(update domain.PriceUpdate) {
+	now := time.Now()
+
+	m.mu.Lock()
+	byExchange := m.prices[update.Pair]
+	if byExchange == nil {
+		byExchange = make(map[string]domain.PriceUpdate)
+		m.prices[update.Pair] = byExchange
+	}
+	if prev, ok := byExchange[update.Exchange]; ok && prev.Time.After(update.Time) {
+		m.mu.Unlock()
+		return // out of order
+	}
+	byExchange[update.Exchange] = update
+
+	var event *SpreadEvent
+	sample, ok := m.sample(update.Pair, now)
+	switch {
+	case ok && sample.Bps >= m.ThresholdBps:
+		since, crossed := m.crossed[update.Pair]
+		if !crossed {
+			since = now
+			m.crossed[update.Pair] = now
+		}
+		if !m.wide[update.Pair] && now.Sub(since) >= m.MinDuration {
+			m.wide[update.Pair] = true
+			event = &SpreadEvent{Sample: sample, Wide: true, Since: since}
+		}
+	default:
+		since := m.crossed[update.Pair]
+		delete(m.crossed, update.Pair)
+		if m.wide[update.Pair] {
+			delete(m.wide, update.Pair)
+			if !ok {
+				sample = domain.SpreadSample{Pair: update.Pair, Time: now}
+			}
+			event = &SpreadEvent{Sample: sample, Wide: false, Since: since}
+		}
+	}
+	m.mu.Unlock()
+
+	if event != nil {
+		m.emit(*event)
+	}
+}
+
+func (m *SpreadMonitor) emit(e SpreadEvent) {
+	s := e.Sample
+	if e.Wide {
+		logger.Warn("spread above threshold", "pair", s.Pair, "bps", s.Bps, "threshold_bps", m.ThresholdBps,
+			"high", s.High, "high_exchange", s.HighExchange, "low", s.Low, "low_exchange", s.LowExchange,
+			"since", e.Since)
+	} else {
+		logger.Info("spread back below threshold", "pair", s.Pair, "bps", s.Bps, "lasted", s.Time.Sub(e.Since))
+	}
+	if m.OnEvent != nil {
+		m.OnEvent(e)
+	}
+}
+
+// sample computes the spread of pair from its fresh prices. It needs at
+// least two exchanges. mu must be held.
+func (m *SpreadMonitor) sample(pair string, now time.Time) (domain.SpreadSample, bool) {
+	s := domain.SpreadSample{Pair: pair, Time: now}
+	for exchange, p := range m.prices[pair] {
+		if now.Sub(p.Time) > m.MaxAge {
+			continue
+		}
+		if s.Exchanges == 0 || p.Price > s.High {
+			s.High, s.HighExchange = p.Price, exchange
+		}
+		if s.Exchanges == 0 || p.Price < s.Low {
+			s.Low, s.LowExchange = p.Price, exchange
+		}
+		s.Exchanges++
+	}
+	if s.Exchanges < 2 {
+		return domain.SpreadSample{}, false
+	}
+	if mid := (s.High + s.Low) / 2; mid > 0 {
+		s.Bps = (s.High - s.Low) / mid * 10000
+	}
+	return s, true
+}
+
+// Spread returns the current spread of pair and the fresh prices it was
+// computed from, sorted by exchange.
+func (m *SpreadMonitor) Spread(pair string) (domain.SpreadSample, []domain.PriceUpdate, error) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sample, ok := m.sample(pair, now)
+	if !ok {
+		return domain.SpreadSample{}, nil, fmt.Errorf("no fresh prices for %s on two or more exchanges: %w", pair, domain.ErrNotFound)
+	}
+	var prices []domain.PriceUpdate
+	for _, p := range m.prices[pair] {
+		if now.Sub(p.Time) <= m.MaxAge {
+			prices = append(prices, p)
+		}
+	}
+	sort.Slice(prices, func(i, j int) bool { return prices[i].Exchange < prices[j].Exchange })
+	return sample, prices, nil
+}
+
+// Spreads returns the current spread of every pair that has one.
+func (m *SpreadMonitor) Spreads() []domain.SpreadSample {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var out []domain.SpreadSample
+	for pair := range m.prices {
+		if s, ok := m.sample(pair, now); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// SpreadRecorder stores every pair's current spread each Interval and
+// deletes samples older than Retention.
+type SpreadRecorder struct {
+	Monitor   *SpreadMonitor
+	Store     domain.SpreadStore
+	Interval  time.Duration
+	Retention time.Duration
+}
+
+// Start records until ctx is cancelled.
+func (r *SpreadRecorder) Start(ctx context.Context) {
+	logger.Info("starting spread recorder", "interval", r.Interval, "retention", r.Retention)
+
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+	lastPrune := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("spread recorder stopped by context")
+			return
+		case <-ticker.C:
+			if err := r.Store.StoreSpreads(ctx, r.Monitor.Spreads()); err != nil {
+				logger.Error("failed to record spreads", "error", err)
+			}
+			if time.Since(lastPrune) >= time.Hour {
+				lastPrune = time.Now()
+				pruned, err := r.Store.PruneSpreads(ctx, lastPrune.Add(-r.Retention))
+				if err != nil {
+					logger.Error("failed to prune spreads", "error", err)
+				} else if pruned > 0 {
+					logger.Info("pruned spread history", "rows", pruned)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+// Scopes a key can be granted.
+const (
+	ReadPrices     = "read:prices"
+	AdminMode      = "admin:mode"
+	AdminExchanges = "admin:exchanges"
+	AdminKeys      = "admin:keys"
+	ManageAlerts   = "manage:alerts"
+)
+
+var Scopes = []string{ReadPrices, AdminMode, AdminExchanges, AdminKeys, ManageAlerts}
+
+// secretPrefix marks marketflow keys so they are easy to spot in logs and
+// secret scanners.
+const secretPrefix = "mf_"
+
+// Service issues and checks API keys and records admin actions.
+type Service struct {
+	Keys  domain.KeyStore
+	Audit domain.AuditLog
+}
+
+func NewService(keys domain.KeyStore, audit domain.AuditLog) *Service {
+	return &Service{Keys: keys, Audit: audit}
+}
+
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authenticate returns the key whose secret is given, or ErrUnauthenticated.
+func (s *Service) Authenticate(ctx context.Context, secret string) (domain.APIKey, error) {
+	if secret == "" {
+		return domain.APIKey{}, fmt.Errorf("missing api key: %w", domain.ErrUnauthenticated)
+	}
+	key, err := s.Keys.FindKey(ctx, Hash(secret))
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.APIKey{}, fmt.Errorf("unknown api key: %w", domain.ErrUnauthenticated)
+	}
+	return key, err
+}
+
+// Create stores a new key and returns it with its secret, which is not
+// kept anywhere and cannot be recovered later.
+func (s *Service) Create(ctx context.Context, name string, scopes []string) (domain.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return domain.APIKey{}, "", fmt.Errorf("key name must not be empty: %w", domain.ErrInvalidArgument)
+	}
+	if len(scopes) == 0 {
+		return domain.APIKey{}, "", fmt.Errorf("at least one scope is required: %w", domain.ErrInvalidArgument)
+	}
+	for _, scope := range scopes {
+		if !validScope(scope) {
+			return domain.APIKey{}, "", fmt.Errorf("unknown scope %q, want one of %s: %w", scope, strings.Join(Scopes, ", "), domain.ErrInvalidArgument)
+		}
+	}
+
+	id, err := randomHex(8)
+	if err != nil {
+		return domain.APIKey{}, "", err
+	}
+	secret, err := randomHex(24)
+	if err != nil {
+		return domain.APIKey{}, "", err
+	}
+	secret = secretPrefix + secret
+
+	key := domain.APIKey{
+		ID:        id,
+		Name:      name,
+		Hash:      Hash(secret),
+		Scopes:    scopes,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.Keys.CreateKey(ctx, key); err != nil {
+		return domain.APIKey{}, "", err
+	}
+	return key, secret, nil
+}
+
+// Record writes an admin action to the log and the audit trail. A failure
+// to store the entry is logged but does not undo the action.
+func (s *Service) Record(ctx context.Context, entry domain.AuditEntry) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	logger.Info("audit", "key_id", entry.KeyID, "key_name", entry.KeyName, "action", entry.Action,
+		"target", entry.Target, "request_id", entry.RequestID, "error", entry.Error)
+	if err := s.Audit.RecordAudit(ctx, entry); err != nil {
+		logger.Error("failed to record audit entry", "action", entry.Action, "error", err)
+	}
+}
+
+func validScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
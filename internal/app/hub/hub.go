@@ -0,0 +1,192 @@
+package hub
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+const (
+	streamBuffer = 64
+
+	// maxQueued caps the ticks queued for one SubscribeAll subscriber, about
+	// 6 MB. A consumer that far behind is stuck, and holding on to more
+	// would only run the process out of memory.
+	maxQueued = 100000
+
+	// dropLogEvery and backlogLogEvery space out the warnings about a
+	// subscriber that falls behind.
+	dropLogEvery    = 1000
+	backlogLogEvery = 10000
+)
+
+// Hub fans live ticks out to subscribers. Publish never blocks. Stream
+// subscribers (Subscribe) that fall behind miss ticks rather than slowing
+// the feed; the drops are counted and logged. Internal consumers
+// (SubscribeAll) get every tick in order: ticks they have not taken yet are
+// queued for them, up to maxQueued. Past that, new ticks are dropped and
+// counted, and an error is logged, until the consumer catches up.
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+// Subscription receives the ticks of one exchange and pair; an empty
+// Exchange or Pair matches every one.
+type Subscription struct {
+	Name     string
+	Exchange string
+	Pair     string
+	Updates  <-chan domain.PriceUpdate
+
+	updates  chan domain.PriceUpdate
+	lossless bool
+	dropped  atomic.Int64
+
+	// Lossless subscriptions only: ticks waiting for room in updates, moved
+	// over by pump.
+	mu    sync.Mutex
+	queue []domain.PriceUpdate
+	wake  chan struct{}
+	done  chan struct{}
+}
+
+func New() *Hub {
+	return &Hub{subs: make(map[*Subscription]struct{})}
+}
+
+func (h *Hub) Publish(update domain.PriceUpdate) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for sub := range h.subs {
+		if (sub.Exchange != "" && sub.Exchange != update.Exchange) || (sub.Pair != "" && sub.Pair != update.Pair) {
+			continue
+		}
+		if sub.lossless {
+			sub.enqueue(update)
+			continue
+		}
+		select {
+		case sub.updates <- update:
+		default:
+			if n := sub.dropped.Add(1); n == 1 || n%dropLogEvery == 0 {
+				logger.Warn("stream subscriber is falling behind, dropping ticks", "subscriber", sub.Name, "dropped", n)
+			}
+		}
+	}
+}
+
+// Subscribe starts a stream subscription for a client; name identifies it
+// in logs.
+func (h *Hub) Subscribe(name, exchange, pair string) *Subscription {
+	updates := make(chan domain.PriceUpdate, streamBuffer)
+	sub := &Subscription{Name: name, Exchange: exchange, Pair: pair, Updates: updates, updates: updates}
+	h.add(sub)
+	return sub
+}
+
+// SubscribeAll starts a subscription to every tick that loses none of them,
+// for consumers inside the process whose state depends on seeing each one.
+func (h *Hub) SubscribeAll(name string) *Subscription {
+	updates := make(chan domain.PriceUpdate, streamBuffer)
+	sub := &Subscription{
+		Name:     name,
+		Updates:  updates,
+		updates:  updates,
+		lossless: true,
+		wake:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	go sub.pump()
+	h.add(sub)
+	return sub
+}
+
+func (h *Hub) add(sub *Subscription) {
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	delete(h.subs, sub)
+	h.mu.Unlock()
+	if sub.lossless {
+		close(sub.done)
+	}
+	if n := sub.dropped.Load(); n > 0 {
+		logger.Warn("stream subscriber missed ticks", "subscriber", sub.Name, "dropped", n)
+	}
+}
+
+// Dropped returns how many ticks the subscription has missed. For
+// SubscribeAll it stays zero unless the queue has overflowed.
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// enqueue hands update to the subscriber directly while nothing is queued,
+// so ticks keep their order, and queues it otherwise. A full queue drops it.
+func (s *Subscription) enqueue(update domain.PriceUpdate) {
+	s.mu.Lock()
+	if len(s.queue) == 0 {
+		select {
+		case s.updates <- update:
+			s.mu.Unlock()
+			return
+		default:
+		}
+	}
+	if len(s.queue) >= maxQueued {
+		s.mu.Unlock()
+		if n := s.dropped.Add(1); n == 1 || n%dropLogEvery == 0 {
+			logger.Error("subscriber queue is full, dropping ticks", "subscriber", s.Name, "queued", maxQueued, "dropped", n)
+		}
+		return
+	}
+	s.queue = append(s.queue, update)
+	if n := len(s.queue); n%backlogLogEvery == 0 {
+		logger.Warn("subscriber is falling behind, queueing ticks", "subscriber", s.Name, "queued", n)
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// pump moves queued ticks to updates until the subscription ends. A tick
+// leaves the queue only once delivered, so enqueue sees a non-empty queue
+// until then.
+func (s *Subscription) pump() {
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.wake:
+		}
+		for {
+			s.mu.Lock()
+			if len(s.queue) == 0 {
+				s.queue = nil
+				s.mu.Unlock()
+				break
+			}
+			next := s.queue[0]
+			s.mu.Unlock()
+
+			select {
+			case s.updates <- next:
+			case <-s.done:
+				return
+			}
+			s.mu.Lock()
+			s.queue = s.queue[1:]
+			s.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package hub
+
+import (
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.InitWithWriter(io.Discard, "test", "error")
+	os.Exit(m.Run())
+}
+
+func tick(i int) domain.PriceUpdate {
+	return domain.PriceUpdate{Exchange: "ex1", Pair: "BTCUSDT", Price: float64(i)}
+}
+
+func TestSubscribeAllKeepsEveryTickInOrder(t *testing.T) {
+	h := New()
+	sub := h.SubscribeAll("test")
+	defer h.Unsubscribe(sub)
+
+	const n = 10 * streamBuffer
+	for i := 0; i < n; i++ {
+		h.Publish(tick(i))
+	}
+	for i := 0; i < n; i++ {
+		select {
+		case u := <-sub.Updates:
+			if u.Price != float64(i) {
+				t.Fatalf("tick %d has price %v", i, u.Price)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("only %d of %d ticks arrived", i, n)
+		}
+	}
+	if sub.Dropped() != 0 {
+		t.Errorf("Dropped = %d", sub.Dropped())
+	}
+}
+
+func TestSubscribeCountsDrops(t *testing.T) {
+	h := New()
+	sub := h.Subscribe("test", "", "BTCUSDT")
+	other := h.Subscribe("other", "", "ETHUSDT")
+	defer h.Unsubscribe(sub)
+	defer h.Unsubscribe(other)
+
+	for i := 0; i < streamBuffer+5; i++ {
+		h.Publish(tick(i))
+	}
+	if got := sub.Dropped(); got != 5 {
+		t.Errorf("Dropped = %d, want 5", got)
+	}
+	if got := other.Dropped(); got != 0 {
+		t.Errorf("filtered subscriber Dropped = %d, want 0", got)
+	}
+	if got := len(sub.Updates); got != streamBuffer {
+		t.Errorf("%d ticks buffered, want %d", got, streamBuffer)
+	}
+}
+
+func TestUnsubscribeStopsPump(t *testing.T) {
+	h := New()
+	sub := h.SubscribeAll("test")
+	for i := 0; i < 2*streamBuffer; i++ {
+		h.Publish(tick(i))
+	}
+	h.Unsubscribe(sub)
+	h.Publish(tick(-1)) // no longer delivered, and must not block
+}
+
+func TestSubscribeAllCapsItsQueue(t *testing.T) {
+	h := New()
+	sub := h.SubscribeAll("test")
+	defer h.Unsubscribe(sub)
+
+	const n = streamBuffer + maxQueued
+	for i := 0; i < n+10; i++ {
+		h.Publish(tick(i))
+	}
+	if got := sub.Dropped(); got != 10 {
+		t.Errorf("Dropped = %d, want the 10 ticks past the cap", got)
+	}
+
+	// The queued ticks still arrive in order, and once the consumer has
+	// caught up, new ones are queued again.
+	for i := 0; i < n; i++ {
+		select {
+		case u := <-sub.Updates:
+			if u.Price != float64(i) {
+				t.Fatalf("tick %d has price %v", i, u.Price)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("only %d of %d ticks arrived", i, n)
+		}
+	}
+	h.Publish(tick(-1))
+	select {
+	case u := <-sub.Updates:
+		if u.Price != -1 {
+			t.Errorf("tick after catching up has price %v, want -1", u.Price)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no tick after catching up")
+	}
+	if got := sub.Dropped(); got != 10 {
+		t.Errorf("Dropped = %d after catching up, want 10", got)
+	}
+}
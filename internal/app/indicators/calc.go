@@ -0,0 +1,170 @@
+package indicators
+
+import "math"
+
+// calc holds the running state of one indicator. push folds in a closed
+// bucket; value reads the indicator with open, if not nil, as the newest
+// bucket without changing the state, and reports false until enough
+// buckets have been pushed.
+type calc interface {
+	push(b Bucket)
+	value(open *Bucket) (Value, bool)
+}
+
+func newCalc(spec Spec) calc {
+	switch spec.Type {
+	case EMA:
+		return &ema{n: spec.Window, alpha: 2 / float64(spec.Window+1)}
+	case RSI:
+		return &rsi{n: spec.Window}
+	default:
+		return &windowCalc{kind: spec.Type, n: spec.Window}
+	}
+}
+
+// windowCalc computes the indicators that only look at the last n buckets:
+// SMA and Bollinger bands over the closes, and VWAP over the typical prices
+// (high + low + close) / 3. The feeds carry no volume, so VWAP weights every
+// bucket the same.
+type windowCalc struct {
+	kind   string
+	n      int
+	recent []Bucket // the last n closed buckets, oldest first
+}
+
+func (c *windowCalc) push(b Bucket) {
+	c.recent = append(c.recent, b)
+	if len(c.recent) > c.n {
+		c.recent = append(c.recent[:0], c.recent[len(c.recent)-c.n:]...)
+	}
+}
+
+func (c *windowCalc) value(open *Bucket) (Value, bool) {
+	window := c.recent
+	if open != nil {
+		if len(window) < c.n-1 {
+			return Value{}, false
+		}
+		window = append(window[len(window)-(c.n-1):len(window):len(window)], *open)
+	} else if len(window) < c.n {
+		return Value{}, false
+	}
+
+	var sum float64
+	for _, b := range window {
+		if c.kind == VWAP {
+			sum += (b.High + b.Low + b.Close) / 3
+		} else {
+			sum += b.Close
+		}
+	}
+	mean := sum / float64(len(window))
+	v := Value{Value: mean}
+	if c.kind == Bollinger {
+		var sq float64
+		for _, b := range window {
+			sq += (b.Close - mean) * (b.Close - mean)
+		}
+		sd := math.Sqrt(sq / float64(len(window)))
+		v.Upper, v.Lower = mean+bollingerWidth*sd, mean-bollingerWidth*sd
+	}
+	return v, true
+}
+
+// ema is seeded with the SMA of its first n closes.
+type ema struct {
+	n     int
+	alpha float64
+	seed  []float64
+	ema   float64
+}
+
+func (c *ema) push(b Bucket) {
+	if len(c.seed) < c.n {
+		c.seed = append(c.seed, b.Close)
+		if len(c.seed) == c.n {
+			c.ema = mean(c.seed)
+		}
+		return
+	}
+	c.ema = c.alpha*b.Close + (1-c.alpha)*c.ema
+}
+
+func (c *ema) value(open *Bucket) (Value, bool) {
+	if len(c.seed) < c.n {
+		return Value{}, false
+	}
+	if open == nil {
+		return Value{Value: c.ema}, true
+	}
+	return Value{Value: c.alpha*open.Close + (1-c.alpha)*c.ema}, true
+}
+
+// rsi uses Wilder's smoothing, seeded with the mean gain and loss of its
+// first n changes.
+type rsi struct {
+	n        int
+	prev     float64
+	seen     int // closes pushed
+	avgGain  float64
+	avgLoss  float64
+	seedGain float64
+	seedLoss float64
+}
+
+func (c *rsi) push(b Bucket) {
+	c.seen++
+	if c.seen == 1 {
+		c.prev = b.Close
+		return
+	}
+	gain, loss := change(c.prev, b.Close)
+	c.prev = b.Close
+	switch {
+	case c.seen <= c.n:
+		c.seedGain += gain
+		c.seedLoss += loss
+	case c.seen == c.n+1:
+		c.avgGain = (c.seedGain + gain) / float64(c.n)
+		c.avgLoss = (c.seedLoss + loss) / float64(c.n)
+	default:
+		c.avgGain, c.avgLoss = c.smooth(gain, loss)
+	}
+}
+
+func (c *rsi) value(open *Bucket) (Value, bool) {
+	if c.seen < c.n+1 {
+		return Value{}, false
+	}
+	avgGain, avgLoss := c.avgGain, c.avgLoss
+	if open != nil {
+		avgGain, avgLoss = c.smooth(change(c.prev, open.Close))
+	}
+	switch {
+	case avgLoss == 0 && avgGain == 0:
+		return Value{Value: 50}, true
+	case avgLoss == 0:
+		return Value{Value: 100}, true
+	}
+	return Value{Value: 100 - 100/(1+avgGain/avgLoss)}, true
+}
+
+func (c *rsi) smooth(gain, loss float64) (float64, float64) {
+	n := float64(c.n)
+	return (c.avgGain*(n-1) + gain) / n, (c.avgLoss*(n-1) + loss) / n
+}
+
+func change(from, to float64) (gain, loss float64) {
+	if to > from {
+		return to - from, 0
+	}
+	return 0, from - to
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
@@ -0,0 +1,147 @@
+// Package indicators computes technical indicators over price buckets built
+// from the stored windows, and keeps the most requested ones up to date from
+// the live ticks.
+package indicators
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+// Indicator types.
+const (
+	SMA       = "sma"
+	EMA       = "ema"
+	VWAP      = "vwap"
+	RSI       = "rsi"
+	Bollinger = "bollinger"
+)
+
+var Types = []string{SMA, EMA, VWAP, RSI, Bollinger}
+
+// Where a value was computed from.
+const (
+	SourceStream  = "stream"  // buckets kept up to date from live ticks
+	SourceWindows = "windows" // stored windows and recent ticks
+	SourceHourly  = "hourly"  // hourly rollups and the windows stored since
+)
+
+const (
+	MaxWindow     = 500
+	MinResolution = time.Second
+	MaxResolution = 24 * time.Hour
+
+	// bollingerWidth is how many standard deviations the bands are apart
+	// from the middle.
+	bollingerWidth = 2
+)
+
+// Spec names one indicator series.
+type Spec struct {
+	Exchange   string
+	Pair       string
+	Type       string
+	Window     int
+	Resolution time.Duration
+}
+
+func (s Spec) Validate() error {
+	if !contains(Types, s.Type) {
+		return fmt.Errorf("unknown indicator type %q, want one of %s: %w", s.Type, strings.Join(Types, ", "), domain.ErrInvalidArgument)
+	}
+	if s.Window < 1 || s.Window > MaxWindow {
+		return fmt.Errorf("window must be between 1 and %d, got %d: %w", MaxWindow, s.Window, domain.ErrInvalidArgument)
+	}
+	if s.Type == RSI && s.Window < 2 {
+		return fmt.Errorf("rsi needs a window of at least 2: %w", domain.ErrInvalidArgument)
+	}
+	if s.Resolution < MinResolution || s.Resolution > MaxResolution || s.Resolution%time.Second != 0 {
+		return fmt.Errorf("resolution must be whole seconds between %s and %s, got %s: %w", MinResolution, MaxResolution, s.Resolution, domain.ErrInvalidArgument)
+	}
+	return nil
+}
+
+// lookback is how many buckets are loaded to compute s from scratch. The
+// recursive indicators get three windows so the seed has faded.
+func (s Spec) lookback() int {
+	switch s.Type {
+	case EMA:
+		return 3 * s.Window
+	case RSI:
+		return 3*s.Window + 1
+	default:
+		return s.Window
+	}
+}
+
+// Bucket is the prices of one resolution step. Close is the last price, or
+// the average of the last stored window. A Filled bucket had no data and
+// repeats the previous close.
+type Bucket struct {
+	Start  time.Time
+	Close  float64
+	High   float64
+	Low    float64
+	Filled bool
+}
+
+// Value is an indicator at the bucket starting at Time. Upper and Lower are
+// only set for Bollinger bands, where Value is the middle band.
+type Value struct {
+	Time    time.Time
+	Value   float64
+	Upper   float64
+	Lower   float64
+	Buckets int // buckets in the window
+	Missing int // of those, filled from the previous close
+	Source  string
+}
+
+// buckets groups stats, oldest first, into the buckets of res from from
+// until now. Stored windows are stamped when they end, so each counts in
+// the bucket it mostly covers. Empty buckets after the first with data are
+// filled; those before it are left out. The bucket holding now is returned
+// as open, or nil while it has no data.
+func buckets(stats []domain.PriceStats, res time.Duration, from, now time.Time) (closed []Bucket, open *Bucket) {
+	byStart := make(map[time.Time]*Bucket)
+	for _, s := range stats {
+		start := s.Timestamp.Add(-time.Nanosecond).Truncate(res)
+		if start.Before(from) {
+			continue
+		}
+		b := byStart[start]
+		if b == nil {
+			byStart[start] = &Bucket{Start: start, Close: s.Average, High: s.Max, Low: s.Min}
+			continue
+		}
+		b.Close = s.Average
+		b.High = max(b.High, s.Max)
+		b.Low = min(b.Low, s.Min)
+	}
+
+	current := now.Truncate(res)
+	for t := from; t.Before(current); t = t.Add(res) {
+		if b, ok := byStart[t]; ok {
+			closed = append(closed, *b)
+		} else if len(closed) > 0 {
+			closed = append(closed, filled(t, closed[len(closed)-1].Close))
+		}
+	}
+	return closed, byStart[current]
+}
+
+func filled(start time.Time, price float64) Bucket {
+	return Bucket{Start: start, Close: price, High: price, Low: price, Filled: true}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,309 @@
+package indicators
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"marketflow/internal/app/query"
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+// Service answers indicator requests from storage. A spec requested
+// TrackAfter times within TrackTTL is then kept up to date from the live
+// ticks and answered from memory, until it goes unrequested for TrackTTL.
+// At most MaxTracked specs are tracked at once.
+type Service struct {
+	query      *query.Service
+	hourly     domain.RollupRepository // nil if storage keeps no rollups
+	TrackAfter int
+	TrackTTL   time.Duration
+	MaxTracked int
+
+	mu      sync.Mutex
+	tracked map[Spec]*tracker
+	byPair  map[domain.PairKey][]*tracker
+	hits    map[Spec]*hits
+}
+
+type hits struct {
+	count int
+	first time.Time
+}
+
+func NewService(q *query.Service, hourly domain.RollupRepository, trackAfter int, trackTTL time.Duration, maxTracked int) *Service {
+	return &Service{
+		query:      q,
+		hourly:     hourly,
+		TrackAfter: trackAfter,
+		TrackTTL:   trackTTL,
+		MaxTracked: maxTracked,
+		tracked:    make(map[Spec]*tracker),
+		byPair:     make(map[domain.PairKey][]*tracker),
+		hits:       make(map[Spec]*hits),
+	}
+}
+
+// Get returns the latest value of spec.
+func (s *Service) Get(ctx context.Context, spec Spec) (Value, error) {
+	if err := spec.Validate(); err != nil {
+		return Value{}, err
+	}
+	now := time.Now()
+
+	s.mu.Lock()
+	if t := s.tracked[spec]; t != nil {
+		t.lastUsed = now
+		t.advance(now.Truncate(spec.Resolution))
+		v, ok := t.value()
+		s.mu.Unlock()
+		if ok {
+			return v, nil
+		}
+	} else {
+		s.mu.Unlock()
+	}
+
+	t, err := s.load(ctx, spec, now)
+	if err != nil {
+		return Value{}, err
+	}
+	v, ok := t.value()
+	if !ok {
+		return Value{}, fmt.Errorf("not enough data for %s of %s:%s: need %d buckets of %s, have %d: %w",
+			spec.Type, spec.Exchange, spec.Pair, spec.Window, spec.Resolution, t.closed, domain.ErrNotFound)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.hot(spec, now) {
+		t.lastUsed = now
+		s.track(t)
+	}
+	return v, nil
+}
+
+// load computes spec from scratch. Whole-hour resolutions use the hourly
+// rollups when storage has them, so they reach back past the raw windows.
+func (s *Service) load(ctx context.Context, spec Spec, now time.Time) (*tracker, error) {
+	lookback := spec.lookback()
+	from := now.Truncate(spec.Resolution).Add(-time.Duration(lookback) * spec.Resolution)
+	// A period that only changes with the lookback keeps the query cache
+	// useful; rows before from are dropped when bucketing.
+	period := time.Duration(lookback+1) * spec.Resolution
+
+	var stats []domain.PriceStats
+	var err error
+	source := SourceWindows
+	if s.hourly != nil && spec.Resolution%time.Hour == 0 {
+		stats, err = s.hourly.GetHourly(ctx, spec.Exchange, spec.Pair, from)
+		if err != nil {
+			return nil, err
+		}
+		if len(stats) > 0 {
+			source = SourceHourly
+			last := stats[len(stats)-1].Timestamp
+			recent, err := s.query.Period(ctx, spec.Exchange, spec.Pair, now.Sub(last))
+			if err != nil && !errors.Is(err, domain.ErrNotFound) {
+				return nil, err
+			}
+			for _, r := range recent {
+				if r.Timestamp.After(last) {
+					stats = append(stats, r)
+				}
+			}
+		}
+	}
+	if source == SourceWindows {
+		if stats, err = s.query.Period(ctx, spec.Exchange, spec.Pair, period); err != nil {
+			return nil, err
+		}
+	}
+
+	closed, open := buckets(stats, spec.Resolution, from, now)
+	t := newTracker(spec, source)
+	for _, b := range closed {
+		t.close(b)
+	}
+	t.open = open
+	if len(closed) == 0 && open != nil {
+		t.next = open.Start
+	}
+	return t, nil
+}
+
+// hot counts a computed request for spec and reports whether it should now
+// be tracked. mu must be held.
+func (s *Service) hot(spec Spec, now time.Time) bool {
+	h := s.hits[spec]
+	if h == nil || now.Sub(h.first) > s.TrackTTL {
+		h = &hits{first: now}
+		s.hits[spec] = h
+	}
+	h.count++
+	return h.count >= s.TrackAfter && len(s.tracked) < s.MaxTracked
+}
+
+// track starts keeping t up to date. mu must be held.
+func (s *Service) track(t *tracker) {
+	t.source = SourceStream
+	s.untrack(t.spec)
+	s.tracked[t.spec] = t
+	key := domain.PairKey{Exchange: t.spec.Exchange, Pair: t.spec.Pair}
+	s.byPair[key] = append(s.byPair[key], t)
+	delete(s.hits, t.spec)
+	logger.Info("tracking indicator", "exchange", t.spec.Exchange, "pair", t.spec.Pair, "type", t.spec.Type,
+		"window", t.spec.Window, "resolution", t.spec.Resolution)
+}
+
+// Run feeds updates to the tracked indicators until ctx is cancelled or
+// updates is closed, and drops those that go unrequested.
+func (s *Service) Run(ctx context.Context, updates <-chan domain.PriceUpdate) {
+	logger.Info("starting indicator tracker", "track_after", s.TrackAfter, "track_ttl", s.TrackTTL, "max_tracked", s.MaxTracked)
+
+	expire := time.NewTicker(time.Minute)
+	defer expire.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			s.observe(update)
+		case now := <-expire.C:
+			s.expire(now)
+		}
+	}
+}
+
+func (s *Service) observe(update domain.PriceUpdate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.byPair[domain.PairKey{Exchange: update.Exchange, Pair: update.Pair}] {
+		t.observe(update)
+	}
+}
+
+func (s *Service) expire(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for spec, t := range s.tracked {
+		if now.Sub(t.lastUsed) > s.TrackTTL {
+			s.untrack(spec)
+			logger.Info("stopped tracking indicator", "exchange", spec.Exchange, "pair", spec.Pair, "type", spec.Type)
+		}
+	}
+	for spec, h := range s.hits {
+		if now.Sub(h.first) > s.TrackTTL {
+			delete(s.hits, spec)
+		}
+	}
+}
+
+// untrack stops keeping spec up to date, if it was. mu must be held.
+func (s *Service) untrack(spec Spec) {
+	t := s.tracked[spec]
+	if t == nil {
+		return
+	}
+	delete(s.tracked, spec)
+	key := domain.PairKey{Exchange: spec.Exchange, Pair: spec.Pair}
+	list := s.byPair[key]
+	for i, other := range list {
+		if other == t {
+			list = append(list[:i:i], list[i+1:]...)
+			break
+		}
+	}
+	if len(list) == 0 {
+		delete(s.byPair, key)
+	} else {
+		s.byPair[key] = list
+	}
+}
+
+// tracker is an indicator's state at its last closed bucket plus the bucket
+// still open.
+type tracker struct {
+	spec     Spec
+	source   string
+	calc     calc
+	closed   int       // buckets pushed
+	next     time.Time // start of the bucket after the last closed one
+	last     float64   // close of the last closed bucket
+	filled   []bool    // of the last Window closed buckets, oldest first
+	open     *Bucket
+	lastUsed time.Time
+}
+
+func newTracker(spec Spec, source string) *tracker {
+	return &tracker{spec: spec, source: source, calc: newCalc(spec)}
+}
+
+func (t *tracker) close(b Bucket) {
+	t.calc.push(b)
+	t.closed++
+	t.next = b.Start.Add(t.spec.Resolution)
+	t.last = b.Close
+	t.filled = append(t.filled, b.Filled)
+	if len(t.filled) > t.spec.Window {
+		t.filled = t.filled[1:]
+	}
+}
+
+// advance closes the open bucket and fills empty ones until the bucket
+// starting at start.
+func (t *tracker) advance(start time.Time) {
+	if t.open != nil && t.open.Start.Before(start) {
+		t.close(*t.open)
+		t.open = nil
+	}
+	for t.closed > 0 && t.next.Before(start) {
+		t.close(filled(t.next, t.last))
+	}
+}
+
+func (t *tracker) observe(u domain.PriceUpdate) {
+	start := u.Time.Truncate(t.spec.Resolution)
+	if start.Before(t.next) {
+		return // belongs to a closed bucket
+	}
+	t.advance(start)
+	if t.open == nil {
+		t.open = &Bucket{Start: start, Close: u.Price, High: u.Price, Low: u.Price}
+		return
+	}
+	t.open.Close = u.Price
+	t.open.High = max(t.open.High, u.Price)
+	t.open.Low = min(t.open.Low, u.Price)
+}
+
+func (t *tracker) value() (Value, bool) {
+	v, ok := t.calc.value(t.open)
+	if !ok {
+		return Value{}, false
+	}
+	v.Source = t.source
+	v.Buckets = min(t.spec.Window, t.closed)
+	filled := t.filled
+	if t.open != nil {
+		v.Time = t.open.Start
+		if len(filled) == t.spec.Window {
+			filled = filled[1:]
+		}
+		v.Buckets = len(filled) + 1
+	} else {
+		v.Time = t.next.Add(-t.spec.Resolution)
+	}
+	for _, f := range filled {
+		if f {
+			v.Missing++
+		}
+	}
+	return v, true
+}
@@ -0,0 +1,104 @@
+package leader
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+// Elector campaigns for a lease shared by every instance. The holder is the
+// leader; the others retry every third of the lease, so a leader that dies
+// is replaced within about one and a third leases.
+type Elector struct {
+	Lock  domain.LeaseLock
+	Lease time.Duration
+
+	leader atomic.Bool
+}
+
+func NewElector(lock domain.LeaseLock, lease time.Duration) *Elector {
+	return &Elector{Lock: lock, Lease: lease}
+}
+
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run campaigns until ctx is cancelled. Each time the lease is won, lead runs
+// with a context that is cancelled when the lease is lost, and Run waits for
+// it to return before campaigning again. The lease is released on exit.
+func (e *Elector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	interval := e.Lease / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logger.Info("campaigning for leadership", "lease", e.Lease)
+	for {
+		won, err := e.Lock.Acquire(ctx, e.Lease)
+		if err != nil {
+			logger.Warn("leader election failed", "error", err)
+		}
+		if won {
+			e.term(ctx, ticker, lead)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// term leads until the lease can no longer be renewed or ctx is cancelled.
+// A renewal that fails on an error is retried while the lease is sure to
+// outlast the next attempt; a renewal that finds another owner ends the term
+// at once.
+func (e *Elector) term(ctx context.Context, ticker *time.Ticker, lead func(ctx context.Context)) {
+	logger.Info("elected leader")
+	e.leader.Store(true)
+	defer e.leader.Store(false)
+
+	termCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lead(termCtx)
+	}()
+	stepDown := func() {
+		cancel()
+		<-done
+	}
+
+	interval := e.Lease / 3
+	renewed := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			stepDown()
+			releaseCtx, releaseCancel := context.WithTimeout(context.Background(), e.Lease/3)
+			defer releaseCancel()
+			if err := e.Lock.Release(releaseCtx); err != nil {
+				logger.Warn("failed to release leadership", "error", err)
+			}
+			logger.Info("leadership released")
+			return
+		case <-ticker.C:
+		}
+
+		ok, err := e.Lock.Renew(ctx, e.Lease)
+		switch {
+		case err != nil && time.Since(renewed)+interval < e.Lease:
+			logger.Warn("failed to renew leadership, retrying", "error", err)
+		case err != nil || !ok:
+			logger.Warn("leadership lost", "error", err)
+			stepDown()
+			return
+		default:
+			renewed = time.Now()
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package maintenance
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+// CacheJob removes cached latest prices whose tick is older than
+// StaleAfter, so a pair that stopped trading does not keep serving an old
+// price. Fresh prices are never touched.
+type CacheJob struct {
+	Cache      domain.CacheCleaner
+	StaleAfter time.Duration
+	Interval   time.Duration
+
+	mu   sync.Mutex
+	last domain.CacheCleanupReport
+	at   time.Time
+}
+
+func NewCacheJob(cache domain.CacheCleaner, staleAfter, interval time.Duration) *CacheJob {
+	return &CacheJob{
+		Cache:      cache,
+		StaleAfter: staleAfter,
+		Interval:   interval,
+	}
+}
+
+// Start runs a cleanup every Interval until ctx is cancelled.
+func (j *CacheJob) Start(ctx context.Context) {
+	logger.Info("starting cache maintenance", "interval", j.Interval, "stale_after", j.StaleAfter)
+
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("cache maintenance stopped by context")
+			return
+		case <-ticker.C:
+			j.RunOnce(ctx)
+		}
+	}
+}
+
+func (j *CacheJob) RunOnce(ctx context.Context) (domain.CacheCleanupReport, error) {
+	report, err := j.Cache.CleanStale(ctx, j.StaleAfter)
+	if err != nil {
+		logger.Error("cache maintenance failed", "error", err,
+			"checked", report.Checked, "removed", report.Removed)
+		return report, err
+	}
+
+	j.mu.Lock()
+	j.last, j.at = report, time.Now()
+	j.mu.Unlock()
+
+	logger.Info("cache maintenance report", "checked", report.Checked, "removed", report.Removed)
+	return report, nil
+}
+
+// Last returns the most recent successful report and when it finished.
+func (j *CacheJob) Last() (domain.CacheCleanupReport, time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.last, j.at
+}
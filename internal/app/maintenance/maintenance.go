@@ -0,0 +1,80 @@
+package maintenance
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+type Job struct {
+	Store    domain.StorageMaintainer
+	Policy   domain.RetentionPolicy
+	Interval time.Duration
+
+	mu   sync.Mutex
+	last domain.MaintenanceReport
+	at   time.Time
+}
+
+func NewJob(store domain.StorageMaintainer, policy domain.RetentionPolicy, interval time.Duration) *Job {
+	return &Job{
+		Store:    store,
+		Policy:   policy,
+		Interval: interval,
+	}
+}
+
+// Start runs maintenance immediately and then every Interval until ctx is
+// cancelled.
+func (j *Job) Start(ctx context.Context) {
+	logger.Info("starting storage maintenance", "interval", j.Interval,
+		"raw_retention", j.Policy.Raw, "hourly_retention", j.Policy.Hourly)
+
+	j.RunOnce(ctx)
+
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("storage maintenance stopped by context")
+			return
+		case <-ticker.C:
+			j.RunOnce(ctx)
+		}
+	}
+}
+
+func (j *Job) RunOnce(ctx context.Context) (domain.MaintenanceReport, error) {
+	report, err := j.Store.Maintain(ctx, j.Policy)
+	if err != nil {
+		logger.Error("storage maintenance failed", "error", err,
+			"created", strings.Join(report.CreatedPartitions, ","),
+			"dropped", strings.Join(report.DroppedPartitions, ","))
+		return report, err
+	}
+
+	j.mu.Lock()
+	j.last, j.at = report, time.Now()
+	j.mu.Unlock()
+
+	if len(report.CreatedPartitions) > 0 || len(report.DroppedPartitions) > 0 || report.PurgedRows > 0 {
+		logger.Info("storage maintenance report",
+			"created", strings.Join(report.CreatedPartitions, ","),
+			"dropped", strings.Join(report.DroppedPartitions, ","),
+			"rolled_up_rows", report.RolledUpRows,
+			"purged_rows", report.PurgedRows)
+	}
+	return report, nil
+}
+
+// Last returns the most recent successful report and when it finished.
+func (j *Job) Last() (domain.MaintenanceReport, time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.last, j.at
+}
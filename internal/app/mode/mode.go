@@ -2,7 +2,8 @@ package mode
 
 import (
 	"context"
-	"errors"
+	"fmt"
+	"strings"
 	"sync"
 
 	"marketflow/internal/adapters/exchange"
@@ -21,15 +22,20 @@ const (
 type Manager struct {
 	mu         sync.Mutex
 	mode       Mode
-	clients    []domain.ExchangeClient
+	clients    map[string]domain.ExchangeClient
+	exchanges  []config.Exchange
+	out        chan<- domain.PriceUpdate
+	ctx        context.Context
 	cancelFunc context.CancelFunc
 	cfg        *config.Config
+	standby    bool
 }
 
 func NewManager(cfg *config.Config) *Manager {
 	return &Manager{
-		mode: Test,
-		cfg:  cfg,
+		mode:      Test,
+		exchanges: cfg.Exchanges,
+		cfg:       cfg,
 	}
 }
 
@@ -37,67 +43,175 @@ func (m *Manager) Start(out chan<- domain.PriceUpdate, mode Mode) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if mode != Test && mode != Live {
+		return fmt.Errorf("invalid mode %q: %w", mode, domain.ErrInvalidArgument)
+	}
+	if m.standby {
+		return fmt.Errorf("instance is a follower, change the mode on the leader: %w", domain.ErrConflict)
+	}
+
 	if m.mode == mode {
 		logger.Warn("mode already set, restarting clients", "mode", mode)
-		if m.cancelFunc != nil {
-			m.cancelFunc()
-			for _, client := range m.clients {
-				client.Stop()
-			}
-		}
-	} else {
-		if m.cancelFunc != nil {
-			logger.Info("stopping previous mode")
-			m.cancelFunc()
-			for _, client := range m.clients {
-				client.Stop()
-			}
-		}
+	} else if m.cancelFunc != nil {
+		logger.Info("stopping previous mode")
 	}
+	m.stopClients()
 
 	ctx, cancel := context.WithCancel(context.Background())
+	m.ctx = ctx
 	m.cancelFunc = cancel
 	m.mode = mode
+	m.out = out
 
-	m.clients = nil
+	m.clients = make(map[string]domain.ExchangeClient)
 	switch mode {
 	case Test:
-		m.clients = []domain.ExchangeClient{
-			exchange.NewTestGenerator("ex1"),
-			exchange.NewTestGenerator("ex2"),
-			exchange.NewTestGenerator("ex3"),
+		for _, name := range []string{"ex1", "ex2", "ex3"} {
+			m.startClient(name, exchange.NewTestGenerator(name, m.cfg.Pairs, m.cfg.Ingest.TestInterval))
 		}
 	case Live:
-		for _, ex := range m.cfg.Exchanges {
-			m.clients = append(m.clients, exchange.NewTCPClient(ctx, ex.Name, ex.Address))
+		for _, ex := range m.exchanges {
+			m.startClient(ex.Name, exchange.NewTCPClient(ctx, ex.Name, ex.Address, m.cfg.Ingest.DialTimeout, m.cfg.Ingest.ReconnectDelay))
 		}
-	default:
-		return errors.New("invalid mode")
 	}
 
-	for _, client := range m.clients {
-		go func(c domain.ExchangeClient) {
-			if err := c.Start(ctx, out); err != nil {
-				logger.Error("failed to start client", "client", c, "error", err)
-			}
-		}(client)
+	logger.Info("started mode", "mode", mode)
+	return nil
+}
+
+// UpdateExchanges replaces the live exchange list. In live mode only the
+// clients whose name or address changed are restarted.
+func (m *Manager) UpdateExchanges(exchanges []config.Exchange) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old := make(map[string]string, len(m.exchanges))
+	for _, ex := range m.exchanges {
+		old[ex.Name] = ex.Address
 	}
+	m.exchanges = exchanges
 
-	logger.Info("started mode", "mode", mode)
+	if m.mode != Live || m.cancelFunc == nil {
+		logger.Info("updated exchange list", "count", len(exchanges), "mode", m.mode)
+		return
+	}
+
+	keep := make(map[string]bool, len(exchanges))
+	for _, ex := range exchanges {
+		keep[ex.Name] = true
+		if addr, ok := old[ex.Name]; ok && addr == ex.Address {
+			continue
+		}
+		if client, ok := m.clients[ex.Name]; ok {
+			logger.Info("restarting exchange client", "exchange", ex.Name, "addr", ex.Address)
+			client.Stop()
+		} else {
+			logger.Info("adding exchange client", "exchange", ex.Name, "addr", ex.Address)
+		}
+		m.startClient(ex.Name, exchange.NewTCPClient(m.ctx, ex.Name, ex.Address, m.cfg.Ingest.DialTimeout, m.cfg.Ingest.ReconnectDelay))
+	}
+
+	for name, client := range m.clients {
+		if !keep[name] {
+			logger.Info("removing exchange client", "exchange", name)
+			client.Stop()
+			delete(m.clients, name)
+		}
+	}
+}
+
+// ExchangeState is a live exchange and whether a client is running for it.
+type ExchangeState struct {
+	config.Exchange
+	Active bool
+}
+
+// Exchanges lists the live exchanges in order. Clients only run in live
+// mode.
+func (m *Manager) Exchanges() []ExchangeState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]ExchangeState, len(m.exchanges))
+	for i, ex := range m.exchanges {
+		_, running := m.clients[ex.Name]
+		out[i] = ExchangeState{Exchange: ex, Active: m.mode == Live && running}
+	}
+	return out
+}
+
+// SetExchanges validates exchanges and applies them as UpdateExchanges
+// does. A follower refuses, like Start.
+func (m *Manager) SetExchanges(exchanges []config.Exchange) error {
+	if problems := config.ValidateExchanges(exchanges); len(problems) > 0 {
+		return fmt.Errorf("%s: %w", strings.Join(problems, "; "), domain.ErrInvalidArgument)
+	}
+	m.mu.Lock()
+	standby := m.standby
+	m.mu.Unlock()
+	if standby {
+		return fmt.Errorf("instance is a follower, change the exchanges on the leader: %w", domain.ErrConflict)
+	}
+	m.UpdateExchanges(exchanges)
 	return nil
 }
 
+func (m *Manager) Mode() Mode {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.mode
+}
+
 func (m *Manager) Stop() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	if m.cancelFunc != nil {
 		logger.Info("stopping mode", "mode", m.mode)
-		m.cancelFunc()
-		for _, client := range m.clients {
-			client.Stop()
-		}
+		m.stopClients()
 		m.cancelFunc = nil
-		m.clients = nil
 	}
 }
+
+// Standby stops the clients and makes Start fail with ErrConflict until
+// Resume. Followers under leader election stand by.
+func (m *Manager) Standby() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.standby = true
+	if m.cancelFunc != nil {
+		logger.Info("standing by", "mode", m.mode)
+		m.stopClients()
+		m.cancelFunc = nil
+	}
+}
+
+// Resume leaves standby and starts the clients of the current mode.
+func (m *Manager) Resume(out chan<- domain.PriceUpdate) error {
+	m.mu.Lock()
+	m.standby = false
+	mode := m.mode
+	m.mu.Unlock()
+	return m.Start(out, mode)
+}
+
+func (m *Manager) startClient(name string, client domain.ExchangeClient) {
+	m.clients[name] = client
+	ctx, out := m.ctx, m.out
+	go func(c domain.ExchangeClient) {
+		if err := c.Start(ctx, out); err != nil {
+			logger.Error("failed to start client", "client", name, "error", err)
+		}
+	}(client)
+}
+
+func (m *Manager) stopClients() {
+	if m.cancelFunc != nil {
+		m.cancelFunc()
+	}
+	for _, client := range m.clients {
+		client.Stop()
+	}
+	m.clients = nil
+}
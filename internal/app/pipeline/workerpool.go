@@ -12,6 +12,8 @@ type Worker struct {
 	Input  <-chan domain.PriceUpdate
 	Cache  domain.Cache
 	Output chan<- domain.PriceUpdate
+	// Observe, if set, sees every update after it is cached. It must not block.
+	Observe func(domain.PriceUpdate)
 }
 
 func (w *Worker) Start(ctx context.Context) {
@@ -21,6 +23,9 @@ func (w *Worker) Start(ctx context.Context) {
 			if err != nil {
 				logger.Error("cache error", "worker", w.ID, "error", err)
 			}
+			if w.Observe != nil {
+				w.Observe(update)
+			}
 
 			if w.Output != nil {
 				w.Output <- update
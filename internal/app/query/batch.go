@@ -0,0 +1,290 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+type Metric string
+
+const (
+	MetricLatest  Metric = "latest"
+	MetricHighest Metric = "highest"
+	MetricLowest  Metric = "lowest"
+	MetricAverage Metric = "average"
+)
+
+// Item is one lookup of a batch. Period is ignored for latest prices.
+type Item struct {
+	Exchange string
+	Pair     string
+	Metric   Metric
+	Period   time.Duration
+}
+
+// Result answers the item at the same index. Time is zero for averages, and
+// Source is only set for latest prices.
+type Result struct {
+	Price  float64
+	Time   time.Time
+	Source string
+	Err    error
+}
+
+// Batch answers many items with as few round trips as the stores allow:
+// latest prices come from one cache read and one storage query for the
+// misses, and period metrics from one storage query per distinct period.
+// A failure is reported on the items it affects only.
+func (s *Service) Batch(ctx context.Context, items []Item) []Result {
+	var latestKeys []domain.PairKey
+	periodKeys := make(map[time.Duration][]domain.PairKey)
+	seenLatest := make(map[domain.PairKey]bool)
+	type periodKey struct {
+		key    domain.PairKey
+		period time.Duration
+	}
+	seenPeriod := make(map[periodKey]bool)
+	for _, item := range items {
+		k := domain.PairKey{Exchange: item.Exchange, Pair: item.Pair}
+		if item.Metric == MetricLatest {
+			if !seenLatest[k] {
+				seenLatest[k] = true
+				latestKeys = append(latestKeys, k)
+			}
+			continue
+		}
+		pk := periodKey{key: k, period: item.Period}
+		if !seenPeriod[pk] {
+			seenPeriod[pk] = true
+			periodKeys[item.Period] = append(periodKeys[item.Period], k)
+		}
+	}
+
+	latest := s.latestMany(ctx, latestKeys)
+	periods := make(map[time.Duration]map[domain.PairKey]periodResult, len(periodKeys))
+	for period, keys := range periodKeys {
+		periods[period] = s.periodMany(ctx, keys, period)
+	}
+
+	results := make([]Result, len(items))
+	for i, item := range items {
+		k := domain.PairKey{Exchange: item.Exchange, Pair: item.Pair}
+		if item.Metric == MetricLatest {
+			r := latest[k]
+			results[i] = Result{Price: r.update.Price, Time: r.update.Time, Source: r.source, Err: r.err}
+			continue
+		}
+
+		r := periods[item.Period][k]
+		if r.err != nil {
+			results[i] = Result{Err: r.err}
+			continue
+		}
+		switch item.Metric {
+		case MetricHighest:
+			results[i].Price, results[i].Time = Highest(r.stats)
+		case MetricLowest:
+			results[i].Price, results[i].Time = Lowest(r.stats)
+		case MetricAverage:
+			results[i].Price = Average(r.stats)
+		default:
+			results[i].Err = fmt.Errorf("unknown metric %q: %w", item.Metric, domain.ErrInvalidArgument)
+		}
+	}
+	return results
+}
+
+type latestResult struct {
+	update domain.PriceUpdate
+	source string
+	err    error
+}
+
+// latestMany is Latest for many keys; every key gets a result.
+func (s *Service) latestMany(ctx context.Context, keys []domain.PairKey) map[domain.PairKey]latestResult {
+	out := make(map[domain.PairKey]latestResult, len(keys))
+	if len(keys) == 0 {
+		return out
+	}
+
+	var missing []domain.PairKey
+	if bc, ok := s.cache.(domain.BatchCache); ok {
+		found, err := bc.GetLatestMany(ctx, keys)
+		if err != nil {
+			logger.Warn("batch cache read failed, falling back to postgres", "pairs", len(keys), "error", err)
+		}
+		for _, k := range keys {
+			if update, ok := found[k]; ok {
+				out[k] = latestResult{update: update, source: SourceCache}
+			} else {
+				missing = append(missing, k)
+			}
+		}
+	} else {
+		for _, k := range keys {
+			if update, err := s.cache.GetLatest(ctx, k.Exchange, k.Pair); err == nil {
+				out[k] = latestResult{update: update, source: SourceCache}
+			} else {
+				missing = append(missing, k)
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return out
+	}
+
+	br, ok := s.repo.(domain.BatchRepository)
+	if !ok {
+		for _, k := range missing {
+			update, source, err := s.fromStorage(ctx, k)
+			out[k] = latestResult{update: update, source: source, err: err}
+		}
+		return out
+	}
+	stored, err := br.GetLatestMany(ctx, missing)
+	for _, k := range missing {
+		switch stat, ok := stored[k]; {
+		case err != nil:
+			out[k] = latestResult{err: err}
+		case !ok:
+			out[k] = latestResult{err: fmt.Errorf("no latest price for %s:%s: %w", k.Exchange, k.Pair, domain.ErrNotFound)}
+		default:
+			out[k] = latestResult{update: statUpdate(stat), source: SourceStorage}
+		}
+	}
+	return out
+}
+
+func (s *Service) fromStorage(ctx context.Context, k domain.PairKey) (domain.PriceUpdate, string, error) {
+	stat, err := s.repo.GetLatest(ctx, k.Exchange, k.Pair)
+	if err != nil {
+		return domain.PriceUpdate{}, "", err
+	}
+	return statUpdate(stat), SourceStorage, nil
+}
+
+func statUpdate(stat domain.PriceStats) domain.PriceUpdate {
+	return domain.PriceUpdate{
+		Exchange: stat.Exchange,
+		Pair:     stat.Pair,
+		Price:    stat.Average,
+		Time:     stat.Timestamp,
+	}
+}
+
+type periodResult struct {
+	stats []domain.PriceStats
+	err   error
+}
+
+// periodMany is Period for many keys sharing a period; every key gets a
+// result. It follows statsForPeriod: ticks answer periods inside the
+// horizon, and stored windows from one grouped query the rest.
+func (s *Service) periodMany(ctx context.Context, keys []domain.PairKey, period time.Duration) map[domain.PairKey]periodResult {
+	out := make(map[domain.PairKey]periodResult, len(keys))
+	tw, hasTicks := s.cache.(domain.TickWindow)
+	hasTicks = hasTicks && tw.TickHorizon() > 0
+	since := time.Now().Add(-period)
+
+	remaining := keys
+	if hasTicks && period <= tw.TickHorizon() {
+		remaining = nil
+		for _, k := range keys {
+			ticks, err := tw.GetTicks(ctx, k.Exchange, k.Pair, since)
+			if err == nil && len(ticks) > 0 {
+				out[k] = periodResult{stats: appendTicks(nil, ticks)}
+			} else {
+				remaining = append(remaining, k)
+			}
+		}
+	}
+	if len(remaining) == 0 {
+		return out
+	}
+
+	stored, err := s.storedMany(ctx, remaining, period)
+	for _, k := range remaining {
+		if err != nil {
+			out[k] = periodResult{err: err}
+			continue
+		}
+		stats := stored[k]
+		if hasTicks {
+			stats = s.appendNewerTicks(ctx, tw, k, stats, since)
+		}
+		if len(stats) == 0 {
+			out[k] = periodResult{err: fmt.Errorf("no data for %s:%s in the last %s: %w", k.Exchange, k.Pair, period, domain.ErrNotFound)}
+			continue
+		}
+		out[k] = periodResult{stats: stats}
+	}
+	return out
+}
+
+// storedMany reads stored windows with one grouped query when the
+// repository supports it, and one query per key otherwise.
+func (s *Service) storedMany(ctx context.Context, keys []domain.PairKey, period time.Duration) (map[domain.PairKey][]domain.PriceStats, error) {
+	if br, ok := s.repo.(domain.BatchRepository); ok {
+		return br.GetByPeriodMany(ctx, keys, period)
+	}
+	out := make(map[domain.PairKey][]domain.PriceStats, len(keys))
+	for _, k := range keys {
+		stats, err := s.repo.GetByPeriod(ctx, k.Exchange, k.Pair, period)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return nil, err
+		}
+		out[k] = stats
+	}
+	return out, nil
+}
+
+// appendNewerTicks adds the ticks after the last stored window, or after
+// since without one, as one partial window: their mean, lowest and highest
+// price stamped with the last tick. Folding them keeps a burst of ticks from
+// counting as many windows next to the stored ones. A tick window that
+// fails leaves stats as they are.
+func (s *Service) appendNewerTicks(ctx context.Context, tw domain.TickWindow, k domain.PairKey, stats []domain.PriceStats, since time.Time) []domain.PriceStats {
+	if len(stats) > 0 {
+		since = stats[len(stats)-1].Timestamp
+	}
+	ticks, err := tw.GetTicks(ctx, k.Exchange, k.Pair, since)
+	if err != nil {
+		logger.Warn("tick window unavailable, using stored stats", "exchange", k.Exchange, "pair", k.Pair, "error", err)
+		return stats
+	}
+	if len(stats) > 0 {
+		for len(ticks) > 0 && !ticks[0].Time.After(since) {
+			ticks = ticks[1:]
+		}
+	}
+	if len(ticks) == 0 {
+		return stats
+	}
+	return append(stats, partialWindow(ticks))
+}
+
+// partialWindow summarizes ticks, oldest first, like the aggregator
+// summarizes a window.
+func partialWindow(ticks []domain.PriceUpdate) domain.PriceStats {
+	last := ticks[len(ticks)-1]
+	w := domain.PriceStats{
+		Exchange:  last.Exchange,
+		Pair:      last.Pair,
+		Timestamp: last.Time,
+		Min:       ticks[0].Price,
+		Max:       ticks[0].Price,
+	}
+	var sum float64
+	for _, t := range ticks {
+		sum += t.Price
+		w.Min = min(w.Min, t.Price)
+		w.Max = max(w.Max, t.Price)
+	}
+	w.Average = sum / float64(len(ticks))
+	return w
+}
@@ -0,0 +1,185 @@
+// Package query answers price reads the same way for every API the
+// instance serves.
+package query
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"marketflow/internal/app/statscache"
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+const (
+	SourceCache   = "cache"
+	SourceStorage = "storage"
+	SourceTicks   = "ticks"
+)
+
+type Service struct {
+	repo    domain.PriceRepository
+	cache   domain.Cache
+	results *statscache.Cache // nil disables result caching
+}
+
+func New(repo domain.PriceRepository, cache domain.Cache, results *statscache.Cache) *Service {
+	return &Service{repo: repo, cache: cache, results: results}
+}
+
+// Latest returns the most recent price of pair on exchange from the cache,
+// falling back to the last stored window, and which of the two answered.
+func (s *Service) Latest(ctx context.Context, exchange, pair string) (domain.PriceUpdate, string, error) {
+	update, err := s.cache.GetLatest(ctx, exchange, pair)
+	if err == nil {
+		return update, SourceCache, nil
+	}
+	logger.Warn("cache miss, falling back to postgres", "symbol", pair, "exchange", exchange)
+	return s.fromStorage(ctx, domain.PairKey{Exchange: exchange, Pair: pair})
+}
+
+// healthReporter is implemented by caches that track whether their shared
+// store is reachable while they keep serving from memory.
+type healthReporter interface {
+	Health() error
+}
+
+// CacheHealth reports whether the shared cache is reachable, for caches
+// that can keep serving without it.
+func (s *Service) CacheHealth() error {
+	if h, ok := s.cache.(healthReporter); ok {
+		return h.Health()
+	}
+	return nil
+}
+
+// Period loads the stats of the last period, oldest first. An empty result
+// is reported as not found.
+func (s *Service) Period(ctx context.Context, exchange, pair string, period time.Duration) ([]domain.PriceStats, error) {
+	var stats []domain.PriceStats
+	var err error
+	if s.results != nil {
+		key := statscache.Key{Exchange: exchange, Pair: pair, Period: period}
+		stats, err = s.results.Get(ctx, key, func(ctx context.Context) ([]domain.PriceStats, error) {
+			return s.statsForPeriod(ctx, exchange, pair, period)
+		})
+	} else {
+		stats, err = s.statsForPeriod(ctx, exchange, pair, period)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(stats) == 0 {
+		return nil, fmt.Errorf("no data for %s:%s in the last %s: %w", exchange, pair, period, domain.ErrNotFound)
+	}
+	return stats, nil
+}
+
+func (s *Service) statsForPeriod(ctx context.Context, exchange, pair string, period time.Duration) ([]domain.PriceStats, error) {
+	stats, _, err := s.samples(ctx, exchange, pair, period)
+	return stats, err
+}
+
+// samples answers periods inside the cache's tick horizon from raw ticks,
+// since a stored window may start before the period does; each tick is a
+// row whose average, min and max are its price. Longer periods use stored
+// windows plus one partial window of the ticks that arrived after the last
+// one. source says which of the two answered.
+func (s *Service) samples(ctx context.Context, exchange, pair string, period time.Duration) (stats []domain.PriceStats, source string, err error) {
+	tw, ok := s.cache.(domain.TickWindow)
+	if !ok || tw.TickHorizon() <= 0 {
+		stats, err = s.repo.GetByPeriod(ctx, exchange, pair, period)
+		return stats, SourceStorage, err
+	}
+
+	since := time.Now().Add(-period)
+	if period <= tw.TickHorizon() {
+		ticks, err := tw.GetTicks(ctx, exchange, pair, since)
+		if err != nil {
+			logger.Warn("tick window unavailable, using stored stats", "exchange", exchange, "pair", pair, "error", err)
+		} else if len(ticks) > 0 {
+			return appendTicks(nil, ticks), SourceTicks, nil
+		}
+	}
+
+	stats, err = s.repo.GetByPeriod(ctx, exchange, pair, period)
+	if err != nil {
+		return nil, SourceStorage, err
+	}
+	return s.appendNewerTicks(ctx, tw, domain.PairKey{Exchange: exchange, Pair: pair}, stats, since), SourceStorage, nil
+}
+
+func appendTicks(stats []domain.PriceStats, ticks []domain.PriceUpdate) []domain.PriceStats {
+	for _, t := range ticks {
+		stats = append(stats, domain.PriceStats{
+			Exchange:  t.Exchange,
+			Pair:      t.Pair,
+			Timestamp: t.Time,
+			Average:   t.Price,
+			Min:       t.Price,
+			Max:       t.Price,
+		})
+	}
+	return stats
+}
+
+// Highest returns the highest price in stats and when it was seen; stats
+// must not be empty.
+func Highest(stats []domain.PriceStats) (float64, time.Time) {
+	maxPrice, maxTime := stats[0].Max, stats[0].Timestamp
+	for _, stat := range stats[1:] {
+		if stat.Max > maxPrice {
+			maxPrice = stat.Max
+			maxTime = stat.Timestamp
+		}
+	}
+	return maxPrice, maxTime
+}
+
+// Lowest returns the lowest price in stats and when it was seen; stats must
+// not be empty.
+func Lowest(stats []domain.PriceStats) (float64, time.Time) {
+	minPrice, minTime := stats[0].Min, stats[0].Timestamp
+	for _, stat := range stats[1:] {
+		if stat.Min < minPrice {
+			minPrice = stat.Min
+			minTime = stat.Timestamp
+		}
+	}
+	return minPrice, minTime
+}
+
+// maxCover is the most time one row is taken to cover: the span of an
+// hourly rollup. It keeps a row after an outage from outweighing the rest.
+const maxCover = time.Hour
+
+// Average returns the mean of the rows' averages, each weighted by the time
+// it covers: the time since the row before it, at most maxCover. Hourly
+// rollups, windows and recent ticks then count for the time they span
+// rather than one each. The first row covers as long as the second. stats
+// must not be empty.
+func Average(stats []domain.PriceStats) float64 {
+	var sum, weights float64
+	for i, stat := range stats {
+		var cover time.Duration
+		switch {
+		case i > 0:
+			cover = stat.Timestamp.Sub(stats[i-1].Timestamp)
+		case len(stats) > 1:
+			cover = stats[1].Timestamp.Sub(stat.Timestamp)
+		}
+		w := max(0, min(cover, maxCover)).Seconds()
+		sum += w * stat.Average
+		weights += w
+	}
+	if weights <= 0 {
+		// A single row, or rows stamped at the same moment.
+		sum = 0
+		for _, stat := range stats {
+			sum += stat.Average
+		}
+		return sum / float64(len(stats))
+	}
+	return sum / weights
+}
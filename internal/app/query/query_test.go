@@ -0,0 +1,102 @@
+package query
+
+import (
+	"context"
+	"io"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/memory"
+	"marketflow/internal/domain"
+	"marketflow/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.InitWithWriter(io.Discard, "test", "error")
+	os.Exit(m.Run())
+}
+
+func window(ts time.Time, avg float64) domain.PriceStats {
+	return domain.PriceStats{Exchange: "ex1", Pair: "BTCUSDT", Timestamp: ts, Average: avg, Min: avg, Max: avg}
+}
+
+// newService stores windows of 100 at now-3m, now-2m and now-1m and sixty
+// ticks of 200 every half second after the last one.
+func newService(t *testing.T) (*Service, time.Time) {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now()
+	repo := memory.NewRepository()
+	repo.StoreStatsBatch(ctx, []domain.PriceStats{
+		window(now.Add(-3*time.Minute), 100),
+		window(now.Add(-2*time.Minute), 100),
+		window(now.Add(-time.Minute), 100),
+	})
+	cache := memory.NewCache(time.Minute, 2*time.Minute)
+	for i := 1; i <= 60; i++ {
+		cache.SetLatest(ctx, domain.PriceUpdate{
+			Exchange: "ex1", Pair: "BTCUSDT", Price: 200,
+			Time: now.Add(-time.Minute + time.Duration(i)*500*time.Millisecond),
+		})
+	}
+	return New(repo, cache, nil), now
+}
+
+func TestPeriodFoldsNewerTicksIntoOneWindow(t *testing.T) {
+	s, now := newService(t)
+	stats, err := s.Period(context.Background(), "ex1", "BTCUSDT", 10*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 4 {
+		t.Fatalf("got %d rows, want 3 windows and one partial window", len(stats))
+	}
+	partial := stats[3]
+	if want := now.Add(-30 * time.Second); !partial.Timestamp.Equal(want) {
+		t.Errorf("partial window stamped %s, want the last tick at %s", partial.Timestamp, want)
+	}
+	if partial.Average != 200 || partial.Min != 200 || partial.Max != 200 {
+		t.Errorf("partial window = %+v, want 200 throughout", partial)
+	}
+
+	// Three windows of a minute at 100 and half a minute of ticks at 200.
+	want := (3*60*100 + 30*200) / 210.0
+	if got := Average(stats); math.Abs(got-want) > 1e-9 {
+		t.Errorf("Average = %v, want %v", got, want)
+	}
+}
+
+func TestPeriodInsideHorizonUsesTicks(t *testing.T) {
+	s, _ := newService(t)
+	stats, source, err := s.samples(context.Background(), "ex1", "BTCUSDT", 45*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source != SourceTicks || len(stats) != 30 {
+		t.Errorf("got %d rows from %q, want the 30 ticks of the last 45s", len(stats), source)
+	}
+}
+
+func TestAverageWeightsRowsByTimeCovered(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Two hourly rollups at 100, then sixty one-minute windows at 200.
+	stats := []domain.PriceStats{window(base, 100), window(base.Add(time.Hour), 100)}
+	for i := 1; i <= 60; i++ {
+		stats = append(stats, window(base.Add(time.Hour+time.Duration(i)*time.Minute), 200))
+	}
+	if got, want := Average(stats), (2*100+200)/3.0; math.Abs(got-want) > 1e-9 {
+		t.Errorf("Average = %v, want %v", got, want)
+	}
+
+	// An outage counts for at most an hour.
+	gap := []domain.PriceStats{window(base, 100), window(base.Add(time.Hour), 100), window(base.Add(25*time.Hour), 400)}
+	if got, want := Average(gap), 200.0; got != want {
+		t.Errorf("Average across an outage = %v, want %v", got, want)
+	}
+
+	if got := Average([]domain.PriceStats{window(base, 42)}); got != 42 {
+		t.Errorf("Average of one row = %v, want 42", got)
+	}
+}
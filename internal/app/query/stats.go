@@ -0,0 +1,181 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+// year annualizes volatility; crypto markets never close.
+const year = 365 * 24 * time.Hour
+
+// Summary describes how a price moved over a period. Prices are the stored
+// windows' averages, or the ticks themselves when Source is SourceTicks.
+// Stored windows are first made one evenly spaced series (see homogeneous).
+// Resolution is the median spacing of those samples. Returns are log
+// returns between consecutive samples; RealizedVolatility is the square root
+// of their summed squares, and AnnualizedVolatility scales it from the time
+// the samples span to a year. MaxDrawdown is the largest fall from a
+// running peak, as a fraction of that peak.
+type Summary struct {
+	Source     string
+	Resolution time.Duration
+	Samples    int
+	From       time.Time
+	To         time.Time
+
+	First  float64
+	Last   float64
+	Mean   float64
+	StdDev float64
+	P5     float64
+	P50    float64
+	P95    float64
+
+	LogReturn            float64
+	MeanLogReturn        float64
+	LogReturnStdDev      float64
+	RealizedVolatility   float64
+	AnnualizedVolatility float64
+	MaxDrawdown          float64
+}
+
+// Summary computes the figures of the last period. It needs at least two
+// samples. Results are not cached.
+func (s *Service) Summary(ctx context.Context, exchange, pair string, period time.Duration) (Summary, error) {
+	stats, source, err := s.samples(ctx, exchange, pair, period)
+	if err != nil {
+		return Summary{}, err
+	}
+	if len(stats) < 2 {
+		return Summary{}, fmt.Errorf("need at least two samples for %s:%s in the last %s, have %d: %w",
+			exchange, pair, period, len(stats), domain.ErrNotFound)
+	}
+	if source == SourceStorage {
+		stats = homogeneous(stats)
+	}
+	sum := summarize(stats)
+	sum.Source = source
+	return sum, nil
+}
+
+// homogeneous makes stored windows one evenly spaced series, so that every
+// return spans about the same time. Past raw retention the windows come back
+// as hourly rollups, and an outage leaves a gap; a gap of maxCover or more
+// means either, and the whole series is then resampled to hours. Each hour
+// averages the windows ending in it and is stamped with the last of them.
+func homogeneous(stats []domain.PriceStats) []domain.PriceStats {
+	coarse := false
+	for i := 1; i < len(stats) && !coarse; i++ {
+		coarse = stats[i].Timestamp.Sub(stats[i-1].Timestamp) >= maxCover
+	}
+	if !coarse {
+		return stats
+	}
+
+	// Windows are stamped with their end, so one ending on the hour belongs
+	// to the hour before.
+	hour := func(t time.Time) time.Time { return t.Add(-time.Nanosecond).Truncate(time.Hour) }
+	var out []domain.PriceStats
+	n := 0
+	for _, st := range stats {
+		if len(out) == 0 || !hour(st.Timestamp).Equal(hour(out[len(out)-1].Timestamp)) {
+			out = append(out, st)
+			n = 1
+			continue
+		}
+		b := &out[len(out)-1]
+		n++
+		b.Average += (st.Average - b.Average) / float64(n)
+		b.Min = min(b.Min, st.Min)
+		b.Max = max(b.Max, st.Max)
+		b.Timestamp = st.Timestamp
+	}
+	return out
+}
+
+// summarize computes a Summary of stats, oldest first; it needs at least two.
+func summarize(stats []domain.PriceStats) Summary {
+	n := len(stats)
+	prices := make([]float64, n)
+	gaps := make([]time.Duration, 0, n-1)
+	for i, st := range stats {
+		prices[i] = st.Average
+		if i > 0 {
+			gaps = append(gaps, st.Timestamp.Sub(stats[i-1].Timestamp))
+		}
+	}
+
+	sum := Summary{
+		Samples: n,
+		From:    stats[0].Timestamp,
+		To:      stats[n-1].Timestamp,
+		First:   prices[0],
+		Last:    prices[n-1],
+	}
+	sum.Mean, sum.StdDev = meanStdDev(prices)
+
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	sum.Resolution = gaps[len(gaps)/2]
+
+	returns := make([]float64, 0, n-1)
+	var squares float64
+	peak := prices[0]
+	for i, p := range prices {
+		if p > peak {
+			peak = p
+		} else if peak > 0 {
+			sum.MaxDrawdown = max(sum.MaxDrawdown, (peak-p)/peak)
+		}
+		if i == 0 || prices[i-1] <= 0 || p <= 0 {
+			continue
+		}
+		r := math.Log(p / prices[i-1])
+		returns = append(returns, r)
+		squares += r * r
+	}
+	if len(returns) > 0 {
+		sum.MeanLogReturn, sum.LogReturnStdDev = meanStdDev(returns)
+	}
+	if sum.First > 0 && sum.Last > 0 {
+		sum.LogReturn = math.Log(sum.Last / sum.First)
+	}
+	sum.RealizedVolatility = math.Sqrt(squares)
+	if span := sum.To.Sub(sum.From); span > 0 {
+		sum.AnnualizedVolatility = sum.RealizedVolatility * math.Sqrt(float64(year)/float64(span))
+	}
+
+	sorted := append([]float64(nil), prices...)
+	sort.Float64s(sorted)
+	sum.P5, sum.P50, sum.P95 = percentile(sorted, 5), percentile(sorted, 50), percentile(sorted, 95)
+	return sum
+}
+
+// meanStdDev returns the mean and sample standard deviation of values.
+func meanStdDev(values []float64) (float64, float64) {
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	mean := total / float64(len(values))
+	if len(values) < 2 {
+		return mean, 0
+	}
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(values)-1))
+}
+
+// percentile interpolates linearly between the closest ranks of sorted.
+func percentile(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
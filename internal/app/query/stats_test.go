@@ -0,0 +1,60 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+func TestHomogeneousResamplesRollupsAndWindowsToHours(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Hourly rollups ending 01:00 to 03:00, then one-minute windows: sixty
+	// at 130 ending 03:01 to 04:00 and thirty at 140 ending 04:01 to 04:30.
+	var stats []domain.PriceStats
+	for i, avg := range []float64{100, 110, 120} {
+		stats = append(stats, window(base.Add(time.Duration(i+1)*time.Hour), avg))
+	}
+	for i := 1; i <= 90; i++ {
+		avg := 130.0
+		if i > 60 {
+			avg = 140
+		}
+		stats = append(stats, window(base.Add(3*time.Hour+time.Duration(i)*time.Minute), avg))
+	}
+	stats[50].Max, stats[70].Min = 150, 120
+
+	got := homogeneous(stats)
+	want := []domain.PriceStats{
+		window(base.Add(1*time.Hour), 100),
+		window(base.Add(2*time.Hour), 110),
+		window(base.Add(3*time.Hour), 120),
+		{Exchange: "ex1", Pair: "BTCUSDT", Timestamp: base.Add(4 * time.Hour), Average: 130, Min: 130, Max: 150},
+		{Exchange: "ex1", Pair: "BTCUSDT", Timestamp: base.Add(4*time.Hour + 30*time.Minute), Average: 140, Min: 120, Max: 140},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d rows, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if !got[i].Timestamp.Equal(want[i].Timestamp) || got[i] != want[i] {
+			t.Errorf("row %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	sum := summarize(got)
+	if sum.Resolution != time.Hour || sum.Samples != 5 || sum.First != 100 || sum.Last != 140 {
+		t.Errorf("summary = %s resolution, %d samples from %v to %v; want 1h, 5, 100 to 140",
+			sum.Resolution, sum.Samples, sum.First, sum.Last)
+	}
+}
+
+func TestHomogeneousKeepsEvenWindows(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var stats []domain.PriceStats
+	for i := 1; i <= 120; i++ {
+		stats = append(stats, window(base.Add(time.Duration(i)*time.Minute), float64(100+i)))
+	}
+	if got := homogeneous(stats); len(got) != len(stats) {
+		t.Errorf("got %d rows from %d one-minute windows, want them unchanged", len(got), len(stats))
+	}
+}
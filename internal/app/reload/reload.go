@@ -0,0 +1,135 @@
+package reload
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"marketflow/internal/app/aggregator"
+	"marketflow/internal/app/mode"
+	"marketflow/internal/config"
+	"marketflow/internal/logger"
+)
+
+type ttlSetter interface {
+	SetTTL(ttl time.Duration)
+}
+
+// safeKeys are the settings that can change without a restart. Anything else
+// is rejected on reload and keeps its running value.
+var safeKeys = map[string]func(r *Reloader, cur, next *config.Config){
+	"log.level": func(r *Reloader, cur, next *config.Config) {
+		if err := logger.SetLevel(next.Log.Level); err != nil {
+			logger.Error("failed to apply log level", "error", err)
+			return
+		}
+		cur.Log = next.Log
+	},
+	"redis.ttl": func(r *Reloader, cur, next *config.Config) {
+		if r.cache != nil {
+			r.cache.SetTTL(next.RedisTTL)
+		}
+		cur.RedisTTL = next.RedisTTL
+	},
+	"aggregator.window": func(r *Reloader, cur, next *config.Config) {
+		if r.agg != nil {
+			r.agg.SetWindow(next.AggregatorWindow)
+		}
+		cur.AggregatorWindow = next.AggregatorWindow
+	},
+	"exchanges": func(r *Reloader, cur, next *config.Config) {
+		if r.manager != nil {
+			r.manager.UpdateExchanges(next.Exchanges)
+		}
+		cur.Exchanges = next.Exchanges
+	},
+}
+
+type Reloader struct {
+	mu      sync.Mutex
+	src     config.Source
+	current *config.Config
+	manager *mode.Manager          // nil on api-only instances
+	agg     *aggregator.Aggregator // nil on api-only instances
+	cache   ttlSetter
+}
+
+func NewReloader(src config.Source, current *config.Config, manager *mode.Manager, agg *aggregator.Aggregator, cache ttlSetter) *Reloader {
+	return &Reloader{
+		src:     src,
+		current: current,
+		manager: manager,
+		agg:     agg,
+		cache:   cache,
+	}
+}
+
+// Run reloads on every value from hup and on config file changes until ctx
+// is cancelled.
+func (r *Reloader) Run(ctx context.Context, hup <-chan os.Signal) {
+	var fileChanged <-chan struct{}
+	if path := r.src.Path(); path != "" {
+		fileChanged = config.Watch(ctx, path, r.current.WatchInterval)
+		logger.Info("watching config file", "path", path, "interval", r.current.WatchInterval)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			logger.Info("received SIGHUP, reloading config")
+			r.Reload()
+		case _, ok := <-fileChanged:
+			if !ok {
+				fileChanged = nil
+				continue
+			}
+			logger.Info("config file changed, reloading config")
+			r.Reload()
+		}
+	}
+}
+
+// Reload loads and validates the configuration again and applies the safe
+// changes. It returns the keys that were applied.
+func (r *Reloader) Reload() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := config.Load(r.src)
+	if err != nil {
+		logger.Error("config reload rejected, keeping current config", "error", err)
+		return nil
+	}
+
+	changed := config.Diff(r.current, next)
+	if len(changed) == 0 {
+		logger.Info("config reloaded, nothing changed")
+		return nil
+	}
+
+	var applied, rejected []string
+	updated := *r.current
+	for _, key := range changed {
+		applyFn, ok := safeKeys[key]
+		if !ok {
+			rejected = append(rejected, key)
+			continue
+		}
+		applyFn(r, &updated, next)
+		applied = append(applied, key)
+	}
+	r.current = &updated
+
+	if len(rejected) > 0 {
+		logger.Warn("config changes require a restart and were not applied",
+			"keys", strings.Join(rejected, ","))
+	}
+	if len(applied) > 0 {
+		logger.Info("config changes applied", "keys", strings.Join(applied, ","))
+	}
+	return applied
+}
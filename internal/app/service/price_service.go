@@ -61,7 +61,7 @@ func (s *PriceService) Start(ctx context.Context) {
 		duration = time.Minute
 	}
 
-	s.aggregSvc = aggregator.NewAggregator(s.aggregator, s.repo, s.cache, duration)
+	s.aggregSvc = aggregator.NewAggregator(s.aggregator, s.repo, duration)
 	go s.aggregSvc.Start(ctx)
 	logger.Info("started aggregator service", "interval", duration)
 
@@ -0,0 +1,138 @@
+package statscache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+// Cache holds period query results for a short time and coalesces
+// concurrent loads of the same key into one, so a dashboard polling every
+// second costs one repository query per TTL instead of one per client.
+// Results are dropped early when the aggregator stores a new row for their
+// exchange and pair.
+type Cache struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	entries   map[Key]*entry
+	lastSweep time.Time
+}
+
+// Key is a normalized query: the period is a duration, so "60s" and "1m"
+// share an entry.
+type Key struct {
+	Exchange string
+	Pair     string
+	Period   time.Duration
+}
+
+// errAbandoned is what waiters get when the load they share did not return.
+var errAbandoned = errors.New("stats load did not complete")
+
+type entry struct {
+	done    chan struct{} // closed when the load has finished
+	stats   []domain.PriceStats
+	err     error
+	expires time.Time
+}
+
+func New(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl, entries: make(map[Key]*entry), lastSweep: time.Now()}
+}
+
+// Get returns the cached result for key or loads it. Callers that arrive
+// while a load is running wait for it instead of starting their own. Errors
+// are shared with those waiters but not cached. The returned slice must not
+// be modified.
+func (c *Cache) Get(ctx context.Context, key Key, load func(ctx context.Context) ([]domain.PriceStats, error)) ([]domain.PriceStats, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	if ok && (!isDone(e) || now.Before(e.expires)) {
+		c.mu.Unlock()
+		select {
+		case <-e.done:
+			return e.stats, e.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	c.sweep(now)
+	e = &entry{done: make(chan struct{})}
+	c.entries[key] = e
+	c.mu.Unlock()
+
+	// The waiters are released even if load panics; they get errAbandoned
+	// while the panic goes on up this caller's stack.
+	completed := false
+	defer func() {
+		if !completed {
+			e.stats, e.err = nil, errAbandoned
+		}
+		c.finish(key, e)
+	}()
+
+	// The load is shared, so one caller giving up must not cancel it; the
+	// repository's own deadlines still bound it.
+	e.stats, e.err = load(context.WithoutCancel(ctx))
+	completed = true
+	return e.stats, e.err
+}
+
+// finish publishes the result of e to its waiters. Errors are not kept.
+func (c *Cache) finish(key Key, e *entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e.expires = time.Now().Add(c.ttl)
+	if e.err != nil && c.entries[key] == e {
+		delete(c.entries, key)
+	}
+	close(e.done)
+}
+
+// Invalidate drops every cached period of exchange and pair. A load already
+// running still answers its waiters but is not kept.
+func (c *Cache) Invalidate(exchange, pair string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if key.Exchange == exchange && key.Pair == pair {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// InvalidateStats drops the entries of every exchange and pair in stats,
+// as an aggregator flush hook.
+func (c *Cache) InvalidateStats(stats []domain.PriceStats) {
+	for _, s := range stats {
+		c.Invalidate(s.Exchange, s.Pair)
+	}
+}
+
+// sweep removes expired entries, at most once per TTL.
+func (c *Cache) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < c.ttl {
+		return
+	}
+	c.lastSweep = now
+	for key, e := range c.entries {
+		if isDone(e) && !now.Before(e.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func isDone(e *entry) bool {
+	select {
+	case <-e.done:
+		return true
+	default:
+		return false
+	}
+}
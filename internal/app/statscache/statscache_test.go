@@ -0,0 +1,55 @@
+package statscache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+func TestPanickingLoadReleasesWaiters(t *testing.T) {
+	c := New(time.Minute)
+	key := Key{Exchange: "ex1", Pair: "BTCUSDT", Period: time.Minute}
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	go func() {
+		defer func() { recover() }()
+		c.Get(context.Background(), key, func(ctx context.Context) ([]domain.PriceStats, error) {
+			close(started)
+			<-release
+			panic("load failed")
+		})
+	}()
+	<-started
+
+	waited := make(chan error, 1)
+	go func() {
+		_, err := c.Get(context.Background(), key, func(ctx context.Context) ([]domain.PriceStats, error) {
+			t.Error("the waiter started a load of its own")
+			return nil, nil
+		})
+		waited <- err
+	}()
+	// Let the waiter find the running load before it panics.
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	select {
+	case err := <-waited:
+		if !errors.Is(err, errAbandoned) {
+			t.Errorf("waiter got %v, want errAbandoned", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiter still blocked after the load panicked")
+	}
+
+	stats, err := c.Get(context.Background(), key, func(ctx context.Context) ([]domain.PriceStats, error) {
+		return []domain.PriceStats{{Average: 1}}, nil
+	})
+	if err != nil || len(stats) != 1 {
+		t.Errorf("next Get = %v, %v; want a fresh load", stats, err)
+	}
+}
@@ -3,33 +3,75 @@ package config
 import (
 	"fmt"
 	"os"
-	"strconv"
 	"time"
 )
 
 type Config struct {
+	Env              string
+	Role             string
+	Log              LogConfig
+	Storage          string
+	FileStore        FileStoreConfig
 	Postgres         PostgresConfig
 	Redis            RedisConfig
 	Exchanges        []Exchange
+	Pairs            []string
 	APIAddr          string
+	BatchLimit       int
+	WSOrigins        []string
+	GRPCAddr         string // empty disables the gRPC API
 	AggregatorWindow time.Duration
 	RedisTTL         time.Duration
+	CleanupInterval  time.Duration
+	StaleAfter       time.Duration
+	FlushInterval    time.Duration
+	QueryCacheTTL    time.Duration
+	WatchInterval    time.Duration
+	Pipeline         PipelineConfig
+	Ingest           IngestConfig
+	Maintenance      MaintenanceConfig
+	Leader           LeaderConfig
+	Auth             AuthConfig
+	RateLimit        RateLimitConfig
+	Spread           SpreadConfig
+	Alerts           AlertsConfig
+	Indicators       IndicatorsConfig
+}
+
+type LogConfig struct {
+	Level string
 }
 
 type PostgresConfig struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	Host            string
+	Port            int
+	User            string
+	Password        string
+	DBName          string
+	SSLMode         string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	AutoMigrate     bool
+	CopyThreshold   int
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	BatchTimeout    time.Duration
+}
+
+type FileStoreConfig struct {
+	Dir         string
+	SegmentSize int
 }
 
 type RedisConfig struct {
-	Host     string
-	Port     int
-	Password string
-	DB       int
+	Host        string
+	Port        int
+	Password    string
+	DB          int
+	PoolSize    int
+	TickHorizon time.Duration
+	PubSub      bool
 }
 
 type Exchange struct {
@@ -37,79 +79,227 @@ type Exchange struct {
 	Address string
 }
 
-func Load() (*Config, error) {
-	requiredEnv := map[string]string{
-		"PG_HOST":           os.Getenv("PG_HOST"),
-		"PG_PORT":           os.Getenv("PG_PORT"),
-		"PG_USER":           os.Getenv("PG_USER"),
-		"PG_PASSWORD":       os.Getenv("PG_PASSWORD"),
-		"PG_DB":             os.Getenv("PG_DB"),
-		"PG_SSLMODE":        os.Getenv("PG_SSLMODE"),
-		"REDIS_HOST":        os.Getenv("REDIS_HOST"),
-		"REDIS_PORT":        os.Getenv("REDIS_PORT"),
-		"REDIS_DB":          os.Getenv("REDIS_DB"),
-		"EXCHANGE1_ADDR":    os.Getenv("EXCHANGE1_ADDR"),
-		"EXCHANGE2_ADDR":    os.Getenv("EXCHANGE2_ADDR"),
-		"EXCHANGE3_ADDR":    os.Getenv("EXCHANGE3_ADDR"),
-		"API_ADDR":          os.Getenv("API_ADDR"),
-		"AGGREGATOR_WINDOW": os.Getenv("AGGREGATOR_WINDOW"),
-		"REDIS_TTL":         os.Getenv("REDIS_TTL"),
-	}
-	for key, value := range requiredEnv {
-		if value == "" {
-			return nil, fmt.Errorf("missing required env variable: %s", key)
-		}
-	}
+type PipelineConfig struct {
+	Workers      int
+	InputBuffer  int
+	OutputBuffer int
+}
 
-	pgPort, err := strconv.Atoi(os.Getenv("PG_PORT"))
-	if err != nil {
-		return nil, fmt.Errorf("invalid PG_PORT: %w", err)
-	}
+type IngestConfig struct {
+	ReconnectDelay time.Duration
+	DialTimeout    time.Duration
+	TestInterval   time.Duration
+}
 
-	redisPort, err := strconv.Atoi(os.Getenv("REDIS_PORT"))
-	if err != nil {
-		return nil, fmt.Errorf("invalid REDIS_PORT: %w", err)
-	}
+type MaintenanceConfig struct {
+	Enabled         bool
+	Interval        time.Duration
+	RawRetention    time.Duration
+	HourlyRetention time.Duration
+	PartitionsAhead int
+}
 
-	redisDB, err := strconv.Atoi(os.Getenv("REDIS_DB"))
-	if err != nil {
-		return nil, fmt.Errorf("invalid REDIS_DB: %w", err)
-	}
+type LeaderConfig struct {
+	Enabled bool
+	Lease   time.Duration
+	Key     string
+}
 
-	aggregatorWindow, err := time.ParseDuration(os.Getenv("AGGREGATOR_WINDOW"))
-	if err != nil {
-		return nil, fmt.Errorf("invalid AGGREGATOR_WINDOW: %w", err)
-	}
+type AuthConfig struct {
+	Enabled   bool
+	Store     string
+	KeysFile  string
+	AuditFile string
+}
 
-	redisTTL, err := time.ParseDuration(os.Getenv("REDIS_TTL"))
-	if err != nil {
-		return nil, fmt.Errorf("invalid REDIS_TTL: %w", err)
-	}
+type RateLimitConfig struct {
+	Enabled     bool
+	Store       string
+	Rate        int
+	Burst       int
+	HistoryCost int
+}
+
+type SpreadConfig struct {
+	Enabled        bool
+	ThresholdBps   int
+	MinDuration    time.Duration
+	MaxAge         time.Duration
+	RecordInterval time.Duration
+	Retention      time.Duration
+}
+
+type AlertsConfig struct {
+	Enabled         bool
+	EvalInterval    time.Duration
+	RefreshInterval time.Duration
+	Cooldown        time.Duration
+	WebhookTimeout  time.Duration
+	Retries         int
+	RetryBackoff    time.Duration
+	// AllowPrivateWebhooks lets webhooks reach loopback, link-local and
+	// private addresses.
+	AllowPrivateWebhooks bool
+}
+
+type IndicatorsConfig struct {
+	Enabled    bool
+	TrackAfter int
+	TrackTTL   time.Duration
+	MaxTracked int
+}
+
+// Source describes where configuration comes from besides the defaults and
+// the environment. File may be empty; Overrides are dotted keys from CLI flags.
+type Source struct {
+	File      string
+	Overrides map[string]string
+}
 
-	cfg := &Config{
+func Default() *Config {
+	return &Config{
+		Env:     "development",
+		Role:    "all",
+		Log:     LogConfig{Level: "debug"},
+		Storage: "postgres",
+		FileStore: FileStoreConfig{
+			Dir:         "marketflow-data",
+			SegmentSize: 4 << 20,
+		},
 		Postgres: PostgresConfig{
-			Host:     os.Getenv("PG_HOST"),
-			Port:     pgPort,
-			User:     os.Getenv("PG_USER"),
-			Password: os.Getenv("PG_PASSWORD"),
-			DBName:   os.Getenv("PG_DB"),
-			SSLMode:  os.Getenv("PG_SSLMODE"),
+			Host:            "localhost",
+			Port:            5432,
+			User:            "postgres",
+			DBName:          "marketflow",
+			SSLMode:         "disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 5 * time.Minute,
+			AutoMigrate:     true,
+			CopyThreshold:   500,
+			ReadTimeout:     5 * time.Second,
+			WriteTimeout:    5 * time.Second,
+			BatchTimeout:    time.Minute,
 		},
 		Redis: RedisConfig{
-			Host:     os.Getenv("REDIS_HOST"),
-			Port:     redisPort,
-			Password: os.Getenv("REDIS_PASSWORD"),
-			DB:       redisDB,
+			Host:        "localhost",
+			Port:        6379,
+			PoolSize:    10,
+			TickHorizon: 5 * time.Minute,
+			PubSub:      true,
 		},
 		Exchanges: []Exchange{
-			{Name: "exchange1", Address: os.Getenv("EXCHANGE1_ADDR")},
-			{Name: "exchange2", Address: os.Getenv("EXCHANGE2_ADDR")},
-			{Name: "exchange3", Address: os.Getenv("EXCHANGE3_ADDR")},
+			{Name: "exchange1", Address: "localhost:40101"},
+			{Name: "exchange2", Address: "localhost:40102"},
+			{Name: "exchange3", Address: "localhost:40103"},
+		},
+		Pairs:            []string{"BTCUSDT", "ETHUSDT", "DOGEUSDT", "TONUSDT", "SOLUSDT"},
+		APIAddr:          ":8080",
+		BatchLimit:       200,
+		AggregatorWindow: time.Minute,
+		RedisTTL:         24 * time.Hour,
+		CleanupInterval:  5 * time.Minute,
+		StaleAfter:       time.Hour,
+		FlushInterval:    100 * time.Millisecond,
+		QueryCacheTTL:    time.Second,
+		WatchInterval:    2 * time.Second,
+		Pipeline: PipelineConfig{
+			Workers:      5,
+			InputBuffer:  1000,
+			OutputBuffer: 1000,
+		},
+		Ingest: IngestConfig{
+			ReconnectDelay: 5 * time.Second,
+			DialTimeout:    5 * time.Second,
+			TestInterval:   time.Second,
+		},
+		Maintenance: MaintenanceConfig{
+			Enabled:         true,
+			Interval:        time.Hour,
+			RawRetention:    7 * 24 * time.Hour,
+			HourlyRetention: 365 * 24 * time.Hour,
+			PartitionsAhead: 3,
+		},
+		Leader: LeaderConfig{
+			Lease: 15 * time.Second,
+			Key:   "marketflow:leader",
+		},
+		Auth: AuthConfig{
+			Store:     "file",
+			KeysFile:  "marketflow-keys.json",
+			AuditFile: "marketflow-audit.log",
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:     true,
+			Store:       "memory",
+			Rate:        20,
+			Burst:       40,
+			HistoryCost: 5,
+		},
+		Spread: SpreadConfig{
+			Enabled:        true,
+			ThresholdBps:   50,
+			MinDuration:    30 * time.Second,
+			MaxAge:         10 * time.Second,
+			RecordInterval: 10 * time.Second,
+			Retention:      7 * 24 * time.Hour,
+		},
+		Alerts: AlertsConfig{
+			Enabled:         true,
+			EvalInterval:    5 * time.Second,
+			RefreshInterval: 5 * time.Second,
+			Cooldown:        5 * time.Minute,
+			WebhookTimeout:  5 * time.Second,
+			Retries:         3,
+			RetryBackoff:    time.Second,
+		},
+		Indicators: IndicatorsConfig{
+			Enabled:    true,
+			TrackAfter: 3,
+			TrackTTL:   15 * time.Minute,
+			MaxTracked: 200,
 		},
-		APIAddr:          os.Getenv("API_ADDR"),
-		AggregatorWindow: aggregatorWindow,
-		RedisTTL:         redisTTL,
 	}
+}
+
+// Load builds the configuration in layers: defaults, then the config file,
+// then environment variables, then CLI overrides. Every parse and validation
+// problem is collected and returned together as a *ValidationError.
+func Load(src Source) (*Config, error) {
+	cfg := Default()
+	verr := &ValidationError{}
 
+	if path := src.Path(); path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		apply(cfg, values, "file "+path, verr)
+	}
+
+	apply(cfg, envValues(cfg), "env", verr)
+	apply(cfg, src.Overrides, "flag", verr)
+
+	verr.Problems = append(verr.Problems, cfg.Validate()...)
+	if len(verr.Problems) > 0 {
+		return nil, verr
+	}
 	return cfg, nil
 }
+
+// Path returns the config file in use: the -config flag, else CONFIG_FILE.
+func (s Source) Path() string {
+	if s.File != "" {
+		return s.File
+	}
+	return os.Getenv("CONFIG_FILE")
+}
+
+func (c PostgresConfig) DSN() string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		c.Host, c.Port, c.User, c.Password, c.DBName, c.SSLMode)
+}
+
+func (c RedisConfig) Addr() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
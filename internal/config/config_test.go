@@ -0,0 +1,123 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, "marketflow.yaml", `
+api:
+  addr: ":8001"
+log:
+  level: info
+pipeline:
+  workers: 2
+aggregator:
+  window: 30s
+`)
+	t.Setenv("API_ADDR", ":8002")
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("AGGREGATOR_WINDOW", "")
+
+	cfg, err := Load(Source{File: path, Overrides: map[string]string{"api.addr": ":8003"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		key       string
+		got, want any
+	}{
+		{"api.addr (flag over env over file)", cfg.APIAddr, ":8003"},
+		{"log.level (env over file)", cfg.Log.Level, "warn"},
+		{"pipeline.workers (file over default)", cfg.Pipeline.Workers, 2},
+		{"aggregator.window (empty env is unset)", cfg.AggregatorWindow, 30 * time.Second},
+		{"redis.port (default)", cfg.Redis.Port, 6379},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s = %v, want %v", tc.key, tc.got, tc.want)
+		}
+	}
+}
+
+func TestLoadJSONFile(t *testing.T) {
+	path := writeFile(t, "marketflow.json", `{"redis": {"port": 6380}, "pairs": ["BTCUSDT"], "exchanges": [{"name": "ex1", "address": "h:1"}]}`)
+	cfg, err := Load(Source{File: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Redis.Port != 6380 || !reflect.DeepEqual(cfg.Pairs, []string{"BTCUSDT"}) ||
+		!reflect.DeepEqual(cfg.Exchanges, []Exchange{{Name: "ex1", Address: "h:1"}}) {
+		t.Errorf("loaded redis.port %d, pairs %v, exchanges %v", cfg.Redis.Port, cfg.Pairs, cfg.Exchanges)
+	}
+}
+
+func TestLegacyExchangeAddresses(t *testing.T) {
+	path := writeFile(t, "marketflow.yaml", `
+exchanges:
+  - name: ex1
+    address: "h:1"
+  - name: ex2
+    address: "h:2"
+`)
+	// EXCHANGE2_ADDR moves the second exchange and EXCHANGE3_ADDR adds a
+	// third. EXCHANGE5_ADDR is ignored: there is no fourth to follow.
+	t.Setenv("EXCHANGE2_ADDR", "h:22")
+	t.Setenv("EXCHANGE3_ADDR", "h:3")
+	t.Setenv("EXCHANGE5_ADDR", "h:5")
+
+	cfg, err := Load(Source{File: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Exchange{{Name: "ex1", Address: "h:1"}, {Name: "ex2", Address: "h:22"}, {Name: "exchange3", Address: "h:3"}}
+	if !reflect.DeepEqual(cfg.Exchanges, want) {
+		t.Errorf("exchanges = %v, want %v", cfg.Exchanges, want)
+	}
+
+	// EXCHANGES wins over the legacy variables.
+	t.Setenv("EXCHANGES", "a=x:1")
+	cfg, err = Load(Source{File: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Exchange{{Name: "a", Address: "x:1"}}
+	if !reflect.DeepEqual(cfg.Exchanges, want) {
+		t.Errorf("exchanges with EXCHANGES set = %v, want %v", cfg.Exchanges, want)
+	}
+}
+
+func TestLoadCollectsEveryProblem(t *testing.T) {
+	path := writeFile(t, "marketflow.yaml", "unknown: 1\npipeline:\n  workers: many\n")
+	t.Setenv("REDIS_PORT", "0")
+	_, err := Load(Source{File: path, Overrides: map[string]string{"aggregator.window": "soon"}})
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Load = %v, want a *ValidationError", err)
+	}
+	want := []string{
+		"file " + path + `: pipeline.workers: invalid integer "many"`,
+		"file " + path + `: unknown key "unknown"`,
+		`flag: aggregator.window: invalid duration "soon"`,
+	}
+	if len(verr.Problems) < len(want) || !reflect.DeepEqual(verr.Problems[:len(want)], want) {
+		t.Fatalf("problems = %q, want them to start with %q", verr.Problems, want)
+	}
+	if !containsPrefix(verr.Problems[len(want):], "redis.port:") {
+		t.Errorf("problems = %q, want the validation of redis.port after the parse errors", verr.Problems)
+	}
+}
@@ -0,0 +1,277 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// field maps a dotted config key and its environment variable to the Config
+// value it controls.
+type field struct {
+	key string
+	env string
+	ptr func(c *Config) any
+}
+
+var fields = []field{
+	{"env", "APP_ENV", func(c *Config) any { return &c.Env }},
+	{"role", "ROLE", func(c *Config) any { return &c.Role }},
+	{"log.level", "LOG_LEVEL", func(c *Config) any { return &c.Log.Level }},
+	{"api.addr", "API_ADDR", func(c *Config) any { return &c.APIAddr }},
+	{"api.batch_limit", "API_BATCH_LIMIT", func(c *Config) any { return &c.BatchLimit }},
+	{"api.ws_origins", "API_WS_ORIGINS", func(c *Config) any { return &c.WSOrigins }},
+	{"grpc.addr", "GRPC_ADDR", func(c *Config) any { return &c.GRPCAddr }},
+	{"storage", "STORAGE", func(c *Config) any { return &c.Storage }},
+	{"filestore.dir", "FILESTORE_DIR", func(c *Config) any { return &c.FileStore.Dir }},
+	{"filestore.segment_size", "FILESTORE_SEGMENT_SIZE", func(c *Config) any { return &c.FileStore.SegmentSize }},
+
+	{"postgres.host", "PG_HOST", func(c *Config) any { return &c.Postgres.Host }},
+	{"postgres.port", "PG_PORT", func(c *Config) any { return &c.Postgres.Port }},
+	{"postgres.user", "PG_USER", func(c *Config) any { return &c.Postgres.User }},
+	{"postgres.password", "PG_PASSWORD", func(c *Config) any { return &c.Postgres.Password }},
+	{"postgres.dbname", "PG_DB", func(c *Config) any { return &c.Postgres.DBName }},
+	{"postgres.sslmode", "PG_SSLMODE", func(c *Config) any { return &c.Postgres.SSLMode }},
+	{"postgres.max_open_conns", "PG_MAX_OPEN_CONNS", func(c *Config) any { return &c.Postgres.MaxOpenConns }},
+	{"postgres.max_idle_conns", "PG_MAX_IDLE_CONNS", func(c *Config) any { return &c.Postgres.MaxIdleConns }},
+	{"postgres.conn_max_lifetime", "PG_CONN_MAX_LIFETIME", func(c *Config) any { return &c.Postgres.ConnMaxLifetime }},
+	{"postgres.copy_threshold", "PG_COPY_THRESHOLD", func(c *Config) any { return &c.Postgres.CopyThreshold }},
+	{"postgres.read_timeout", "PG_READ_TIMEOUT", func(c *Config) any { return &c.Postgres.ReadTimeout }},
+	{"postgres.write_timeout", "PG_WRITE_TIMEOUT", func(c *Config) any { return &c.Postgres.WriteTimeout }},
+	{"postgres.batch_timeout", "PG_BATCH_TIMEOUT", func(c *Config) any { return &c.Postgres.BatchTimeout }},
+	{"postgres.auto_migrate", "PG_AUTO_MIGRATE", func(c *Config) any { return &c.Postgres.AutoMigrate }},
+
+	{"redis.host", "REDIS_HOST", func(c *Config) any { return &c.Redis.Host }},
+	{"redis.port", "REDIS_PORT", func(c *Config) any { return &c.Redis.Port }},
+	{"redis.password", "REDIS_PASSWORD", func(c *Config) any { return &c.Redis.Password }},
+	{"redis.db", "REDIS_DB", func(c *Config) any { return &c.Redis.DB }},
+	{"redis.pool_size", "REDIS_POOL_SIZE", func(c *Config) any { return &c.Redis.PoolSize }},
+	{"redis.ttl", "REDIS_TTL", func(c *Config) any { return &c.RedisTTL }},
+	{"redis.tick_horizon", "REDIS_TICK_HORIZON", func(c *Config) any { return &c.Redis.TickHorizon }},
+	{"redis.pubsub", "REDIS_PUBSUB", func(c *Config) any { return &c.Redis.PubSub }},
+
+	{"aggregator.window", "AGGREGATOR_WINDOW", func(c *Config) any { return &c.AggregatorWindow }},
+	{"cache.cleanup_interval", "CACHE_CLEANUP_INTERVAL", func(c *Config) any { return &c.CleanupInterval }},
+	{"cache.stale_after", "CACHE_STALE_AFTER", func(c *Config) any { return &c.StaleAfter }},
+	{"cache.flush_interval", "CACHE_FLUSH_INTERVAL", func(c *Config) any { return &c.FlushInterval }},
+	{"cache.query_ttl", "CACHE_QUERY_TTL", func(c *Config) any { return &c.QueryCacheTTL }},
+	{"config.watch_interval", "CONFIG_WATCH_INTERVAL", func(c *Config) any { return &c.WatchInterval }},
+
+	{"pipeline.workers", "WORKERS", func(c *Config) any { return &c.Pipeline.Workers }},
+	{"pipeline.input_buffer", "INPUT_BUFFER", func(c *Config) any { return &c.Pipeline.InputBuffer }},
+	{"pipeline.output_buffer", "OUTPUT_BUFFER", func(c *Config) any { return &c.Pipeline.OutputBuffer }},
+
+	{"ingest.reconnect_delay", "RECONNECT_DELAY", func(c *Config) any { return &c.Ingest.ReconnectDelay }},
+	{"ingest.dial_timeout", "DIAL_TIMEOUT", func(c *Config) any { return &c.Ingest.DialTimeout }},
+	{"ingest.test_interval", "TEST_TICK_INTERVAL", func(c *Config) any { return &c.Ingest.TestInterval }},
+
+	{"maintenance.enabled", "MAINTENANCE_ENABLED", func(c *Config) any { return &c.Maintenance.Enabled }},
+	{"maintenance.interval", "MAINTENANCE_INTERVAL", func(c *Config) any { return &c.Maintenance.Interval }},
+	{"maintenance.partitions_ahead", "PARTITIONS_AHEAD", func(c *Config) any { return &c.Maintenance.PartitionsAhead }},
+	{"retention.raw", "RETENTION_RAW", func(c *Config) any { return &c.Maintenance.RawRetention }},
+	{"retention.hourly", "RETENTION_HOURLY", func(c *Config) any { return &c.Maintenance.HourlyRetention }},
+
+	{"leader.enabled", "LEADER_ELECTION", func(c *Config) any { return &c.Leader.Enabled }},
+	{"leader.lease", "LEADER_LEASE", func(c *Config) any { return &c.Leader.Lease }},
+	{"leader.key", "LEADER_KEY", func(c *Config) any { return &c.Leader.Key }},
+
+	{"auth.enabled", "AUTH_ENABLED", func(c *Config) any { return &c.Auth.Enabled }},
+	{"auth.store", "AUTH_STORE", func(c *Config) any { return &c.Auth.Store }},
+	{"auth.keys_file", "AUTH_KEYS_FILE", func(c *Config) any { return &c.Auth.KeysFile }},
+	{"auth.audit_file", "AUTH_AUDIT_FILE", func(c *Config) any { return &c.Auth.AuditFile }},
+
+	{"ratelimit.enabled", "RATE_LIMIT_ENABLED", func(c *Config) any { return &c.RateLimit.Enabled }},
+	{"ratelimit.store", "RATE_LIMIT_STORE", func(c *Config) any { return &c.RateLimit.Store }},
+	{"ratelimit.rate", "RATE_LIMIT_RATE", func(c *Config) any { return &c.RateLimit.Rate }},
+	{"ratelimit.burst", "RATE_LIMIT_BURST", func(c *Config) any { return &c.RateLimit.Burst }},
+	{"ratelimit.history_cost", "RATE_LIMIT_HISTORY_COST", func(c *Config) any { return &c.RateLimit.HistoryCost }},
+
+	{"spread.enabled", "SPREAD_ENABLED", func(c *Config) any { return &c.Spread.Enabled }},
+	{"spread.threshold_bps", "SPREAD_THRESHOLD_BPS", func(c *Config) any { return &c.Spread.ThresholdBps }},
+	{"spread.min_duration", "SPREAD_MIN_DURATION", func(c *Config) any { return &c.Spread.MinDuration }},
+	{"spread.max_age", "SPREAD_MAX_AGE", func(c *Config) any { return &c.Spread.MaxAge }},
+	{"spread.record_interval", "SPREAD_RECORD_INTERVAL", func(c *Config) any { return &c.Spread.RecordInterval }},
+	{"spread.retention", "SPREAD_RETENTION", func(c *Config) any { return &c.Spread.Retention }},
+	{"alerts.enabled", "ALERTS_ENABLED", func(c *Config) any { return &c.Alerts.Enabled }},
+	{"alerts.eval_interval", "ALERTS_EVAL_INTERVAL", func(c *Config) any { return &c.Alerts.EvalInterval }},
+	{"alerts.refresh_interval", "ALERTS_REFRESH_INTERVAL", func(c *Config) any { return &c.Alerts.RefreshInterval }},
+	{"alerts.cooldown", "ALERTS_COOLDOWN", func(c *Config) any { return &c.Alerts.Cooldown }},
+	{"alerts.webhook_timeout", "ALERTS_WEBHOOK_TIMEOUT", func(c *Config) any { return &c.Alerts.WebhookTimeout }},
+	{"alerts.retries", "ALERTS_RETRIES", func(c *Config) any { return &c.Alerts.Retries }},
+	{"alerts.retry_backoff", "ALERTS_RETRY_BACKOFF", func(c *Config) any { return &c.Alerts.RetryBackoff }},
+	{"alerts.allow_private_webhooks", "ALERTS_ALLOW_PRIVATE_WEBHOOKS", func(c *Config) any { return &c.Alerts.AllowPrivateWebhooks }},
+	{"indicators.enabled", "INDICATORS_ENABLED", func(c *Config) any { return &c.Indicators.Enabled }},
+	{"indicators.track_after", "INDICATORS_TRACK_AFTER", func(c *Config) any { return &c.Indicators.TrackAfter }},
+	{"indicators.track_ttl", "INDICATORS_TRACK_TTL", func(c *Config) any { return &c.Indicators.TrackTTL }},
+	{"indicators.max_tracked", "INDICATORS_MAX_TRACKED", func(c *Config) any { return &c.Indicators.MaxTracked }},
+
+	{"pairs", "PAIRS", func(c *Config) any { return &c.Pairs }},
+	{"exchanges", "EXCHANGES", func(c *Config) any { return &c.Exchanges }},
+}
+
+func apply(cfg *Config, values map[string]string, origin string, verr *ValidationError) {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		f, ok := lookupField(key)
+		if !ok {
+			if exchangeAddrIndex(key) >= 0 {
+				setLegacyExchange(cfg, key, values[key])
+				continue
+			}
+			verr.add("%s: unknown key %q", origin, key)
+			continue
+		}
+		if err := f.set(cfg, values[key]); err != nil {
+			verr.add("%s: %s: %v", origin, key, err)
+		}
+	}
+}
+
+// Values lists every key with its effective value, secrets masked.
+func (c *Config) Values() [][2]string {
+	out := make([][2]string, 0, len(fields))
+	for _, f := range fields {
+		v := f.get(c)
+		if strings.HasSuffix(f.key, "password") && v != "" {
+			v = "********"
+		}
+		out = append(out, [2]string{f.key, v})
+	}
+	return out
+}
+
+func lookupField(key string) (field, bool) {
+	for _, f := range fields {
+		if f.key == key {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+func envValues(cfg *Config) map[string]string {
+	values := make(map[string]string)
+	for _, f := range fields {
+		if v, ok := os.LookupEnv(f.env); ok && v != "" {
+			values[f.key] = v
+		}
+	}
+	// EXCHANGE1_ADDR..EXCHANGEn_ADDR are kept for existing .env files.
+	for i := 1; i <= len(cfg.Exchanges)+1; i++ {
+		name := fmt.Sprintf("EXCHANGE%d_ADDR", i)
+		if v := os.Getenv(name); v != "" {
+			values[name] = v
+		}
+	}
+	return values
+}
+
+func exchangeAddrIndex(key string) int {
+	if !strings.HasPrefix(key, "EXCHANGE") || !strings.HasSuffix(key, "_ADDR") {
+		return -1
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(key, "EXCHANGE"), "_ADDR"))
+	if err != nil || n < 1 {
+		return -1
+	}
+	return n - 1
+}
+
+func setLegacyExchange(cfg *Config, key, addr string) {
+	i := exchangeAddrIndex(key)
+	if i < len(cfg.Exchanges) {
+		cfg.Exchanges[i].Address = addr
+		return
+	}
+	cfg.Exchanges = append(cfg.Exchanges, Exchange{Name: fmt.Sprintf("exchange%d", i+1), Address: addr})
+}
+
+func (f field) set(c *Config, v string) error {
+	switch p := f.ptr(c).(type) {
+	case *string:
+		*p = v
+	case *int:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", v)
+		}
+		*p = n
+	case *bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", v)
+		}
+		*p = b
+	case *time.Duration:
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", v)
+		}
+		*p = d
+	case *[]string:
+		*p = splitList(v)
+	case *[]Exchange:
+		exchanges, err := parseExchanges(v)
+		if err != nil {
+			return err
+		}
+		*p = exchanges
+	default:
+		return fmt.Errorf("unsupported field type %T", p)
+	}
+	return nil
+}
+
+func (f field) get(c *Config) string {
+	switch p := f.ptr(c).(type) {
+	case *string:
+		return *p
+	case *int:
+		return strconv.Itoa(*p)
+	case *bool:
+		return strconv.FormatBool(*p)
+	case *time.Duration:
+		return p.String()
+	case *[]string:
+		return strings.Join(*p, ",")
+	case *[]Exchange:
+		items := make([]string, len(*p))
+		for i, ex := range *p {
+			items[i] = ex.Name + "=" + ex.Address
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(p)
+	}
+}
+
+// parseExchanges parses "name=host:port,name=host:port".
+func parseExchanges(v string) ([]Exchange, error) {
+	var exchanges []Exchange
+	for _, item := range splitList(v) {
+		name, addr, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid exchange %q, want name=host:port", item)
+		}
+		exchanges = append(exchanges, Exchange{Name: strings.TrimSpace(name), Address: strings.TrimSpace(addr)})
+	}
+	return exchanges, nil
+}
+
+func splitList(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// readFile parses a JSON or YAML config file into flat dotted keys
+// ("postgres.host") so it can be applied through the same field table as
+// environment variables and flags.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree map[string]any
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".json" || (ext != ".yaml" && ext != ".yml" && bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))) {
+		if err := json.Unmarshal(data, &tree); err != nil {
+			return nil, fmt.Errorf("invalid JSON in %s: %w", path, err)
+		}
+	} else {
+		tree, err = parseYAML(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid YAML in %s: %w", path, err)
+		}
+	}
+
+	values := make(map[string]string)
+	if err := flatten("", tree, values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func flatten(prefix string, node map[string]any, out map[string]string) error {
+	for k, v := range node {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch val := v.(type) {
+		case map[string]any:
+			if err := flatten(key, val, out); err != nil {
+				return err
+			}
+		case []any:
+			items := make([]string, 0, len(val))
+			for _, item := range val {
+				s, err := listItem(key, item)
+				if err != nil {
+					return err
+				}
+				items = append(items, s)
+			}
+			out[key] = strings.Join(items, ",")
+		default:
+			out[key] = scalar(val)
+		}
+	}
+	return nil
+}
+
+// listItem renders list entries; objects are only allowed for exchanges,
+// which become "name=address".
+func listItem(key string, item any) (string, error) {
+	m, ok := item.(map[string]any)
+	if !ok {
+		return scalar(item), nil
+	}
+	if key != "exchanges" {
+		return "", fmt.Errorf("%s: objects are not supported in this list", key)
+	}
+	return scalar(m["name"]) + "=" + scalar(m["address"]), nil
+}
+
+func scalar(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprint(val)
+	}
+}
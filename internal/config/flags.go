@@ -0,0 +1,39 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// BindFlags registers the configuration flags on fs and returns the Source
+// they fill in once fs is parsed.
+func BindFlags(fs *flag.FlagSet) *Source {
+	src := &Source{Overrides: make(map[string]string)}
+
+	fs.StringVar(&src.File, "config", "", "path to a JSON or YAML config file (env CONFIG_FILE)")
+	fs.Func("set", "override a config key, e.g. -set pipeline.workers=8 (repeatable)", func(v string) error {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("want key=value, got %q", v)
+		}
+		src.Overrides[strings.TrimSpace(key)] = value
+		return nil
+	})
+
+	shortcuts := []struct{ name, key, usage string }{
+		{"api-addr", "api.addr", "HTTP listen address"},
+		{"log-level", "log.level", "log level: debug, info, warn, error"},
+		{"window", "aggregator.window", "aggregation window"},
+		{"workers", "pipeline.workers", "number of pipeline workers"},
+	}
+	for _, s := range shortcuts {
+		key := s.key
+		fs.Func(s.name, s.usage+" (same as -set "+key+"=...)", func(v string) error {
+			src.Overrides[key] = v
+			return nil
+		})
+	}
+
+	return src
+}
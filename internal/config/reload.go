@@ -0,0 +1,52 @@
+package config
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// Diff returns the keys whose values differ between old and new.
+func Diff(old, new *Config) []string {
+	var changed []string
+	for _, f := range fields {
+		if f.get(old) != f.get(new) {
+			changed = append(changed, f.key)
+		}
+	}
+	return changed
+}
+
+// Watch polls path every interval and signals on the returned channel when
+// its size or modification time changes. The channel is closed when ctx ends.
+func Watch(ctx context.Context, path string, interval time.Duration) <-chan struct{} {
+	changed := make(chan struct{}, 1)
+
+	go func() {
+		defer close(changed)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		last, _ := os.Stat(path)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				info, err := os.Stat(path)
+				if err != nil {
+					continue
+				}
+				if last == nil || !info.ModTime().Equal(last.ModTime()) || info.Size() != last.Size() {
+					last = info
+					select {
+					case changed <- struct{}{}:
+					default:
+					}
+				}
+			}
+		}
+	}()
+
+	return changed
+}
@@ -0,0 +1,270 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration (%d problems):\n  %s", len(e.Problems), strings.Join(e.Problems, "\n  "))
+}
+
+func (e *ValidationError) add(format string, args ...any) {
+	e.Problems = append(e.Problems, fmt.Sprintf(format, args...))
+}
+
+var (
+	validLogLevels = []string{"debug", "info", "warn", "error"}
+	validSSLModes  = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	validStorages  = []string{"postgres", "memory", "file"}
+	validRoles     = []string{"all", "api"}
+	validKeyStores = []string{"file", "postgres"}
+	validLimiters  = []string{"memory", "redis"}
+)
+
+// Validate returns every problem found in the configuration.
+func (c *Config) Validate() []string {
+	v := &ValidationError{}
+
+	if c.Env == "" {
+		v.add("env: must not be empty")
+	}
+	if !contains(validLogLevels, c.Log.Level) {
+		v.add("log.level: %q is not one of %s", c.Log.Level, strings.Join(validLogLevels, ", "))
+	}
+	if _, _, err := net.SplitHostPort(c.APIAddr); err != nil {
+		v.add("api.addr: %q is not a host:port address", c.APIAddr)
+	}
+	checkPositive(v, "api.batch_limit", c.BatchLimit)
+	for _, origin := range c.WSOrigins {
+		if u, err := url.Parse(origin); origin != "*" && (err != nil || u.Scheme == "" || u.Host == "" || u.Path != "") {
+			v.add("api.ws_origins: %q is not an origin like https://example.com, or *", origin)
+		}
+	}
+	if c.GRPCAddr != "" {
+		if _, _, err := net.SplitHostPort(c.GRPCAddr); err != nil {
+			v.add("grpc.addr: %q is not a host:port address", c.GRPCAddr)
+		} else if c.GRPCAddr == c.APIAddr {
+			v.add("grpc.addr: must differ from api.addr")
+		}
+	}
+	if !contains(validRoles, c.Role) {
+		v.add("role: %q is not one of %s", c.Role, strings.Join(validRoles, ", "))
+	}
+	if c.Role == "api" && (c.Storage != "postgres" || !c.Redis.PubSub) {
+		v.add("role: api instances read ticks from redis pub/sub and need storage postgres with redis.pubsub enabled")
+	}
+	if !contains(validStorages, c.Storage) {
+		v.add("storage: %q is not one of %s", c.Storage, strings.Join(validStorages, ", "))
+	}
+	if c.Storage == "file" {
+		if c.FileStore.Dir == "" {
+			v.add("filestore.dir: must not be empty")
+		}
+		checkPositive(v, "filestore.segment_size", c.FileStore.SegmentSize)
+	}
+
+	if c.Postgres.Host == "" {
+		v.add("postgres.host: must not be empty")
+	}
+	checkPort(v, "postgres.port", c.Postgres.Port)
+	if c.Postgres.User == "" {
+		v.add("postgres.user: must not be empty")
+	}
+	if c.Postgres.DBName == "" {
+		v.add("postgres.dbname: must not be empty")
+	}
+	if !contains(validSSLModes, c.Postgres.SSLMode) {
+		v.add("postgres.sslmode: %q is not one of %s", c.Postgres.SSLMode, strings.Join(validSSLModes, ", "))
+	}
+	checkPositive(v, "postgres.max_open_conns", c.Postgres.MaxOpenConns)
+	if c.Postgres.MaxIdleConns < 0 || c.Postgres.MaxIdleConns > c.Postgres.MaxOpenConns {
+		v.add("postgres.max_idle_conns: must be between 0 and max_open_conns (%d)", c.Postgres.MaxOpenConns)
+	}
+	checkDuration(v, "postgres.conn_max_lifetime", c.Postgres.ConnMaxLifetime)
+	checkDuration(v, "postgres.read_timeout", c.Postgres.ReadTimeout)
+	checkDuration(v, "postgres.write_timeout", c.Postgres.WriteTimeout)
+	checkDuration(v, "postgres.batch_timeout", c.Postgres.BatchTimeout)
+	if c.Postgres.CopyThreshold < 0 {
+		v.add("postgres.copy_threshold: must not be negative (0 disables COPY)")
+	}
+
+	if c.Redis.Host == "" {
+		v.add("redis.host: must not be empty")
+	}
+	checkPort(v, "redis.port", c.Redis.Port)
+	if c.Redis.DB < 0 || c.Redis.DB > 15 {
+		v.add("redis.db: must be between 0 and 15")
+	}
+	checkPositive(v, "redis.pool_size", c.Redis.PoolSize)
+	checkDuration(v, "redis.ttl", c.RedisTTL)
+	if c.Redis.TickHorizon < 0 {
+		v.add("redis.tick_horizon: must not be negative (0 disables the tick window)")
+	}
+
+	checkDuration(v, "aggregator.window", c.AggregatorWindow)
+	checkDuration(v, "cache.cleanup_interval", c.CleanupInterval)
+	checkDuration(v, "cache.stale_after", c.StaleAfter)
+	if c.FlushInterval < 0 {
+		v.add("cache.flush_interval: must not be negative (0 writes every tick to redis)")
+	}
+	if c.QueryCacheTTL < 0 {
+		v.add("cache.query_ttl: must not be negative (0 disables the query cache)")
+	}
+	checkDuration(v, "config.watch_interval", c.WatchInterval)
+
+	checkPositive(v, "pipeline.workers", c.Pipeline.Workers)
+	checkPositive(v, "pipeline.input_buffer", c.Pipeline.InputBuffer)
+	checkPositive(v, "pipeline.output_buffer", c.Pipeline.OutputBuffer)
+
+	checkDuration(v, "ingest.reconnect_delay", c.Ingest.ReconnectDelay)
+	checkDuration(v, "ingest.dial_timeout", c.Ingest.DialTimeout)
+	checkDuration(v, "ingest.test_interval", c.Ingest.TestInterval)
+
+	checkDuration(v, "maintenance.interval", c.Maintenance.Interval)
+	checkDuration(v, "retention.raw", c.Maintenance.RawRetention)
+	checkDuration(v, "retention.hourly", c.Maintenance.HourlyRetention)
+	if c.Maintenance.HourlyRetention < c.Maintenance.RawRetention {
+		v.add("retention.hourly: must not be shorter than retention.raw (%s)", c.Maintenance.RawRetention)
+	}
+	if c.Maintenance.PartitionsAhead < 0 {
+		v.add("maintenance.partitions_ahead: must not be negative")
+	}
+
+	if c.Leader.Enabled {
+		if c.Role != "all" || c.Storage != "postgres" || !c.Redis.PubSub {
+			v.add("leader.enabled: needs role all and storage postgres with redis.pubsub enabled")
+		}
+		if c.Leader.Lease < 3*time.Second {
+			v.add("leader.lease: must be at least 3s")
+		}
+		if c.Leader.Key == "" {
+			v.add("leader.key: must not be empty")
+		}
+	}
+
+	if !contains(validKeyStores, c.Auth.Store) {
+		v.add("auth.store: %q is not one of %s", c.Auth.Store, strings.Join(validKeyStores, ", "))
+	}
+	if c.Auth.Store == "postgres" && c.Storage != "postgres" {
+		v.add("auth.store: postgres needs storage postgres")
+	}
+	if c.Auth.Store == "file" && (c.Auth.KeysFile == "" || c.Auth.AuditFile == "") {
+		v.add("auth.keys_file, auth.audit_file: must not be empty with auth.store file")
+	}
+
+	if c.RateLimit.Enabled {
+		if !contains(validLimiters, c.RateLimit.Store) {
+			v.add("ratelimit.store: %q is not one of %s", c.RateLimit.Store, strings.Join(validLimiters, ", "))
+		}
+		if c.RateLimit.Store == "redis" && c.Storage != "postgres" {
+			v.add("ratelimit.store: redis needs storage postgres")
+		}
+		checkPositive(v, "ratelimit.rate", c.RateLimit.Rate)
+		checkPositive(v, "ratelimit.burst", c.RateLimit.Burst)
+		if c.RateLimit.HistoryCost < 1 || c.RateLimit.HistoryCost > c.RateLimit.Burst {
+			v.add("ratelimit.history_cost: must be between 1 and ratelimit.burst (%d), or no period query could ever be admitted", c.RateLimit.Burst)
+		}
+	}
+
+	if c.Spread.Enabled {
+		checkPositive(v, "spread.threshold_bps", c.Spread.ThresholdBps)
+		if c.Spread.MinDuration < 0 {
+			v.add("spread.min_duration: must not be negative, got %s", c.Spread.MinDuration)
+		}
+		checkDuration(v, "spread.max_age", c.Spread.MaxAge)
+		checkDuration(v, "spread.record_interval", c.Spread.RecordInterval)
+		checkDuration(v, "spread.retention", c.Spread.Retention)
+	}
+
+	if c.Alerts.Enabled {
+		checkDuration(v, "alerts.eval_interval", c.Alerts.EvalInterval)
+		checkDuration(v, "alerts.refresh_interval", c.Alerts.RefreshInterval)
+		if c.Alerts.Cooldown < 0 {
+			v.add("alerts.cooldown: must not be negative, got %s", c.Alerts.Cooldown)
+		}
+		checkDuration(v, "alerts.webhook_timeout", c.Alerts.WebhookTimeout)
+		if c.Alerts.Retries < 0 {
+			v.add("alerts.retries: must not be negative, got %d", c.Alerts.Retries)
+		}
+		checkDuration(v, "alerts.retry_backoff", c.Alerts.RetryBackoff)
+	}
+
+	if c.Indicators.Enabled {
+		checkPositive(v, "indicators.track_after", c.Indicators.TrackAfter)
+		checkDuration(v, "indicators.track_ttl", c.Indicators.TrackTTL)
+		if c.Indicators.MaxTracked < 0 {
+			v.add("indicators.max_tracked: must not be negative, got %d", c.Indicators.MaxTracked)
+		}
+	}
+
+	if len(c.Pairs) == 0 {
+		v.add("pairs: at least one pair is required")
+	}
+	seenPairs := make(map[string]bool)
+	for _, p := range c.Pairs {
+		if seenPairs[p] {
+			v.add("pairs: duplicate pair %q", p)
+		}
+		seenPairs[p] = true
+	}
+
+	v.Problems = append(v.Problems, ValidateExchanges(c.Exchanges)...)
+	return v.Problems
+}
+
+// ValidateExchanges returns every problem with a live exchange list, as
+// Validate reports them.
+func ValidateExchanges(exchanges []Exchange) []string {
+	v := &ValidationError{}
+	if len(exchanges) == 0 {
+		v.add("exchanges: at least one exchange is required")
+	}
+	seen := make(map[string]bool)
+	for i, ex := range exchanges {
+		if ex.Name == "" {
+			v.add("exchanges[%d].name: must not be empty", i)
+		} else if seen[ex.Name] {
+			v.add("exchanges[%d].name: duplicate exchange %q", i, ex.Name)
+		}
+		seen[ex.Name] = true
+		if _, _, err := net.SplitHostPort(ex.Address); err != nil {
+			v.add("exchanges[%d].address: %q is not a host:port address", i, ex.Address)
+		}
+	}
+	return v.Problems
+}
+
+func checkPort(v *ValidationError, key string, port int) {
+	if port < 1 || port > 65535 {
+		v.add("%s: %d is out of range 1-65535", key, port)
+	}
+}
+
+func checkPositive(v *ValidationError, key string, n int) {
+	if n <= 0 {
+		v.add("%s: must be positive, got %d", key, n)
+	}
+}
+
+func checkDuration(v *ValidationError, key string, d time.Duration) {
+	if d <= 0 {
+		v.add("%s: must be positive, got %s", key, d)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func containsPrefix(problems []string, prefix string) bool {
+	for _, p := range problems {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestValidateDefaults(t *testing.T) {
+	if problems := Default().Validate(); len(problems) != 0 {
+		t.Errorf("defaults have problems: %q", problems)
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	c := Default()
+	c.Log.Level = "verbose"
+	c.Redis.Port = 70000
+	c.AggregatorWindow = -time.Second
+	c.Exchanges = []Exchange{{Name: "ex1", Address: "h:1"}, {Name: "ex1", Address: "nowhere"}}
+
+	problems := c.Validate()
+	for _, prefix := range []string{
+		"log.level:",
+		"redis.port:",
+		"aggregator.window:",
+		"exchanges[1].name: duplicate exchange",
+		"exchanges[1].address:",
+	} {
+		if !containsPrefix(problems, prefix) {
+			t.Errorf("no %q problem in %q", prefix, problems)
+		}
+	}
+	if len(problems) != 5 {
+		t.Errorf("got %d problems, want 5: %q", len(problems), problems)
+	}
+
+	err := &ValidationError{Problems: problems}
+	if msg := err.Error(); !strings.HasPrefix(msg, "invalid configuration (5 problems):\n  log.level:") || strings.Count(msg, "\n  ") != 5 {
+		t.Errorf("Error() = %q, want one indented line per problem", msg)
+	}
+}
+
+func TestValidateHistoryCostFitsBurst(t *testing.T) {
+	for _, tc := range []struct {
+		burst, cost int
+		ok          bool
+	}{
+		{40, 5, true},
+		{5, 5, true},
+		{4, 5, false},
+		{40, 0, false},
+	} {
+		c := Default()
+		c.RateLimit.Burst, c.RateLimit.HistoryCost = tc.burst, tc.cost
+		if found := containsPrefix(c.Validate(), "ratelimit.history_cost:"); found == tc.ok {
+			t.Errorf("burst %d, history_cost %d: rejected = %v, want %v", tc.burst, tc.cost, found, !tc.ok)
+		}
+	}
+}
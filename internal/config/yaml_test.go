@@ -0,0 +1,83 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	src := `
+# a comment line
+---
+env: production   # trailing comment
+log:
+  level: "warn"
+postgres:
+  host: 'db # not a comment'
+  password: "p#ss"
+  port: 5433
+redis:
+  pool:
+    size: 4
+pairs: [BTCUSDT, "ETHUSDT", 'SOLUSDT']
+empty: []
+auth:
+  store:
+exchanges:
+  - name: ex1
+    address: "127.0.0.1:40101"
+  -
+    name: ex2
+    address: 127.0.0.1:40102
+tags:
+- one
+-   'two: quoted'
+`
+	got, err := parseYAML([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"env": "production",
+		"log": map[string]any{"level": "warn"},
+		"postgres": map[string]any{
+			"host":     "db # not a comment",
+			"password": "p#ss",
+			"port":     "5433",
+		},
+		"redis": map[string]any{"pool": map[string]any{"size": "4"}},
+		"pairs": []any{"BTCUSDT", "ETHUSDT", "SOLUSDT"},
+		"empty": []any{},
+		"auth":  map[string]any{"store": nil},
+		"exchanges": []any{
+			map[string]any{"name": "ex1", "address": "127.0.0.1:40101"},
+			map[string]any{"name": "ex2", "address": "127.0.0.1:40102"},
+		},
+		"tags": []any{"one", "two: quoted"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseYAML =\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name, src, err string
+	}{
+		{"deeper key", "log:\n  level: info\n    extra: 1\n", "line 3: unexpected indentation"},
+		{"shallower key", "log:\n    level: info\n  format: json\n", "line 3: unexpected indentation"},
+		{"indented first key", "  env: dev\nrole: all\n", "line 2: unexpected indentation"},
+		{"tab", "log:\n\tlevel: info\n", "line 2: tabs are not allowed"},
+		{"no colon", "env production\n", "line 1: expected \"key: value\""},
+		{"duplicate", "env: a\nenv: b\n", "line 2: duplicate key \"env\""},
+		{"list in mapping", "log:\n  level: info\n  - x\n", "line 3: list item where a key was expected"},
+		{"deeper list item", "pairs:\n  - a\n    - b\n", "line 3: unexpected indentation"},
+		{"top-level list", "- a\n- b\n", "top level must be a mapping"},
+	} {
+		_, err := parseYAML([]byte(tc.src))
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: err = %v, want %q", tc.name, err, tc.err)
+		}
+	}
+}
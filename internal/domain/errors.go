@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TimeoutError is returned when a storage operation exceeds its deadline.
+// It unwraps to context.DeadlineExceeded.
+type TimeoutError struct {
+	Op      string
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Timeout > 0 {
+		return fmt.Sprintf("%s timed out after %s", e.Op, e.Timeout)
+	}
+	return fmt.Sprintf("%s timed out", e.Op)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// Is makes a timeout also count as ErrUnavailable.
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrUnavailable
+}
+
+func IsTimeout(err error) bool {
+	var te *TimeoutError
+	return errors.As(err, &te)
+}
+
+// Error kinds shared by the adapters. Wrap them with %w so the API can map
+// failures to status codes without comparing strings.
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrUnavailable     = errors.New("unavailable")
+	ErrInvalidArgument = errors.New("invalid argument")
+	ErrConflict        = errors.New("conflict")
+	ErrUnauthenticated = errors.New("unauthenticated")
+	ErrForbidden       = errors.New("forbidden")
+)
@@ -9,6 +9,12 @@ type PriceUpdate struct {
 	Time     time.Time
 }
 
+// PairKey names one pair on one exchange.
+type PairKey struct {
+	Exchange string
+	Pair     string
+}
+
 type PriceStats struct {
 	Exchange  string
 	Pair      string
@@ -17,3 +23,140 @@ type PriceStats struct {
 	Min       float64
 	Max       float64
 }
+
+// SpreadSample is the spread of a pair across exchanges at one moment: the
+// highest and lowest current price and their gap in basis points of the
+// midpoint.
+type SpreadSample struct {
+	Pair         string
+	Time         time.Time
+	High         float64
+	HighExchange string
+	Low          float64
+	LowExchange  string
+	Bps          float64
+	Exchanges    int
+}
+
+// Alert kinds.
+const (
+	AlertAbove = "above" // the price crosses up to Threshold or beyond
+	AlertBelow = "below" // the price crosses down to Threshold or beyond
+	AlertMove  = "move"  // the price moves Threshold percent within Period
+	AlertStale = "stale" // no tick arrives for Period
+)
+
+// Alert is a condition on one pair of one exchange whose notifications are
+// posted to WebhookURL, signed with Secret.
+type Alert struct {
+	ID         string
+	Name       string
+	Kind       string
+	Exchange   string
+	Pair       string
+	Threshold  float64
+	Period     time.Duration
+	WebhookURL string
+	Secret     string
+	// Cooldown is the least time between two notifications of the alert.
+	Cooldown  time.Duration
+	CreatedAt time.Time
+}
+
+// AlertEvent is one firing of an alert. ID stays the same across delivery
+// attempts so receivers can drop duplicates.
+type AlertEvent struct {
+	ID      string
+	AlertID string
+	Time    time.Time
+	Price   float64 // zero for stale alerts
+	// Move is the percent change for move alerts.
+	Move    float64
+	Message string
+}
+
+// AlertDelivery is the outcome of posting an event to a webhook.
+type AlertDelivery struct {
+	AlertID    string
+	EventID    string
+	Time       time.Time
+	Attempts   int
+	StatusCode int    // of the last attempt; zero if no response arrived
+	Error      string // empty when the webhook accepted the event
+}
+
+// RetentionPolicy says how long each stored resolution is kept and how many
+// partitions to create ahead of time.
+type RetentionPolicy struct {
+	Raw             time.Duration
+	Hourly          time.Duration
+	PartitionsAhead int
+}
+
+// MaintenanceReport describes what one storage maintenance run changed.
+type MaintenanceReport struct {
+	CreatedPartitions []string
+	DroppedPartitions []string
+	RolledUpRows      int64
+	PurgedRows        int64
+}
+
+// CacheCleanupReport describes one pass over the cached latest prices.
+type CacheCleanupReport struct {
+	Checked int
+	Removed int
+}
+
+// APIKey is a client credential. Only the SHA-256 hash of the secret is
+// stored; the secret itself is shown once when the key is created.
+type APIKey struct {
+	ID        string
+	Name      string
+	Hash      string
+	Scopes    []string
+	CreatedAt time.Time
+}
+
+func (k APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditEntry records one admin action and who performed it.
+type AuditEntry struct {
+	Time      time.Time
+	KeyID     string
+	KeyName   string
+	Action    string
+	Target    string
+	RequestID string
+	Error     string // empty when the action succeeded
+}
+
+// RateLimitResult is the outcome of taking tokens from a client's bucket.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int // bucket size
+	Remaining  int
+	RetryAfter time.Duration // until the request would be allowed; zero if it was
+	Reset      time.Duration // until the bucket is full again
+}
+
+// NewRateLimitResult describes a token bucket refilled at rate tokens per
+// second up to burst, holding tokens after a take of cost was decided.
+func NewRateLimitResult(allowed bool, tokens, rate float64, burst, cost int) RateLimitResult {
+	res := RateLimitResult{
+		Allowed:   allowed,
+		Limit:     burst,
+		Remaining: int(tokens),
+		Reset:     time.Duration((float64(burst) - tokens) / rate * float64(time.Second)),
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration((float64(cost) - tokens) / rate * float64(time.Second))
+	}
+	return res
+}
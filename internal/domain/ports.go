@@ -11,17 +11,102 @@ type Cache interface {
 	GetLatest(ctx context.Context, exchange, pair string) (PriceUpdate, error)
 }
 
+// latest prices of many pairs in one round trip; pairs without a price are
+// left out, and on error the result holds what was read before it
+type BatchCache interface {
+	GetLatestMany(ctx context.Context, keys []PairKey) (map[PairKey]PriceUpdate, error)
+}
+
+// recent ticks kept by the cache, for periods the aggregator has not stored yet
+type TickWindow interface {
+	GetTicks(ctx context.Context, exchange, pair string, since time.Time) ([]PriceUpdate, error)
+	TickHorizon() time.Duration
+}
+
+// live ticks shared between instances; caches publish what they are given
+type TickSubscriber interface {
+	Subscribe(ctx context.Context) (<-chan PriceUpdate, error)
+}
+
+// a lock with a lease, shared between instances to elect a leader
+type LeaseLock interface {
+	Acquire(ctx context.Context, ttl time.Duration) (bool, error)
+	Renew(ctx context.Context, ttl time.Duration) (bool, error)
+	Release(ctx context.Context) error
+}
+
+// API keys, looked up by the hash of their secret
+type KeyStore interface {
+	CreateKey(ctx context.Context, key APIKey) error
+	FindKey(ctx context.Context, hash string) (APIKey, error)
+	ListKeys(ctx context.Context) ([]APIKey, error)
+	DeleteKey(ctx context.Context, id string) error
+}
+
+type AuditLog interface {
+	RecordAudit(ctx context.Context, entry AuditEntry) error
+}
+
+// per-client token buckets; cost is the number of tokens a request takes
+type RateLimiter interface {
+	Take(ctx context.Context, key string, cost int) (RateLimitResult, error)
+}
+
 // postgres
 type PriceRepository interface {
-	StoreStats(stat PriceStats) error
-	StoreStatsBatch(stats []PriceStats) error
-	GetStats(pair, exchange string, since time.Time) ([]PriceStats, error)
+	StoreStats(ctx context.Context, stat PriceStats) error
+	StoreStatsBatch(ctx context.Context, stats []PriceStats) error
+	GetStats(ctx context.Context, pair, exchange string, since time.Time) ([]PriceStats, error)
 	GetLatest(ctx context.Context, exchange, pair string) (PriceStats, error)
 	GetByPeriod(ctx context.Context, exchange, pair string, period time.Duration) ([]PriceStats, error)
 }
 
+// stored windows of many pairs in one query; pairs without rows are left out
+type BatchRepository interface {
+	GetLatestMany(ctx context.Context, keys []PairKey) (map[PairKey]PriceStats, error)
+	GetByPeriodMany(ctx context.Context, keys []PairKey, period time.Duration) (map[PairKey][]PriceStats, error)
+}
+
+// hourly rollups of the stored windows, each stamped when its hour ends
+type RollupRepository interface {
+	GetHourly(ctx context.Context, exchange, pair string, since time.Time) ([]PriceStats, error)
+}
+
+// recorded cross-exchange spreads
+type SpreadStore interface {
+	StoreSpreads(ctx context.Context, samples []SpreadSample) error
+	GetSpreads(ctx context.Context, pair string, since time.Time) ([]SpreadSample, error)
+	PruneSpreads(ctx context.Context, before time.Time) (int64, error)
+}
+
+// registered alerts and the history of their webhook deliveries, newest
+// first
+type AlertStore interface {
+	CreateAlert(ctx context.Context, alert Alert) error
+	GetAlert(ctx context.Context, id string) (Alert, error)
+	ListAlerts(ctx context.Context) ([]Alert, error)
+	DeleteAlert(ctx context.Context, id string) error
+	RecordDelivery(ctx context.Context, delivery AlertDelivery) error
+	ListDeliveries(ctx context.Context, alertID string, limit int) ([]AlertDelivery, error)
+}
+
+// storage maintenance (partitions, rollups, retention)
+type StorageMaintainer interface {
+	Maintain(ctx context.Context, policy RetentionPolicy) (MaintenanceReport, error)
+}
+
+// cache maintenance (stale latest prices)
+type CacheCleaner interface {
+	CleanStale(ctx context.Context, staleAfter time.Duration) (CacheCleanupReport, error)
+}
+
 // http
 type ExchangeClient interface {
 	Start(ctx context.Context, out chan<- PriceUpdate) error
 	Stop() error
 }
+
+// posts an alert event once; status is the response code, zero if none came
+type AlertNotifier interface {
+	Notify(ctx context.Context, alert Alert, event AlertEvent) (status int, err error)
+}
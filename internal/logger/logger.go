@@ -2,29 +2,60 @@ package logger
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"strings"
 )
 
-var Log *slog.Logger
+var (
+	Log   *slog.Logger
+	level = new(slog.LevelVar)
+)
+
+func Init(env, lvl string) {
+	InitWithWriter(os.Stdout, env, lvl)
+}
 
-func Init(env string) {
+// InitWithWriter is Init with a custom destination; CLI commands log to
+// stderr so their stdout stays machine-readable.
+func InitWithWriter(w io.Writer, env, lvl string) {
+	if err := SetLevel(lvl); err != nil {
+		level.Set(slog.LevelDebug)
+	}
 	opts := slog.HandlerOptions{
-		Level: slog.LevelDebug,
+		Level: level,
 	}
 
 	var handler slog.Handler
 
 	if env == "development" {
-		handler = NewPrettyHandler(os.Stdout, opts)
+		handler = NewPrettyHandler(w, opts)
 	} else {
-		handler = slog.NewJSONHandler(os.Stdout, &opts)
+		handler = slog.NewJSONHandler(w, &opts)
 	}
 
 	Log = slog.New(handler)
 	slog.SetDefault(Log)
 }
 
+func SetLevel(lvl string) error {
+	switch strings.ToLower(lvl) {
+	case "debug":
+		level.Set(slog.LevelDebug)
+	case "info":
+		level.Set(slog.LevelInfo)
+	case "warn":
+		level.Set(slog.LevelWarn)
+	case "error":
+		level.Set(slog.LevelError)
+	default:
+		return fmt.Errorf("unknown log level %q", lvl)
+	}
+	return nil
+}
+
 func Info(msg string, args ...any) {
 	Log.Info(msg, args...)
 }
@@ -3,18 +3,18 @@ package logger
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
-	"os"
 	"strings"
 )
 
 type PrettyHandler struct {
-	out    *os.File
+	out    io.Writer
 	opts   slog.HandlerOptions
 	levels map[slog.Level]string
 }
 
-func NewPrettyHandler(out *os.File, opts slog.HandlerOptions) slog.Handler {
+func NewPrettyHandler(out io.Writer, opts slog.HandlerOptions) slog.Handler {
 	return &PrettyHandler{
 		out:  out,
 		opts: opts,
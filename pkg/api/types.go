@@ -0,0 +1,273 @@
+// Package api holds the JSON bodies of the marketflow HTTP API. The server
+// encodes them and pkg/client decodes them, and /openapi.json describes them.
+package api
+
+import "time"
+
+// LatestPrice is returned by /prices/latest.
+type LatestPrice struct {
+	Exchange string    `json:"exchange"`
+	Pair     string    `json:"pair"`
+	Price    float64   `json:"price"`
+	Time     time.Time `json:"time"`
+	Source   string    `json:"source"`             // "cache" or "storage"
+	Degraded bool      `json:"degraded,omitempty"` // the shared cache is unreachable
+}
+
+// Price is returned by /prices/highest, /prices/lowest and /prices/average.
+// Time is when the extreme was seen and is absent for averages.
+type Price struct {
+	Exchange string     `json:"exchange"`
+	Pair     string     `json:"pair"`
+	Price    float64    `json:"price"`
+	Time     *time.Time `json:"time,omitempty"`
+}
+
+// History is returned by /prices/history: the aggregated windows of a
+// period, oldest first. Ticks newer than the last stored window make up
+// one more, partial window stamped with the last tick.
+type History struct {
+	Exchange string   `json:"exchange"`
+	Pair     string   `json:"pair"`
+	Period   string   `json:"period"`
+	Windows  []Window `json:"windows"`
+}
+
+// Stats is returned by /prices/stats: how the price moved over a period.
+// Source is "ticks" when the period was answered from raw ticks and
+// "storage" when from stored windows, and Resolution is the median spacing
+// of those samples. Stored windows are resampled to hours when the period
+// reaches the hourly rollups or spans an outage. Returns are log returns
+// between consecutive samples. RealizedVolatility is the square root of
+// their summed squares, and AnnualizedVolatility scales it to a year.
+// MaxDrawdown is the largest fall from a running peak, as a fraction of the
+// peak.
+type Stats struct {
+	Exchange             string    `json:"exchange"`
+	Pair                 string    `json:"pair"`
+	Period               string    `json:"period"`
+	Source               string    `json:"source"`
+	Resolution           string    `json:"resolution"`
+	Samples              int       `json:"samples"`
+	From                 time.Time `json:"from"`
+	To                   time.Time `json:"to"`
+	First                float64   `json:"first"`
+	Last                 float64   `json:"last"`
+	Mean                 float64   `json:"mean"`
+	StdDev               float64   `json:"stddev"`
+	P5                   float64   `json:"p5"`
+	P50                  float64   `json:"p50"`
+	P95                  float64   `json:"p95"`
+	LogReturn            float64   `json:"log_return"`
+	MeanLogReturn        float64   `json:"mean_log_return"`
+	LogReturnStdDev      float64   `json:"log_return_stddev"`
+	RealizedVolatility   float64   `json:"realized_volatility"`
+	AnnualizedVolatility float64   `json:"annualized_volatility"`
+	MaxDrawdown          float64   `json:"max_drawdown"`
+}
+
+type Window struct {
+	Time    time.Time `json:"time"`
+	Average float64   `json:"average"`
+	Min     float64   `json:"min"`
+	Max     float64   `json:"max"`
+}
+
+// Tick is one message on the /ws/prices stream.
+type Tick struct {
+	Exchange string    `json:"exchange"`
+	Pair     string    `json:"pair"`
+	Price    float64   `json:"price"`
+	Time     time.Time `json:"time"`
+}
+
+// Spread is returned by /analytics/spread: how far apart the exchanges
+// price a pair now and, with ?period=, over that period, oldest first.
+// Current is absent when fewer than two exchanges have a fresh price.
+type Spread struct {
+	Pair    string          `json:"pair"`
+	Current *SpreadPoint    `json:"current,omitempty"`
+	Prices  []ExchangePrice `json:"prices,omitempty"`
+	History []SpreadPoint   `json:"history,omitempty"`
+}
+
+// SpreadPoint is a spread at one moment. Bps is the gap between the highest
+// and lowest price in basis points of their midpoint.
+type SpreadPoint struct {
+	Time         time.Time `json:"time"`
+	Bps          float64   `json:"bps"`
+	High         float64   `json:"high"`
+	HighExchange string    `json:"high_exchange"`
+	Low          float64   `json:"low"`
+	LowExchange  string    `json:"low_exchange"`
+	Exchanges    int       `json:"exchanges"`
+}
+
+type ExchangePrice struct {
+	Exchange string    `json:"exchange"`
+	Price    float64   `json:"price"`
+	Time     time.Time `json:"time"`
+}
+
+// Indicator is returned by /indicators: the latest value of a technical
+// indicator over Window buckets of Resolution. Time is the start of the
+// newest bucket, which may still be open. Missing counts the buckets in the
+// window that had no data and repeat the previous close. Upper and Lower are
+// only set for Bollinger bands, where Value is the middle band. Source is
+// "stream", "windows" or "hourly".
+type Indicator struct {
+	Exchange   string    `json:"exchange"`
+	Pair       string    `json:"pair"`
+	Type       string    `json:"type"`
+	Window     int       `json:"window"`
+	Resolution string    `json:"resolution"`
+	Time       time.Time `json:"time"`
+	Value      float64   `json:"value"`
+	Upper      *float64  `json:"upper,omitempty"`
+	Lower      *float64  `json:"lower,omitempty"`
+	Buckets    int       `json:"buckets"`
+	Missing    int       `json:"missing"`
+	Source     string    `json:"source"`
+}
+
+// Mode is returned by /mode/test and /mode/live.
+type Mode struct {
+	Mode string `json:"mode"`
+}
+
+// Exchange is an exchange the feed connects to in live mode, as listed by
+// GET /admin/exchanges. A PUT takes a list of them; Active is ignored there.
+type Exchange struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Active  bool   `json:"active"`
+}
+
+// Health is returned by /health. Each store is "ok", "degraded" or
+// "unavailable".
+type Health struct {
+	Redis    string `json:"redis"`
+	Postgres string `json:"postgres"`
+}
+
+// Key describes an API key. Secret is only set in the response that
+// created it.
+type Key struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+	Secret    string    `json:"key,omitempty"`
+}
+
+// KeyRequest is the body of POST /admin/keys.
+type KeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// Error is the body of every failed request.
+type Error struct {
+	Error ErrorDetail `json:"error"`
+}
+
+type ErrorDetail struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// BatchRequest is the body of POST /prices/batch.
+type BatchRequest struct {
+	Items []BatchItem `json:"items"`
+}
+
+// BatchItem asks for one metric: "latest", "highest", "lowest" or
+// "average". Exchange defaults to ex1 and Period, which latest ignores, to
+// one minute.
+type BatchItem struct {
+	Exchange string `json:"exchange,omitempty"`
+	Pair     string `json:"pair"`
+	Metric   string `json:"metric"`
+	Period   string `json:"period,omitempty"`
+}
+
+// BatchResponse holds one result per requested item, in request order.
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+// BatchResult is either a price or, when that item failed, an error; the
+// other items are unaffected.
+type BatchResult struct {
+	Exchange string       `json:"exchange"`
+	Pair     string       `json:"pair"`
+	Metric   string       `json:"metric"`
+	Period   string       `json:"period,omitempty"`
+	Price    *float64     `json:"price,omitempty"`
+	Time     *time.Time   `json:"time,omitempty"`
+	Source   string       `json:"source,omitempty"`
+	Error    *ErrorDetail `json:"error,omitempty"`
+}
+
+// Alert describes a registered alert. Secret, the key of the webhook
+// signatures, is only set in the response that created it.
+type Alert struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name,omitempty"`
+	Kind       string    `json:"kind"`
+	Exchange   string    `json:"exchange"`
+	Pair       string    `json:"pair"`
+	Threshold  float64   `json:"threshold,omitempty"`
+	Period     string    `json:"period,omitempty"`
+	WebhookURL string    `json:"webhook_url"`
+	Cooldown   string    `json:"cooldown"`
+	CreatedAt  time.Time `json:"created_at"`
+	Secret     string    `json:"secret,omitempty"`
+}
+
+// AlertRequest is the body of POST /alerts. Kind is "above" or "below"
+// (Threshold is a price), "move" (Threshold is a percent change within
+// Period) or "stale" (no tick for Period). Exchange defaults to ex1 and
+// Cooldown to the server's alerts.cooldown.
+type AlertRequest struct {
+	Name       string  `json:"name,omitempty"`
+	Kind       string  `json:"kind"`
+	Exchange   string  `json:"exchange,omitempty"`
+	Pair       string  `json:"pair"`
+	Threshold  float64 `json:"threshold,omitempty"`
+	Period     string  `json:"period,omitempty"`
+	WebhookURL string  `json:"webhook_url"`
+	Cooldown   string  `json:"cooldown,omitempty"`
+}
+
+// AlertDelivery is one entry of GET /alerts/{id}/deliveries. StatusCode is
+// that of the last attempt and Error is absent when the webhook accepted
+// the event.
+type AlertDelivery struct {
+	EventID    string    `json:"event_id"`
+	Time       time.Time `json:"time"`
+	Attempts   int       `json:"attempts"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// AlertNotification is the body posted to an alert's webhook. The request
+// carries the headers X-Marketflow-Event (the event ID, the same on every
+// retry), X-Marketflow-Timestamp (Unix seconds) and X-Marketflow-Signature,
+// "sha256=" and the hex HMAC-SHA256 of "{timestamp}.{body}" keyed with the
+// alert's secret.
+type AlertNotification struct {
+	EventID   string    `json:"event_id"`
+	AlertID   string    `json:"alert_id"`
+	Name      string    `json:"name,omitempty"`
+	Kind      string    `json:"kind"`
+	Exchange  string    `json:"exchange"`
+	Pair      string    `json:"pair"`
+	Threshold float64   `json:"threshold,omitempty"`
+	Period    string    `json:"period,omitempty"`
+	Price     float64   `json:"price,omitempty"`
+	Move      float64   `json:"move,omitempty"`
+	Message   string    `json:"message"`
+	Time      time.Time `json:"time"`
+}
@@ -0,0 +1,288 @@
+// Package client is a typed Go client for the marketflow HTTP API.
+//
+//	c := client.New("http://localhost:8080", client.Options{APIKey: key})
+//	price, err := c.Latest(ctx, "ex1", "BTCUSDT")
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketflow/pkg/api"
+)
+
+type Client struct {
+	base   *url.URL
+	apiKey string
+	http   *http.Client
+}
+
+type Options struct {
+	// APIKey is sent as a bearer token when set.
+	APIKey string
+	// HTTPClient defaults to a client with a 30s timeout.
+	HTTPClient *http.Client
+}
+
+// Error is a failed request, decoded from the API's error body.
+type Error struct {
+	Status    int
+	Code      string
+	Message   string
+	RequestID string
+	// RetryAfter is set on rate limited requests.
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("marketflow: %s (%d %s, request %s)", e.Message, e.Status, e.Code, e.RequestID)
+	}
+	return fmt.Sprintf("marketflow: %s (%d %s)", e.Message, e.Status, e.Code)
+}
+
+// IsNotFound reports whether err is an API error for missing data.
+func IsNotFound(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Status == http.StatusNotFound
+}
+
+// New returns a client for the API at baseURL, such as
+// "http://localhost:8080". It panics if baseURL does not parse.
+func New(baseURL string, opts Options) *Client {
+	base, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		panic(fmt.Sprintf("client: invalid base URL %q: %v", baseURL, err))
+	}
+	hc := opts.HTTPClient
+	if hc == nil {
+		hc = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Client{base: base, apiKey: opts.APIKey, http: hc}
+}
+
+// Latest returns the most recent price of pair on exchange.
+func (c *Client) Latest(ctx context.Context, exchange, pair string) (api.LatestPrice, error) {
+	var out api.LatestPrice
+	err := c.do(ctx, http.MethodGet, pricePath("latest", exchange, pair), nil, nil, &out)
+	return out, err
+}
+
+// Highest returns the highest price within the last period. A zero period
+// uses the server's default of one minute.
+func (c *Client) Highest(ctx context.Context, exchange, pair string, period time.Duration) (api.Price, error) {
+	var out api.Price
+	err := c.do(ctx, http.MethodGet, pricePath("highest", exchange, pair), periodQuery(period), nil, &out)
+	return out, err
+}
+
+func (c *Client) Lowest(ctx context.Context, exchange, pair string, period time.Duration) (api.Price, error) {
+	var out api.Price
+	err := c.do(ctx, http.MethodGet, pricePath("lowest", exchange, pair), periodQuery(period), nil, &out)
+	return out, err
+}
+
+func (c *Client) Average(ctx context.Context, exchange, pair string, period time.Duration) (api.Price, error) {
+	var out api.Price
+	err := c.do(ctx, http.MethodGet, pricePath("average", exchange, pair), periodQuery(period), nil, &out)
+	return out, err
+}
+
+// History returns the aggregated windows within the last period.
+func (c *Client) History(ctx context.Context, exchange, pair string, period time.Duration) (api.History, error) {
+	var out api.History
+	err := c.do(ctx, http.MethodGet, pricePath("history", exchange, pair), periodQuery(period), nil, &out)
+	return out, err
+}
+
+// Stats returns volatility, percentile and return figures for the last
+// period.
+func (c *Client) Stats(ctx context.Context, exchange, pair string, period time.Duration) (api.Stats, error) {
+	var out api.Stats
+	err := c.do(ctx, http.MethodGet, pricePath("stats", exchange, pair), periodQuery(period), nil, &out)
+	return out, err
+}
+
+// Batch resolves many items in one request. Each result answers the item at
+// the same index and carries its own error; err is only set when the whole
+// request failed.
+func (c *Client) Batch(ctx context.Context, items []api.BatchItem) ([]api.BatchResult, error) {
+	var out api.BatchResponse
+	err := c.do(ctx, http.MethodPost, "/prices/batch", nil, api.BatchRequest{Items: items}, &out)
+	return out.Results, err
+}
+
+// Spread returns the current spread of pair across exchanges and, with a
+// positive period, the spreads recorded within it.
+func (c *Client) Spread(ctx context.Context, pair string, period time.Duration) (api.Spread, error) {
+	var out api.Spread
+	err := c.do(ctx, http.MethodGet, "/analytics/spread/"+url.PathEscape(pair), periodQuery(period), nil, &out)
+	return out, err
+}
+
+// Indicator returns the latest value of a technical indicator. A zero
+// window or resolution uses the server's default of 20 buckets of one minute.
+func (c *Client) Indicator(ctx context.Context, exchange, pair, kind string, window int, resolution time.Duration) (api.Indicator, error) {
+	query := url.Values{"type": {kind}}
+	if window > 0 {
+		query.Set("window", strconv.Itoa(window))
+	}
+	if resolution > 0 {
+		query.Set("resolution", resolution.String())
+	}
+	path := "/indicators/" + url.PathEscape(pair)
+	if exchange != "" {
+		path = "/indicators/" + url.PathEscape(exchange) + "/" + url.PathEscape(pair)
+	}
+	var out api.Indicator
+	err := c.do(ctx, http.MethodGet, path, query, nil, &out)
+	return out, err
+}
+
+// SetMode switches the instance to "test" or "live".
+func (c *Client) SetMode(ctx context.Context, mode string) (api.Mode, error) {
+	var out api.Mode
+	err := c.do(ctx, http.MethodPost, "/mode/"+url.PathEscape(mode), nil, nil, &out)
+	return out, err
+}
+
+func (c *Client) Health(ctx context.Context) (api.Health, error) {
+	var out api.Health
+	err := c.do(ctx, http.MethodGet, "/health", nil, nil, &out)
+	return out, err
+}
+
+func (c *Client) ListKeys(ctx context.Context) ([]api.Key, error) {
+	var out []api.Key
+	err := c.do(ctx, http.MethodGet, "/admin/keys", nil, nil, &out)
+	return out, err
+}
+
+// CreateKey returns the new key with its secret, which the API shows only
+// once.
+func (c *Client) CreateKey(ctx context.Context, name string, scopes ...string) (api.Key, error) {
+	var out api.Key
+	err := c.do(ctx, http.MethodPost, "/admin/keys", nil, api.KeyRequest{Name: name, Scopes: scopes}, &out)
+	return out, err
+}
+
+func (c *Client) DeleteKey(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/admin/keys/"+url.PathEscape(id), nil, nil, nil)
+}
+
+func (c *Client) ListAlerts(ctx context.Context) ([]api.Alert, error) {
+	var out []api.Alert
+	err := c.do(ctx, http.MethodGet, "/alerts", nil, nil, &out)
+	return out, err
+}
+
+// CreateAlert returns the new alert with the secret its webhook calls are
+// signed with, which the API shows only once.
+func (c *Client) CreateAlert(ctx context.Context, req api.AlertRequest) (api.Alert, error) {
+	var out api.Alert
+	err := c.do(ctx, http.MethodPost, "/alerts", nil, req, &out)
+	return out, err
+}
+
+func (c *Client) DeleteAlert(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/alerts/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// AlertDeliveries returns up to limit of the alert's latest deliveries,
+// newest first. A zero limit uses the server's default of 50.
+func (c *Client) AlertDeliveries(ctx context.Context, id string, limit int) ([]api.AlertDelivery, error) {
+	var query url.Values
+	if limit > 0 {
+		query = url.Values{"limit": {strconv.Itoa(limit)}}
+	}
+	var out []api.AlertDelivery
+	err := c.do(ctx, http.MethodGet, "/alerts/"+url.PathEscape(id)+"/deliveries", query, nil, &out)
+	return out, err
+}
+
+func pricePath(kind, exchange, pair string) string {
+	if exchange == "" {
+		return "/prices/" + kind + "/" + url.PathEscape(pair)
+	}
+	return "/prices/" + kind + "/" + url.PathEscape(exchange) + "/" + url.PathEscape(pair)
+}
+
+func periodQuery(period time.Duration) url.Values {
+	if period <= 0 {
+		return nil
+	}
+	return url.Values{"period": {period.String()}}
+}
+
+func (c *Client) url(path string, query url.Values) string {
+	u := *c.base
+	u.Path += path
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// do sends a request with in as its JSON body, if not nil, and decodes a
+// successful response into out, if not nil.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.url(path, query), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return decodeError(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("marketflow: invalid response from %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+func decodeError(resp *http.Response) error {
+	e := &Error{Status: resp.StatusCode, Code: "unknown", Message: resp.Status}
+	var body api.Error
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&body); err == nil && body.Error.Code != "" {
+		e.Code, e.Message, e.RequestID = body.Error.Code, body.Error.Message, body.Error.RequestID
+	}
+	if s := resp.Header.Get("Retry-After"); s != "" {
+		var secs int
+		if _, err := fmt.Sscan(s, &secs); err == nil {
+			e.RetryAfter = time.Duration(secs) * time.Second
+		}
+	}
+	return e
+}
@@ -0,0 +1,217 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"marketflow/pkg/api"
+)
+
+// Stream is a live tick subscription over /ws/prices.
+type Stream struct {
+	ctx  context.Context
+	conn net.Conn
+	r    *bufio.Reader
+	mu   sync.Mutex // serializes writes
+	stop func() bool
+}
+
+const (
+	wsGUID     = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	wsMaxFrame = 1 << 20
+
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xA
+)
+
+// Stream subscribes to live ticks, optionally only those of exchange and
+// pair. The stream is closed when ctx is done.
+func (c *Client) Stream(ctx context.Context, exchange, pair string) (*Stream, error) {
+	u := *c.base
+	u.Path += "/ws/prices"
+	q := url.Values{}
+	if exchange != "" {
+		q.Set("exchange", exchange)
+	}
+	if pair != "" {
+		q.Set("pair", pair)
+	}
+	u.RawQuery = q.Encode()
+
+	host := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "https" {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "https" {
+		tc := tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+		if err := tc.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tc
+	}
+
+	s, err := c.handshake(ctx, conn, &u)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	s.ctx = ctx
+	s.stop = context.AfterFunc(ctx, func() { s.Close() })
+	return s, nil
+}
+
+func (c *Client) handshake(ctx context.Context, conn net.Conn, u *url.URL) (*Stream, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		defer resp.Body.Close()
+		return nil, decodeError(resp)
+	}
+	sum := sha1.Sum([]byte(key + wsGUID))
+	if resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		return nil, errors.New("marketflow: invalid websocket handshake")
+	}
+	return &Stream{conn: conn, r: r}, nil
+}
+
+// Recv blocks until the next tick. It returns io.EOF once the server has
+// closed the stream, and the context's error once that is done.
+func (s *Stream) Recv() (api.Tick, error) {
+	for {
+		op, payload, err := s.readFrame()
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return api.Tick{}, s.ctx.Err()
+			}
+			return api.Tick{}, err
+		}
+		switch op {
+		case opText:
+			var tick api.Tick
+			if err := json.Unmarshal(payload, &tick); err != nil {
+				return api.Tick{}, fmt.Errorf("marketflow: invalid tick: %w", err)
+			}
+			return tick, nil
+		case opPing:
+			if err := s.writeFrame(opPong, payload); err != nil {
+				return api.Tick{}, err
+			}
+		case opClose:
+			s.writeFrame(opClose, payload[:min(len(payload), 2)])
+			return api.Tick{}, io.EOF
+		}
+	}
+}
+
+func (s *Stream) Close() error {
+	if s.stop != nil {
+		s.stop()
+	}
+	s.writeFrame(opClose, []byte{0x03, 0xE8}) // 1000 normal closure
+	return s.conn.Close()
+}
+
+// readFrame reads one unfragmented server frame; the server never
+// fragments or masks.
+func (s *Stream) readFrame() (byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(s.r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	op := head[0] & 0x0F
+	n := uint64(head[1] & 0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(s.r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(s.r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if n > wsMaxFrame {
+		return 0, nil, fmt.Errorf("marketflow: websocket frame of %d bytes is too large", n)
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(s.r, payload); err != nil {
+		return 0, nil, err
+	}
+	return op, payload, nil
+}
+
+// writeFrame sends a masked frame, as clients must.
+func (s *Stream) writeFrame(op byte, payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	frame := []byte{0x80 | op, 0x80 | byte(len(payload))} // control frames only, < 126 bytes
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return err
+	}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err := s.conn.Write(frame)
+	return err
+}
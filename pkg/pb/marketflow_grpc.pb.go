@@ -0,0 +1,362 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.28.3
+// source: marketflow.proto
+
+// The gRPC API mirrors the HTTP API: the same prices, periods and mode
+// control, plus a server stream of live ticks.
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MarketFlow_Latest_FullMethodName          = "/marketflow.v1.MarketFlow/Latest"
+	MarketFlow_Highest_FullMethodName         = "/marketflow.v1.MarketFlow/Highest"
+	MarketFlow_Lowest_FullMethodName          = "/marketflow.v1.MarketFlow/Lowest"
+	MarketFlow_Average_FullMethodName         = "/marketflow.v1.MarketFlow/Average"
+	MarketFlow_History_FullMethodName         = "/marketflow.v1.MarketFlow/History"
+	MarketFlow_SetMode_FullMethodName         = "/marketflow.v1.MarketFlow/SetMode"
+	MarketFlow_SubscribePrices_FullMethodName = "/marketflow.v1.MarketFlow/SubscribePrices"
+)
+
+// MarketFlowClient is the client API for MarketFlow service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MarketFlowClient interface {
+	Latest(ctx context.Context, in *PriceRequest, opts ...grpc.CallOption) (*LatestPrice, error)
+	Highest(ctx context.Context, in *PeriodRequest, opts ...grpc.CallOption) (*Price, error)
+	Lowest(ctx context.Context, in *PeriodRequest, opts ...grpc.CallOption) (*Price, error)
+	Average(ctx context.Context, in *PeriodRequest, opts ...grpc.CallOption) (*Price, error)
+	History(ctx context.Context, in *PeriodRequest, opts ...grpc.CallOption) (*PriceHistory, error)
+	SetMode(ctx context.Context, in *SetModeRequest, opts ...grpc.CallOption) (*SetModeResponse, error)
+	// SubscribePrices streams every tick the instance sees, optionally only
+	// those of one exchange and pair. A subscriber that falls behind misses
+	// ticks.
+	SubscribePrices(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Tick], error)
+}
+
+type marketFlowClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMarketFlowClient(cc grpc.ClientConnInterface) MarketFlowClient {
+	return &marketFlowClient{cc}
+}
+
+func (c *marketFlowClient) Latest(ctx context.Context, in *PriceRequest, opts ...grpc.CallOption) (*LatestPrice, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LatestPrice)
+	err := c.cc.Invoke(ctx, MarketFlow_Latest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketFlowClient) Highest(ctx context.Context, in *PeriodRequest, opts ...grpc.CallOption) (*Price, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Price)
+	err := c.cc.Invoke(ctx, MarketFlow_Highest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketFlowClient) Lowest(ctx context.Context, in *PeriodRequest, opts ...grpc.CallOption) (*Price, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Price)
+	err := c.cc.Invoke(ctx, MarketFlow_Lowest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketFlowClient) Average(ctx context.Context, in *PeriodRequest, opts ...grpc.CallOption) (*Price, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Price)
+	err := c.cc.Invoke(ctx, MarketFlow_Average_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketFlowClient) History(ctx context.Context, in *PeriodRequest, opts ...grpc.CallOption) (*PriceHistory, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PriceHistory)
+	err := c.cc.Invoke(ctx, MarketFlow_History_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketFlowClient) SetMode(ctx context.Context, in *SetModeRequest, opts ...grpc.CallOption) (*SetModeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetModeResponse)
+	err := c.cc.Invoke(ctx, MarketFlow_SetMode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketFlowClient) SubscribePrices(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Tick], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MarketFlow_ServiceDesc.Streams[0], MarketFlow_SubscribePrices_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeRequest, Tick]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MarketFlow_SubscribePricesClient = grpc.ServerStreamingClient[Tick]
+
+// MarketFlowServer is the server API for MarketFlow service.
+// All implementations must embed UnimplementedMarketFlowServer
+// for forward compatibility.
+type MarketFlowServer interface {
+	Latest(context.Context, *PriceRequest) (*LatestPrice, error)
+	Highest(context.Context, *PeriodRequest) (*Price, error)
+	Lowest(context.Context, *PeriodRequest) (*Price, error)
+	Average(context.Context, *PeriodRequest) (*Price, error)
+	History(context.Context, *PeriodRequest) (*PriceHistory, error)
+	SetMode(context.Context, *SetModeRequest) (*SetModeResponse, error)
+	// SubscribePrices streams every tick the instance sees, optionally only
+	// those of one exchange and pair. A subscriber that falls behind misses
+	// ticks.
+	SubscribePrices(*SubscribeRequest, grpc.ServerStreamingServer[Tick]) error
+	mustEmbedUnimplementedMarketFlowServer()
+}
+
+// UnimplementedMarketFlowServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMarketFlowServer struct{}
+
+func (UnimplementedMarketFlowServer) Latest(context.Context, *PriceRequest) (*LatestPrice, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Latest not implemented")
+}
+func (UnimplementedMarketFlowServer) Highest(context.Context, *PeriodRequest) (*Price, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Highest not implemented")
+}
+func (UnimplementedMarketFlowServer) Lowest(context.Context, *PeriodRequest) (*Price, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Lowest not implemented")
+}
+func (UnimplementedMarketFlowServer) Average(context.Context, *PeriodRequest) (*Price, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Average not implemented")
+}
+func (UnimplementedMarketFlowServer) History(context.Context, *PeriodRequest) (*PriceHistory, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method History not implemented")
+}
+func (UnimplementedMarketFlowServer) SetMode(context.Context, *SetModeRequest) (*SetModeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetMode not implemented")
+}
+func (UnimplementedMarketFlowServer) SubscribePrices(*SubscribeRequest, grpc.ServerStreamingServer[Tick]) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribePrices not implemented")
+}
+func (UnimplementedMarketFlowServer) mustEmbedUnimplementedMarketFlowServer() {}
+func (UnimplementedMarketFlowServer) testEmbeddedByValue()                    {}
+
+// UnsafeMarketFlowServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MarketFlowServer will
+// result in compilation errors.
+type UnsafeMarketFlowServer interface {
+	mustEmbedUnimplementedMarketFlowServer()
+}
+
+func RegisterMarketFlowServer(s grpc.ServiceRegistrar, srv MarketFlowServer) {
+	// If the following call pancis, it indicates UnimplementedMarketFlowServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MarketFlow_ServiceDesc, srv)
+}
+
+func _MarketFlow_Latest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PriceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketFlowServer).Latest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketFlow_Latest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketFlowServer).Latest(ctx, req.(*PriceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarketFlow_Highest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PeriodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketFlowServer).Highest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketFlow_Highest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketFlowServer).Highest(ctx, req.(*PeriodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarketFlow_Lowest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PeriodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketFlowServer).Lowest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketFlow_Lowest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketFlowServer).Lowest(ctx, req.(*PeriodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarketFlow_Average_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PeriodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketFlowServer).Average(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketFlow_Average_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketFlowServer).Average(ctx, req.(*PeriodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarketFlow_History_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PeriodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketFlowServer).History(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketFlow_History_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketFlowServer).History(ctx, req.(*PeriodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarketFlow_SetMode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetModeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketFlowServer).SetMode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarketFlow_SetMode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketFlowServer).SetMode(ctx, req.(*SetModeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarketFlow_SubscribePrices_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MarketFlowServer).SubscribePrices(m, &grpc.GenericServerStream[SubscribeRequest, Tick]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MarketFlow_SubscribePricesServer = grpc.ServerStreamingServer[Tick]
+
+// MarketFlow_ServiceDesc is the grpc.ServiceDesc for MarketFlow service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MarketFlow_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "marketflow.v1.MarketFlow",
+	HandlerType: (*MarketFlowServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Latest",
+			Handler:    _MarketFlow_Latest_Handler,
+		},
+		{
+			MethodName: "Highest",
+			Handler:    _MarketFlow_Highest_Handler,
+		},
+		{
+			MethodName: "Lowest",
+			Handler:    _MarketFlow_Lowest_Handler,
+		},
+		{
+			MethodName: "Average",
+			Handler:    _MarketFlow_Average_Handler,
+		},
+		{
+			MethodName: "History",
+			Handler:    _MarketFlow_History_Handler,
+		},
+		{
+			MethodName: "SetMode",
+			Handler:    _MarketFlow_SetMode_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribePrices",
+			Handler:       _MarketFlow_SubscribePrices_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "marketflow.proto",
+}